@@ -3,9 +3,14 @@ package supabase
 import (
 	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -74,16 +79,61 @@ type AdminUser struct {
 	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
+// AdminUserParams contains the fields of a user. Empty fields are omitted, so
+// GoTrue applies its defaults when creating a user and keeps the existing
+// values when updating one.
 type AdminUserParams struct {
-	Role         string  `json:"role"`
-	Email        string  `json:"email"`
-	Phone        string  `json:"phone"`
-	Password     *string `json:"password"`
-	EmailConfirm bool    `json:"email_confirm"`
-	PhoneConfirm bool    `json:"phone_confirm"`
-	UserMetadata JSONMap `json:"user_metadata"`
-	AppMetadata  JSONMap `json:"app_metadata"`
-	BanDuration  string  `json:"ban_duration"`
+	Role         string  `json:"role,omitempty"`
+	Email        string  `json:"email,omitempty"`
+	Phone        string  `json:"phone,omitempty"`
+	Password     *string `json:"password,omitempty"`
+	EmailConfirm bool    `json:"email_confirm,omitempty"`
+	PhoneConfirm bool    `json:"phone_confirm,omitempty"`
+	UserMetadata JSONMap `json:"user_metadata,omitempty"`
+	AppMetadata  JSONMap `json:"app_metadata,omitempty"`
+	BanDuration  string  `json:"ban_duration,omitempty"`
+}
+
+// Validate checks the params of a new user: an email or a phone number is
+// required and the password, if set, must not be empty. The error is an
+// *AuthError with the validation_failed code, like the errors of GoTrue.
+func (p AdminUserParams) Validate() error {
+	if p.Email == "" && p.Phone == "" {
+		return newValidationError("an email or a phone number is required")
+	}
+	if p.Password != nil && *p.Password == "" {
+		return newValidationError("password must not be empty")
+	}
+	if p.EmailConfirm && p.Email == "" {
+		return newValidationError("email_confirm requires an email")
+	}
+	if p.PhoneConfirm && p.Phone == "" {
+		return newValidationError("phone_confirm requires a phone number")
+	}
+	return nil
+}
+
+func newValidationError(message string) *AuthError {
+	return &AuthError{StatusCode: http.StatusUnprocessableEntity, Code: ErrCodeValidationFailed, Message: message}
+}
+
+// AdminUserUpdateParams contains the fields of a user to be updated, nil fields
+// are left unchanged. The metadata is merged into the existing metadata.
+type AdminUserUpdateParams struct {
+	Role         *string `json:"role,omitempty"`
+	Email        *string `json:"email,omitempty"`
+	Phone        *string `json:"phone,omitempty"`
+	Password     *string `json:"password,omitempty"`
+	EmailConfirm *bool   `json:"email_confirm,omitempty"`
+	PhoneConfirm *bool   `json:"phone_confirm,omitempty"`
+	UserMetadata JSONMap `json:"user_metadata,omitempty"`
+	AppMetadata  JSONMap `json:"app_metadata,omitempty"`
+	BanDuration  *string `json:"ban_duration,omitempty"`
+}
+
+// Ptr returns a pointer to v, for setting the optional fields of params structs
+func Ptr[T any](v T) *T {
+	return &v
 }
 
 type GenerateLinkParams struct {
@@ -106,7 +156,7 @@ type GenerateLinkResponse struct {
 
 // Retrieve the user
 func (a *Admin) GetUser(ctx context.Context, userID string) (*AdminUser, error) {
-	reqURL := fmt.Sprintf("%s/%s/users/%s", a.client.BaseURL, AdminEndpoint, userID)
+	reqURL := fmt.Sprintf("%s/admin/users/%s", a.client.authURL(), userID)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return nil, err
@@ -121,10 +171,192 @@ func (a *Admin) GetUser(ctx context.Context, userID string) (*AdminUser, error)
 	return &res, nil
 }
 
-// Create a user
+type ListUsersParams struct {
+	// Page is the page number, starting at 1
+	Page    int
+	PerPage int
+	// Sort orders the users by creation time, "created_at desc" by default
+	Sort string
+}
+
+type listUsersResponse struct {
+	Users []AdminUser `json:"users"`
+}
+
+// List a page of users
+func (a *Admin) ListUsers(ctx context.Context, params ListUsersParams) ([]AdminUser, error) {
+	query := url.Values{}
+	if params.Page > 0 {
+		query.Set("page", strconv.Itoa(params.Page))
+	}
+	if params.PerPage > 0 {
+		query.Set("per_page", strconv.Itoa(params.PerPage))
+	}
+	if params.Sort != "" {
+		query.Set("sort", params.Sort)
+	}
+
+	reqURL := fmt.Sprintf("%s/admin/users?%s", a.client.authURL(), query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	injectAuthorizationHeader(req, a.serviceKey)
+	res := listUsersResponse{}
+	if err := a.client.sendRequest(req, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Users, nil
+}
+
+// UserIterator pages through all users, see Admin.IterateUsers.
+type UserIterator struct {
+	admin   *Admin
+	ctx     context.Context
+	perPage int
+	page    int
+	users   []AdminUser
+	current *AdminUser
+	done    bool
+	err     error
+}
+
+// Iterate through all users, fetching perPage users per request
+//
+//	it := client.Admin.IterateUsers(ctx, 100)
+//	for it.Next() {
+//		user := it.User()
+//	}
+//	if err := it.Err(); err != nil {
+//		// handle error
+//	}
+func (a *Admin) IterateUsers(ctx context.Context, perPage int) *UserIterator {
+	if perPage <= 0 {
+		perPage = 50
+	}
+	return &UserIterator{admin: a, ctx: ctx, perPage: perPage}
+}
+
+// Next advances to the next user, fetching the next page when needed. It
+// returns false when all users were visited or an error occurred.
+func (it *UserIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if len(it.users) == 0 {
+		if it.done {
+			return false
+		}
+
+		it.page++
+		users, err := it.admin.ListUsers(it.ctx, ListUsersParams{Page: it.page, PerPage: it.perPage})
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.users = users
+		it.done = len(users) < it.perPage
+		if len(users) == 0 {
+			return false
+		}
+	}
+
+	it.current = &it.users[0]
+	it.users = it.users[1:]
+	return true
+}
+
+// User returns the current user.
+func (it *UserIterator) User() *AdminUser {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *UserIterator) Err() error {
+	return it.err
+}
+
+type ExportFormat string
+
+const (
+	ExportFormatCSV    ExportFormat = "csv"
+	ExportFormatNDJSON ExportFormat = "ndjson"
+)
+
+var exportCSVHeader = []string{
+	"id", "email", "phone", "role", "aud", "created_at", "updated_at",
+	"last_sign_in_at", "email_confirmed_at", "phone_confirmed_at", "banned_until",
+}
+
+// Export all users to w as CSV (one row per user with the main fields) or
+// NDJSON (one JSON encoded user per line)
+func (a *Admin) ExportUsers(ctx context.Context, w io.Writer, format ExportFormat) error {
+	var (
+		csvWriter   *csv.Writer
+		jsonEncoder *json.Encoder
+	)
+	switch format {
+	case ExportFormatCSV:
+		csvWriter = csv.NewWriter(w)
+		if err := csvWriter.Write(exportCSVHeader); err != nil {
+			return err
+		}
+	case ExportFormatNDJSON:
+		jsonEncoder = json.NewEncoder(w)
+	default:
+		return fmt.Errorf("unsupported export format: %q", format)
+	}
+
+	it := a.IterateUsers(ctx, 100)
+	for it.Next() {
+		user := it.User()
+		if jsonEncoder != nil {
+			if err := jsonEncoder.Encode(user); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := csvWriter.Write([]string{
+			user.ID, user.Email, user.Phone, user.Role, user.Aud,
+			formatExportTime(&user.CreatedAt), formatExportTime(&user.UpdatedAt),
+			formatExportTime(user.LastSignInAt), formatExportTime(user.EmailConfirmedAt),
+			formatExportTime(user.PhoneConfirmedAt), formatExportTime(user.BannedUntil),
+		}); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	if csvWriter != nil {
+		csvWriter.Flush()
+		return csvWriter.Error()
+	}
+	return nil
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Create a user. The params are checked with Validate first. Errors returned by
+// GoTrue are *AuthError, e.g. with the email_exists code.
 func (a *Admin) CreateUser(ctx context.Context, params AdminUserParams) (*AdminUser, error) {
-	reqBody, _ := json.Marshal(params)
-	reqURL := fmt.Sprintf("%s/%s/users", a.client.BaseURL, AdminEndpoint)
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	reqBody, _ := a.client.marshalJSON(params)
+	reqURL := fmt.Sprintf("%s/admin/users", a.client.authURL())
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
@@ -132,17 +364,50 @@ func (a *Admin) CreateUser(ctx context.Context, params AdminUserParams) (*AdminU
 
 	injectAuthorizationHeader(req, a.serviceKey)
 	res := AdminUser{}
-	if err := a.client.sendRequest(req, &res); err != nil {
+	if err := a.client.Auth.sendRequest(req, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
-// Update a user
+// Update a user. Empty fields of params are left unchanged; use
+// UpdateUserWithParams to clear a field.
 func (a *Admin) UpdateUser(ctx context.Context, userID string, params AdminUserParams) (*AdminUser, error) {
-	reqBody, _ := json.Marshal(params)
-	reqURL := fmt.Sprintf("%s/%s/users/%s", a.client.BaseURL, AdminEndpoint, userID)
+	return a.updateUser(ctx, userID, params)
+}
+
+// Update the given fields of a user
+func (a *Admin) UpdateUserWithParams(ctx context.Context, userID string, params AdminUserUpdateParams) (*AdminUser, error) {
+	return a.updateUser(ctx, userID, params)
+}
+
+// Ban a user for the given duration
+func (a *Admin) BanUser(ctx context.Context, userID string, duration time.Duration) (*AdminUser, error) {
+	banDuration, err := formatBanDuration(duration)
+	if err != nil {
+		return nil, err
+	}
+	return a.updateUser(ctx, userID, map[string]string{"ban_duration": banDuration})
+}
+
+// Lift the ban of a user
+func (a *Admin) UnbanUser(ctx context.Context, userID string) (*AdminUser, error) {
+	return a.updateUser(ctx, userID, map[string]string{"ban_duration": "none"})
+}
+
+// formatBanDuration formats a duration as a ban_duration, which GoTrue parses
+// with time.ParseDuration and truncates to whole seconds.
+func formatBanDuration(duration time.Duration) (string, error) {
+	if duration < time.Second {
+		return "", fmt.Errorf("ban duration must be at least 1s, got %s", duration)
+	}
+	return duration.Truncate(time.Second).String(), nil
+}
+
+func (a *Admin) updateUser(ctx context.Context, userID string, body interface{}) (*AdminUser, error) {
+	reqBody, _ := a.client.marshalJSON(body)
+	reqURL := fmt.Sprintf("%s/admin/users/%s", a.client.authURL(), userID)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPut, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
@@ -150,7 +415,7 @@ func (a *Admin) UpdateUser(ctx context.Context, userID string, params AdminUserP
 
 	injectAuthorizationHeader(req, a.serviceKey)
 	res := AdminUser{}
-	if err := a.client.sendRequest(req, &res); err != nil {
+	if err := a.client.Auth.sendRequest(req, &res); err != nil {
 		return nil, err
 	}
 
@@ -159,8 +424,8 @@ func (a *Admin) UpdateUser(ctx context.Context, userID string, params AdminUserP
 
 // Update a user
 func (a *Admin) GenerateLink(ctx context.Context, params GenerateLinkParams) (*GenerateLinkResponse, error) {
-	reqBody, _ := json.Marshal(params)
-	reqURL := fmt.Sprintf("%s/%s/generate_link", a.client.BaseURL, AdminEndpoint)
+	reqBody, _ := a.client.marshalJSON(params)
+	reqURL := fmt.Sprintf("%s/admin/generate_link", a.client.authURL())
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
@@ -174,3 +439,30 @@ func (a *Admin) GenerateLink(ctx context.Context, params GenerateLinkParams) (*G
 
 	return &res, nil
 }
+
+// Do sends a request to an admin endpoint without a dedicated method, e.g.
+// "/users/{id}/factors", authenticated with the service key. The body is
+// encoded as JSON if not nil and the response is decoded into out if not nil.
+// Errors returned by GoTrue are *AuthError.
+func (a *Admin) Do(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := a.client.marshalJSON(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	reqURL := fmt.Sprintf("%s/admin/%s", a.client.authURL(), strings.TrimPrefix(path, "/"))
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	injectAuthorizationHeader(req, a.serviceKey)
+	return a.client.Auth.sendRequest(req, out)
+}
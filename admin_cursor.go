@@ -0,0 +1,231 @@
+package supabase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for a cursor not returned by a previous page.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// AuditLogEntry is an entry of the auth audit log.
+type AuditLogEntry struct {
+	ID        string    `json:"id"`
+	Payload   JSONMap   `json:"payload"`
+	CreatedAt time.Time `json:"created_at"`
+	IPAddress string    `json:"ip_address"`
+}
+
+type ListAuditLogsParams struct {
+	// Page is the page number, starting at 1
+	Page    int
+	PerPage int
+	// Query filters the entries by actor, e.g. an email address
+	Query string
+}
+
+// List a page of audit log entries, newest first
+func (a *Admin) ListAuditLogs(ctx context.Context, params ListAuditLogsParams) ([]AuditLogEntry, error) {
+	query := url.Values{}
+	if params.Page > 0 {
+		query.Set("page", strconv.Itoa(params.Page))
+	}
+	if params.PerPage > 0 {
+		query.Set("per_page", strconv.Itoa(params.PerPage))
+	}
+	if params.Query != "" {
+		query.Set("query", params.Query)
+	}
+
+	reqURL := fmt.Sprintf("%s/admin/audit?%s", a.client.authURL(), query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	injectAuthorizationHeader(req, a.serviceKey)
+	var res []AuditLogEntry
+	if err := a.client.sendRequest(req, &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// UserPage is a page of users listed with ListUsersAfter.
+type UserPage struct {
+	Users []AdminUser
+	// NextCursor is passed to ListUsersAfter to get the next page, it is empty on the last page
+	NextCursor string
+}
+
+// AuditLogPage is a page of audit log entries listed with ListAuditLogsAfter.
+type AuditLogPage struct {
+	Entries []AuditLogEntry
+	// NextCursor is passed to ListAuditLogsAfter to get the next page, it is empty on the last page
+	NextCursor string
+}
+
+// List up to limit users, oldest first, created after the cursor of the
+// previous page; an empty cursor starts with the oldest user.
+//
+// GoTrue only pages with offsets, so the users are listed by ascending
+// creation time, which appends new users to the last page, and the users up
+// to the cursor are skipped. Unlike ListUsers pages, users created or deleted
+// during the iteration neither shift users into an already visited page nor
+// make them appear twice.
+func (a *Admin) ListUsersAfter(ctx context.Context, cursor string, limit int) (*UserPage, error) {
+	fetch := func(page int, perPage int) ([]AdminUser, error) {
+		return a.ListUsers(ctx, ListUsersParams{Page: page, PerPage: perPage, Sort: "created_at asc"})
+	}
+	key := func(user AdminUser) cursorKey {
+		return cursorKey{createdAt: user.CreatedAt, id: user.ID}
+	}
+
+	users, next, err := listAfter(cursor, limit, fetch, key, false)
+	if err != nil {
+		return nil, err
+	}
+	return &UserPage{Users: users, NextCursor: next}, nil
+}
+
+// List up to limit audit log entries, newest first, older than the cursor of
+// the previous page; an empty cursor starts with the newest entry. Entries
+// logged during the iteration are skipped instead of shifting the entries
+// into an already visited page, see ListUsersAfter.
+func (a *Admin) ListAuditLogsAfter(ctx context.Context, cursor string, limit int) (*AuditLogPage, error) {
+	fetch := func(page int, perPage int) ([]AuditLogEntry, error) {
+		return a.ListAuditLogs(ctx, ListAuditLogsParams{Page: page, PerPage: perPage})
+	}
+	key := func(entry AuditLogEntry) cursorKey {
+		return cursorKey{createdAt: entry.CreatedAt, id: entry.ID}
+	}
+
+	entries, next, err := listAfter(cursor, limit, fetch, key, true)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLogPage{Entries: entries, NextCursor: next}, nil
+}
+
+// cursorKey is the position of a record in a listing ordered by creation time.
+type cursorKey struct {
+	createdAt time.Time
+	id        string
+}
+
+// compare orders keys by creation time, then by ID.
+func (k cursorKey) compare(other cursorKey) int {
+	if c := k.createdAt.Compare(other.createdAt); c != 0 {
+		return c
+	}
+	return strings.Compare(k.id, other.id)
+}
+
+// pageCursor is a cursorKey along with the page it was found on, where the
+// next listing starts looking for it, and the size of the pages.
+type pageCursor struct {
+	key     cursorKey
+	page    int
+	perPage int
+}
+
+func (c pageCursor) encode() string {
+	raw := fmt.Sprintf("%d|%d|%s|%s", c.page, c.perPage, c.key.createdAt.Format(time.RFC3339Nano), c.key.id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (pageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return pageCursor{}, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 4)
+	if len(parts) != 4 {
+		return pageCursor{}, ErrInvalidCursor
+	}
+
+	page, err := strconv.Atoi(parts[0])
+	if err != nil || page < 1 {
+		return pageCursor{}, ErrInvalidCursor
+	}
+	perPage, err := strconv.Atoi(parts[1])
+	if err != nil || perPage < 1 {
+		return pageCursor{}, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[2])
+	if err != nil {
+		return pageCursor{}, ErrInvalidCursor
+	}
+	return pageCursor{key: cursorKey{createdAt: createdAt, id: parts[3]}, page: page, perPage: perPage}, nil
+}
+
+// listAfter lists up to limit records following the cursor in a listing paged
+// with offsets, ordered by ascending creation time or descending if newestFirst.
+func listAfter[T any](cursor string, limit int, fetch func(page int, perPage int) ([]T, error), key func(T) cursorKey, newestFirst bool) ([]T, string, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	// the pages keep the size of the first listing, so the page of the cursor stays valid
+	start := pageCursor{page: 1, perPage: limit}
+	if cursor != "" {
+		var err error
+		if start, err = decodeCursor(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// follows reports whether a record comes after the cursor in the listing
+	follows := func(record T) bool {
+		if cursor == "" {
+			return true
+		}
+		c := key(record).compare(start.key)
+		if newestFirst {
+			return c < 0
+		}
+		return c > 0
+	}
+
+	page := start.page
+	records, err := fetch(page, start.perPage)
+	if err != nil {
+		return nil, "", err
+	}
+	// deleted records move the cursor to an earlier page, possibly leaving its page empty
+	for page > 1 && (len(records) == 0 || follows(records[0])) {
+		page--
+		if records, err = fetch(page, start.perPage); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var result []T
+	for {
+		for _, record := range records {
+			if !follows(record) {
+				continue
+			}
+			result = append(result, record)
+			if len(result) == limit {
+				return result, pageCursor{key: key(record), page: page, perPage: start.perPage}.encode(), nil
+			}
+		}
+		if len(records) < start.perPage {
+			return result, "", nil
+		}
+
+		page++
+		if records, err = fetch(page, start.perPage); err != nil {
+			return nil, "", err
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package supabase
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+type cursorRecord struct {
+	id        string
+	createdAt time.Time
+}
+
+func cursorRecordKey(r cursorRecord) cursorKey {
+	return cursorKey{createdAt: r.createdAt, id: r.id}
+}
+
+// fakeListing pages records with offsets like GoTrue, ordered by creation
+// time, and lets the tests insert and delete records between pages.
+type fakeListing struct {
+	records     []cursorRecord
+	newestFirst bool
+	base        time.Time
+}
+
+func newFakeListing(n int, newestFirst bool) *fakeListing {
+	l := &fakeListing{newestFirst: newestFirst, base: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	for i := 1; i <= n; i++ {
+		l.insert(i)
+	}
+	return l
+}
+
+// insert adds the record r<i> created i minutes after the base time.
+func (l *fakeListing) insert(i int) {
+	l.records = append(l.records, cursorRecord{id: fmt.Sprintf("r%02d", i), createdAt: l.base.Add(time.Duration(i) * time.Minute)})
+	sort.Slice(l.records, func(a, b int) bool {
+		if l.newestFirst {
+			return l.records[a].createdAt.After(l.records[b].createdAt)
+		}
+		return l.records[a].createdAt.Before(l.records[b].createdAt)
+	})
+}
+
+func (l *fakeListing) delete(ids ...string) {
+	for _, id := range ids {
+		for i, r := range l.records {
+			if r.id == id {
+				l.records = append(l.records[:i], l.records[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func (l *fakeListing) fetch(page int, perPage int) ([]cursorRecord, error) {
+	start := (page - 1) * perPage
+	if start >= len(l.records) {
+		return nil, nil
+	}
+	end := start + perPage
+	if end > len(l.records) {
+		end = len(l.records)
+	}
+	return append([]cursorRecord(nil), l.records[start:end]...), nil
+}
+
+func TestListAfter(t *testing.T) {
+	ids := func(from, to int) []string {
+		var ids []string
+		for i := from; i <= to; i++ {
+			ids = append(ids, fmt.Sprintf("r%02d", i))
+		}
+		return ids
+	}
+	reversed := func(ids []string) []string {
+		for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+			ids[i], ids[j] = ids[j], ids[i]
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name        string
+		records     int
+		limit       int
+		newestFirst bool
+		// between changes the listing after the given number of pages were listed
+		between func(l *fakeListing, pages int)
+		want    []string
+	}{
+		{
+			name:    "no changes",
+			records: 10,
+			limit:   3,
+			want:    ids(1, 10),
+		},
+		{
+			name:    "exact pages",
+			records: 9,
+			limit:   3,
+			want:    ids(1, 9),
+		},
+		{
+			name:    "visited records deleted",
+			records: 10,
+			limit:   3,
+			between: func(l *fakeListing, pages int) {
+				if pages == 1 {
+					l.delete("r01", "r02")
+				}
+			},
+			want: ids(1, 10),
+		},
+		{
+			name:    "deletions spanning several pages",
+			records: 12,
+			limit:   3,
+			between: func(l *fakeListing, pages int) {
+				if pages == 3 {
+					// the cursor r09 moves from page 3 to page 1
+					l.delete("r01", "r02", "r03", "r04", "r05", "r06", "r07")
+				}
+			},
+			want: ids(1, 12),
+		},
+		{
+			name:    "records not visited yet deleted",
+			records: 10,
+			limit:   3,
+			between: func(l *fakeListing, pages int) {
+				if pages == 1 {
+					l.delete("r05", "r06")
+				}
+			},
+			want: append(ids(1, 4), ids(7, 10)...),
+		},
+		{
+			name:    "new records appended to the last page",
+			records: 7,
+			limit:   3,
+			between: func(l *fakeListing, pages int) {
+				if pages == 1 {
+					l.insert(8)
+					l.insert(9)
+				}
+			},
+			want: ids(1, 9),
+		},
+		{
+			name:        "newest first",
+			records:     10,
+			limit:       4,
+			newestFirst: true,
+			want:        reversed(ids(1, 10)),
+		},
+		{
+			name:        "newest first with new entries pushed to later pages",
+			records:     10,
+			limit:       3,
+			newestFirst: true,
+			between: func(l *fakeListing, pages int) {
+				if pages == 1 || pages == 2 {
+					// new entries shift the visited ones into the next pages
+					l.insert(10 + pages*2 - 1)
+					l.insert(10 + pages*2)
+				}
+			},
+			want: reversed(ids(1, 10)),
+		},
+		{
+			name:        "newest first with visited entries deleted",
+			records:     10,
+			limit:       3,
+			newestFirst: true,
+			between: func(l *fakeListing, pages int) {
+				if pages == 2 {
+					l.delete("r10", "r09", "r08", "r07")
+				}
+			},
+			want: reversed(ids(1, 10)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listing := newFakeListing(tt.records, tt.newestFirst)
+
+			var got []string
+			cursor := ""
+			for pages := 1; ; pages++ {
+				if pages > 20 {
+					t.Fatalf("the listing did not end, got %v", got)
+				}
+				records, next, err := listAfter(cursor, tt.limit, listing.fetch, cursorRecordKey, tt.newestFirst)
+				if err != nil {
+					t.Fatalf("page %d: %v", pages, err)
+				}
+				if len(records) > tt.limit {
+					t.Fatalf("page %d has %d records, want at most %d", pages, len(records), tt.limit)
+				}
+				for _, r := range records {
+					got = append(got, r.id)
+				}
+				if next == "" {
+					break
+				}
+				cursor = next
+				if tt.between != nil {
+					tt.between(listing, pages)
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListAfter_FetchError(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+	fetch := func(page int, perPage int) ([]cursorRecord, error) {
+		return nil, errFetch
+	}
+	if _, _, err := listAfter("", 10, fetch, cursorRecordKey, false); !errors.Is(err, errFetch) {
+		t.Errorf("expected the fetch error, got %v", err)
+	}
+}
+
+func TestPageCursor(t *testing.T) {
+	cursor := pageCursor{
+		key:     cursorKey{createdAt: time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC), id: "a|b"},
+		page:    3,
+		perPage: 50,
+	}
+	decoded, err := decodeCursor(cursor.encode())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !decoded.key.createdAt.Equal(cursor.key.createdAt) || decoded.key.id != cursor.key.id || decoded.page != 3 || decoded.perPage != 50 {
+		t.Errorf("decoded %+v, want %+v", decoded, cursor)
+	}
+
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	invalid := map[string]string{
+		"not base64":        "!!!",
+		"missing parts":     encode("1|50|2024-05-01T12:30:00Z"),
+		"page not a number": encode("x|50|2024-05-01T12:30:00Z|id"),
+		"page zero":         encode("0|50|2024-05-01T12:30:00Z|id"),
+		"page size zero":    encode("1|0|2024-05-01T12:30:00Z|id"),
+		"invalid time":      encode("1|50|yesterday|id"),
+	}
+	for name, cursor := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeCursor(cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("expected ErrInvalidCursor, got %v", err)
+			}
+			fetch := func(page int, perPage int) ([]cursorRecord, error) {
+				t.Fatal("expected no request with an invalid cursor")
+				return nil, nil
+			}
+			if _, _, err := listAfter(cursor, 10, fetch, cursorRecordKey, false); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("expected ErrInvalidCursor from listAfter, got %v", err)
+			}
+		})
+	}
+}
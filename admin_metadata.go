@@ -0,0 +1,76 @@
+package supabase
+
+import "context"
+
+// MergeUserMetadata deep-merges patch into the user metadata of a user and
+// returns the updated user. GoTrue replaces the top-level keys sent to it, so
+// the nested objects of the metadata are fetched and merged with patch
+// instead: objects are merged key by key, other values replace the existing
+// ones and nil values remove the key. Only the top-level keys of patch are
+// sent, so concurrent updates of other keys are kept, but a concurrent update
+// of the same key between the fetch and the update is lost.
+func (a *Admin) MergeUserMetadata(ctx context.Context, userID string, patch JSONMap) (*AdminUser, error) {
+	user, err := a.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(patch) == 0 {
+		return user, nil
+	}
+	return a.updateUser(ctx, userID, AdminUserUpdateParams{UserMetadata: mergeMetadata(user.UserMetaData, patch)})
+}
+
+// MergeAppMetadata deep-merges patch into the app metadata of a user and
+// returns the updated user, like MergeUserMetadata.
+func (a *Admin) MergeAppMetadata(ctx context.Context, userID string, patch JSONMap) (*AdminUser, error) {
+	user, err := a.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(patch) == 0 {
+		return user, nil
+	}
+	return a.updateUser(ctx, userID, AdminUserUpdateParams{AppMetadata: mergeMetadata(user.AppMetaData, patch)})
+}
+
+// mergeMetadata returns the top-level keys of patch merged with the existing
+// metadata, a nil value removing the key in GoTrue.
+func mergeMetadata(existing JSONMap, patch JSONMap) JSONMap {
+	update := make(JSONMap, len(patch))
+	for key, value := range patch {
+		update[key] = mergeValue(existing[key], value)
+	}
+	return update
+}
+
+// mergeValue deep-merges patch into value without modifying either.
+func mergeValue(value interface{}, patch interface{}) interface{} {
+	patchMap, ok := asObject(patch)
+	if !ok {
+		return patch
+	}
+	valueMap, _ := asObject(value)
+
+	merged := make(map[string]interface{}, len(valueMap)+len(patchMap))
+	for key, v := range valueMap {
+		merged[key] = v
+	}
+	for key, v := range patchMap {
+		if v == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = mergeValue(merged[key], v)
+	}
+	return merged
+}
+
+func asObject(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, true
+	case JSONMap:
+		return v, true
+	}
+	return nil, false
+}
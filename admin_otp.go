@@ -0,0 +1,140 @@
+package supabase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ResendType is the type of message sent again by Admin.Resend.
+type ResendType string
+
+const (
+	ResendTypeSignup      ResendType = "signup"
+	ResendTypeEmailChange ResendType = "email_change"
+	ResendTypeSMS         ResendType = "sms"
+	ResendTypePhoneChange ResendType = "phone_change"
+)
+
+// ResendParams are the parameters of Admin.Resend.
+type ResendParams struct {
+	Type ResendType `json:"type"`
+	// Email is required for the signup and email_change types
+	Email string `json:"email,omitempty"`
+	// Phone is required for the sms and phone_change types
+	Phone string `json:"phone,omitempty"`
+	// RedirectTo is the URL the user is sent to after following an email link
+	RedirectTo string `json:"-"`
+}
+
+func (p ResendParams) validate() error {
+	switch p.Type {
+	case ResendTypeSignup, ResendTypeEmailChange:
+		if p.Email == "" {
+			return newValidationError(fmt.Sprintf("resend %s requires an email", p.Type))
+		}
+	case ResendTypeSMS, ResendTypePhoneChange:
+		if p.Phone == "" {
+			return newValidationError(fmt.Sprintf("resend %s requires a phone number", p.Type))
+		}
+	default:
+		return newValidationError(fmt.Sprintf("unknown resend type %q", p.Type))
+	}
+	return nil
+}
+
+// Resend sends a signup confirmation, an email change confirmation or a phone
+// OTP again on behalf of a user, e.g. when the first message was lost. The
+// request is authenticated with the service key, so it is not subject to
+// captcha protection, but GoTrue still enforces the minimum interval between
+// two messages to the same user.
+func (a *Admin) Resend(ctx context.Context, params ResendParams) error {
+	req, err := a.client.Auth.newResendRequest(ctx, params)
+	if err != nil {
+		return err
+	}
+	injectAuthorizationHeader(req, a.serviceKey)
+	return a.client.Auth.sendRequest(req, nil)
+}
+
+// newResendRequest creates the request sending a message again, shared by
+// Admin.Resend and Auth.ResendPhoneConfirmation.
+func (a *Auth) newResendRequest(ctx context.Context, params ResendParams) (*http.Request, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	return a.newRequest(ctx, "/resend", params.RedirectTo, params)
+}
+
+// OtpChannel is the channel delivering OTPs sent to a phone number.
+type OtpChannel string
+
+const (
+	OtpChannelSMS      OtpChannel = "sms"
+	OtpChannelWhatsApp OtpChannel = "whatsapp"
+)
+
+// AdminOtpParams are the parameters of Admin.SendOtp. Either Email or Phone is set.
+type AdminOtpParams struct {
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+	// Channel delivers phone OTPs, sms if empty
+	Channel OtpChannel `json:"channel,omitempty"`
+	// CreateUser controls whether a user is created for unknown emails and
+	// phone numbers, true if nil
+	CreateUser *bool `json:"create_user,omitempty"`
+	// Data is stored in the user metadata of new users and available to the email templates
+	Data map[string]interface{} `json:"data,omitempty"`
+	// RedirectTo is the URL the user is sent to after following the magic link
+	RedirectTo string `json:"-"`
+}
+
+// SendOtp delivers a one-time password on behalf of a user through the mailer
+// or SMS provider of the project: a magic link email with its code for an
+// email, or a code sent with Channel for a phone number.
+func (a *Admin) SendOtp(ctx context.Context, params AdminOtpParams) error {
+	if (params.Email == "") == (params.Phone == "") {
+		return newValidationError("send otp requires either an email or a phone number")
+	}
+	if params.Email != "" && params.Channel != "" {
+		return newValidationError("send otp channel only applies to phone numbers")
+	}
+	return a.sendAuthRequest(ctx, "/otp", params.RedirectTo, params)
+}
+
+// MagicLinkMailer delivers a magic link generated by GenerateAndSendMagicLink,
+// e.g. with the mailer of the application. The link is in ActionLink, the code
+// of the link in EmailOtp and the recipient in Email.
+type MagicLinkMailer func(ctx context.Context, link *GenerateLinkResponse) error
+
+// GenerateAndSendMagicLink generates a magic link for the user with the given
+// email, creating the user if needed, and delivers it with send. GoTrue does
+// not email links generated by the admin API, so they can be sent with custom
+// templates or through another provider than the one of the project. The link
+// is returned along with the error of send if it fails.
+func (a *Admin) GenerateAndSendMagicLink(ctx context.Context, params GenerateLinkParams, send MagicLinkMailer) (*GenerateLinkResponse, error) {
+	if send == nil {
+		return nil, errors.New("generate and send magic link requires a mailer")
+	}
+
+	params.Type = "magiclink"
+	link, err := a.GenerateLink(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := send(ctx, link); err != nil {
+		return link, fmt.Errorf("send magic link: %w", err)
+	}
+	return link, nil
+}
+
+// sendAuthRequest posts body to a public auth endpoint authenticated with the service key.
+func (a *Admin) sendAuthRequest(ctx context.Context, path string, redirectTo string, body interface{}) error {
+	req, err := a.client.Auth.newRequest(ctx, path, redirectTo, body)
+	if err != nil {
+		return err
+	}
+	injectAuthorizationHeader(req, a.serviceKey)
+	return a.client.Auth.sendRequest(req, nil)
+}
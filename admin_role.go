@@ -0,0 +1,71 @@
+package supabase
+
+import (
+	"context"
+	"fmt"
+)
+
+// Common application roles set with SetUserRole.
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleMember    = "member"
+)
+
+// Postgres roles of the tokens issued by GoTrue, set with SetUserDatabaseRole.
+const (
+	RoleAuthenticated = "authenticated"
+	RoleAnon          = "anon"
+)
+
+// reservedRoles are the roles of the Supabase services, which must never be
+// granted to a user.
+var reservedRoles = map[string]bool{
+	"service_role":           true,
+	"supabase_admin":         true,
+	"supabase_auth_admin":    true,
+	"supabase_storage_admin": true,
+	"authenticator":          true,
+	"postgres":               true,
+	"dashboard_user":         true,
+}
+
+// checkRole rejects empty and reserved roles before a request is sent.
+func checkRole(role string) error {
+	if role == "" {
+		return newValidationError("role must not be empty")
+	}
+	if reservedRoles[role] {
+		return newValidationError(fmt.Sprintf("role %q is reserved", role))
+	}
+	return nil
+}
+
+// SetUserRole sets the application role of a user as "role" in its app
+// metadata, which users cannot change themselves, keeping the other keys of
+// the metadata. The role is in the app_metadata claim of the next access
+// tokens of the user, as returned by Claims.Roles. Reserved roles such as
+// service_role are rejected with an *AuthError with the validation_failed
+// code.
+func (a *Admin) SetUserRole(ctx context.Context, userID string, role string) (*AdminUser, error) {
+	if err := checkRole(role); err != nil {
+		return nil, err
+	}
+	return a.updateUser(ctx, userID, AdminUserUpdateParams{AppMetadata: JSONMap{"role": role}})
+}
+
+// RemoveUserRole removes the application role set with SetUserRole.
+func (a *Admin) RemoveUserRole(ctx context.Context, userID string) (*AdminUser, error) {
+	return a.updateUser(ctx, userID, AdminUserUpdateParams{AppMetadata: JSONMap{"role": nil}})
+}
+
+// SetUserDatabaseRole sets the role of a user, the Postgres role of its access
+// tokens and of its requests to the database, RoleAuthenticated by default.
+// The role must exist in the database. Reserved roles are rejected like with
+// SetUserRole.
+func (a *Admin) SetUserDatabaseRole(ctx context.Context, userID string, role string) (*AdminUser, error) {
+	if err := checkRole(role); err != nil {
+		return nil, err
+	}
+	return a.updateUser(ctx, userID, AdminUserUpdateParams{Role: &role})
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -18,14 +19,158 @@ import (
 	"github.com/google/go-querystring/query"
 )
 
-type authError struct {
-	Message string `json:"message"`
-}
-
 type Auth struct {
 	client *Client
 }
 
+// AuthErrorCode is an error code returned by GoTrue. Error codes can be used as
+// targets of errors.Is to match an AuthError with the same code.
+type AuthErrorCode string
+
+const (
+	ErrCodeUnexpectedFailure              AuthErrorCode = "unexpected_failure"
+	ErrCodeValidationFailed               AuthErrorCode = "validation_failed"
+	ErrCodeBadJSON                        AuthErrorCode = "bad_json"
+	ErrCodeBadJWT                         AuthErrorCode = "bad_jwt"
+	ErrCodeNotAdmin                       AuthErrorCode = "not_admin"
+	ErrCodeNoAuthorization                AuthErrorCode = "no_authorization"
+	ErrCodeInvalidCredentials             AuthErrorCode = "invalid_credentials"
+	ErrCodeEmailNotConfirmed              AuthErrorCode = "email_not_confirmed"
+	ErrCodePhoneNotConfirmed              AuthErrorCode = "phone_not_confirmed"
+	ErrCodeEmailExists                    AuthErrorCode = "email_exists"
+	ErrCodePhoneExists                    AuthErrorCode = "phone_exists"
+	ErrCodeUserAlreadyExists              AuthErrorCode = "user_already_exists"
+	ErrCodeUserNotFound                   AuthErrorCode = "user_not_found"
+	ErrCodeUserBanned                     AuthErrorCode = "user_banned"
+	ErrCodeSessionNotFound                AuthErrorCode = "session_not_found"
+	ErrCodeSignupDisabled                 AuthErrorCode = "signup_disabled"
+	ErrCodeEmailProviderDisabled          AuthErrorCode = "email_provider_disabled"
+	ErrCodePhoneProviderDisabled          AuthErrorCode = "phone_provider_disabled"
+	ErrCodeEmailAddressInvalid            AuthErrorCode = "email_address_invalid"
+	ErrCodeEmailAddressNotAuthorized      AuthErrorCode = "email_address_not_authorized"
+	ErrCodeWeakPassword                   AuthErrorCode = "weak_password"
+	ErrCodeSamePassword                   AuthErrorCode = "same_password"
+	ErrCodeReauthNonceMissing             AuthErrorCode = "reauth_nonce_missing"
+	ErrCodeOverRequestRateLimit           AuthErrorCode = "over_request_rate_limit"
+	ErrCodeOverEmailSendRateLimit         AuthErrorCode = "over_email_send_rate_limit"
+	ErrCodeOverSMSSendRateLimit           AuthErrorCode = "over_sms_send_rate_limit"
+	ErrCodeCaptchaFailed                  AuthErrorCode = "captcha_failed"
+	ErrCodeOTPExpired                     AuthErrorCode = "otp_expired"
+	ErrCodeOTPDisabled                    AuthErrorCode = "otp_disabled"
+	ErrCodeRefreshTokenNotFound           AuthErrorCode = "refresh_token_not_found"
+	ErrCodeRefreshTokenAlreadyUsed        AuthErrorCode = "refresh_token_already_used"
+	ErrCodeFlowStateNotFound              AuthErrorCode = "flow_state_not_found"
+	ErrCodeFlowStateExpired               AuthErrorCode = "flow_state_expired"
+	ErrCodeBadCodeVerifier                AuthErrorCode = "bad_code_verifier"
+	ErrCodeIdentityAlreadyExists          AuthErrorCode = "identity_already_exists"
+	ErrCodeIdentityNotFound               AuthErrorCode = "identity_not_found"
+	ErrCodeInsufficientAAL                AuthErrorCode = "insufficient_aal"
+	ErrCodeMFAVerificationFailed          AuthErrorCode = "mfa_verification_failed"
+	ErrCodeProviderEmailNeedsVerification AuthErrorCode = "provider_email_needs_verification"
+)
+
+func (c AuthErrorCode) Error() string {
+	return string(c)
+}
+
+// AuthError is the error returned by auth methods when GoTrue responds with an error.
+type AuthError struct {
+	// StatusCode is the HTTP status code reported in the error body
+	StatusCode int
+	Code       AuthErrorCode
+	Message    string
+	// Reasons lists why a password was rejected for weak_password errors,
+	// one of "length", "characters" or "pwned"
+	Reasons []string
+}
+
+func (err *AuthError) Error() string {
+	if err.Code == "" {
+		return err.Message
+	}
+	return fmt.Sprintf("%s: %s", err.Code, err.Message)
+}
+
+// HTTPStatus returns the HTTP status code of the response.
+func (err *AuthError) HTTPStatus() int {
+	return err.StatusCode
+}
+
+// Is reports whether the target is the AuthErrorCode of the error.
+func (err *AuthError) Is(target error) bool {
+	code, ok := target.(AuthErrorCode)
+	return ok && err.Code != "" && code == err.Code
+}
+
+// UnmarshalJSON decodes both the current and the legacy GoTrue error formats.
+func (err *AuthError) UnmarshalJSON(data []byte) error {
+	var body struct {
+		Code             int    `json:"code"`
+		ErrorCode        string `json:"error_code"`
+		Msg              string `json:"msg"`
+		Message          string `json:"message"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+		WeakPassword     *struct {
+			Reasons []string `json:"reasons"`
+		} `json:"weak_password"`
+	}
+	if e := json.Unmarshal(data, &body); e != nil {
+		return e
+	}
+
+	err.StatusCode = body.Code
+	err.Code = AuthErrorCode(body.ErrorCode)
+	if err.Code == "" {
+		err.Code = AuthErrorCode(body.Error)
+	}
+
+	if body.WeakPassword != nil {
+		err.Reasons = body.WeakPassword.Reasons
+	}
+
+	for _, msg := range []string{body.Msg, body.Message, body.ErrorDescription} {
+		if msg != "" {
+			err.Message = msg
+			break
+		}
+	}
+	return nil
+}
+
+// newRequest creates a JSON POST request of body to the given auth endpoint,
+// redirecting email links to redirectTo if set.
+func (a *Auth) newRequest(ctx context.Context, path string, redirectTo string, body interface{}) (*http.Request, error) {
+	reqBody, err := a.client.marshalJSON(body)
+	if err != nil {
+		return nil, err
+	}
+
+	reqURL := a.client.authURL() + path
+	if redirectTo != "" {
+		reqURL += "?" + url.Values{"redirect_to": {redirectTo}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// sendRequest sends an auth request and returns GoTrue errors as *AuthError.
+func (a *Auth) sendRequest(req *http.Request, v interface{}) error {
+	errRes := AuthError{}
+	hasCustomError, err := a.client.sendCustomRequest(req, v, &errRes)
+	if err != nil {
+		return err
+	} else if hasCustomError {
+		return &errRes
+	}
+
+	return nil
+}
+
 type UserCredentials struct {
 	Email    string
 	Password string
@@ -37,6 +182,11 @@ type User struct {
 	Aud                string                    `json:"aud"`
 	Role               string                    `json:"role"`
 	Email              string                    `json:"email"`
+	Phone              string                    `json:"phone"`
+	NewEmail           string                    `json:"new_email"`
+	NewPhone           string                    `json:"new_phone"`
+	EmailChangeSentAt  *time.Time                `json:"email_change_sent_at"`
+	PhoneChangeSentAt  *time.Time                `json:"phone_change_sent_at"`
 	InvitedAt          time.Time                 `json:"invited_at"`
 	ConfirmedAt        time.Time                 `json:"confirmed_at"`
 	ConfirmationSentAt time.Time                 `json:"confirmation_sent_at"`
@@ -48,8 +198,8 @@ type User struct {
 
 // SignUp registers the user's email and password to the database.
 func (a *Auth) SignUp(ctx context.Context, credentials UserCredentials) (*User, error) {
-	reqBody, _ := json.Marshal(credentials)
-	reqURL := fmt.Sprintf("%s/%s/signup", a.client.BaseURL, AuthEndpoint)
+	reqBody, _ := a.client.marshalJSON(credentials)
+	reqURL := fmt.Sprintf("%s/signup", a.client.authURL())
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
@@ -57,13 +207,104 @@ func (a *Auth) SignUp(ctx context.Context, credentials UserCredentials) (*User,
 
 	req.Header.Set("Content-Type", "application/json")
 	res := User{}
-	if err := a.client.sendRequest(req, &res); err != nil {
+	if err := a.sendRequest(req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// AuthSettings is the public configuration of the auth server.
+type AuthSettings struct {
+	External          map[string]bool `json:"external"`
+	DisableSignup     bool            `json:"disable_signup"`
+	MailerAutoconfirm bool            `json:"mailer_autoconfirm"`
+	PhoneAutoconfirm  bool            `json:"phone_autoconfirm"`
+	SmsProvider       string          `json:"sms_provider"`
+	SAMLEnabled       bool            `json:"saml_enabled"`
+	// PasswordMinLength and PasswordRequiredCharacters are only reported by
+	// servers exposing their password policy
+	PasswordMinLength          int    `json:"password_min_length,omitempty"`
+	PasswordRequiredCharacters string `json:"password_required_characters,omitempty"`
+}
+
+// Settings retrieves the public configuration of the auth server.
+func (a *Auth) Settings(ctx context.Context) (*AuthSettings, error) {
+	reqURL := fmt.Sprintf("%s/settings", a.client.authURL())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := AuthSettings{}
+	if err := a.sendRequest(req, &res); err != nil {
 		return nil, err
 	}
 
 	return &res, nil
 }
 
+// defaultPasswordMinLength is the minimum password length GoTrue enforces when none is configured.
+const defaultPasswordMinLength = 6
+
+// PasswordPolicy is the set of requirements a password must meet.
+type PasswordPolicy struct {
+	MinLength int
+	// RequiredCharacters lists character sets of which the password must
+	// contain at least one character each
+	RequiredCharacters []string
+}
+
+// PasswordPolicy returns the password policy of the settings.
+func (s *AuthSettings) PasswordPolicy() PasswordPolicy {
+	policy := PasswordPolicy{MinLength: s.PasswordMinLength}
+	if policy.MinLength < defaultPasswordMinLength {
+		policy.MinLength = defaultPasswordMinLength
+	}
+	if s.PasswordRequiredCharacters != "" {
+		policy.RequiredCharacters = strings.Split(s.PasswordRequiredCharacters, ":")
+	}
+	return policy
+}
+
+// ValidatePassword checks the password against the policy configured on the
+// auth server, so weak passwords can be rejected before calling SignUp.
+func (a *Auth) ValidatePassword(ctx context.Context, password string) error {
+	settings, err := a.Settings(ctx)
+	if err != nil {
+		return err
+	}
+	return ValidatePassword(password, settings.PasswordPolicy())
+}
+
+// ValidatePassword checks the password against the policy. It returns an
+// *AuthError with the weak_password code and the same reasons GoTrue reports.
+func ValidatePassword(password string, policy PasswordPolicy) error {
+	var reasons, messages []string
+	if len(password) < policy.MinLength {
+		reasons = append(reasons, "length")
+		messages = append(messages, fmt.Sprintf("Password should be at least %d characters.", policy.MinLength))
+	}
+
+	for _, chars := range policy.RequiredCharacters {
+		if chars != "" && !strings.ContainsAny(password, chars) {
+			reasons = append(reasons, "characters")
+			messages = append(messages, fmt.Sprintf("Password should contain at least one character of each: %s.", strings.Join(policy.RequiredCharacters, ", ")))
+			break
+		}
+	}
+
+	if len(reasons) == 0 {
+		return nil
+	}
+	return &AuthError{
+		StatusCode: http.StatusUnprocessableEntity,
+		Code:       ErrCodeWeakPassword,
+		Message:    strings.Join(messages, " "),
+		Reasons:    reasons,
+	}
+}
+
 type AuthenticatedDetails struct {
 	AccessToken          string `json:"access_token"`
 	TokenType            string `json:"token_type"`
@@ -74,19 +315,10 @@ type AuthenticatedDetails struct {
 	ProviderRefreshToken string `json:"provider_refresh_token"`
 }
 
-type authenticationError struct {
-	Error            string `json:"error_code"`
-	ErrorDescription string `json:"msg"`
-}
-
-type exchangeError struct {
-	Message string `json:"msg"`
-}
-
 // SignIn enters the user credentials and returns the current user if succeeded.
 func (a *Auth) SignIn(ctx context.Context, credentials UserCredentials) (*AuthenticatedDetails, error) {
-	reqBody, _ := json.Marshal(credentials)
-	reqURL := fmt.Sprintf("%s/%s/token?grant_type=password", a.client.BaseURL, AuthEndpoint)
+	reqBody, _ := a.client.marshalJSON(credentials)
+	reqURL := fmt.Sprintf("%s/token?grant_type=password", a.client.authURL())
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
@@ -94,21 +326,18 @@ func (a *Auth) SignIn(ctx context.Context, credentials UserCredentials) (*Authen
 
 	req.Header.Set("Content-Type", "application/json")
 	res := AuthenticatedDetails{}
-	errRes := authenticationError{}
-	hasCustomError, err := a.client.sendCustomRequest(req, &res, &errRes)
-	if err != nil {
+	if err := a.sendRequest(req, &res); err != nil {
 		return nil, err
-	} else if hasCustomError {
-		return nil, errors.New(fmt.Sprintf("%s: %s", errRes.Error, errRes.ErrorDescription))
 	}
 
+	a.client.setSession(&res)
 	return &res, nil
 }
 
 // SignIn enters the user credentials and returns the current user if succeeded.
 func (a *Auth) RefreshUser(ctx context.Context, userToken string, refreshToken string) (*AuthenticatedDetails, error) {
-	reqBody, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
-	reqURL := fmt.Sprintf("%s/%s/token?grant_type=refresh_token", a.client.BaseURL, AuthEndpoint)
+	reqBody, _ := a.client.marshalJSON(map[string]string{"refresh_token": refreshToken})
+	reqURL := fmt.Sprintf("%s/token?grant_type=refresh_token", a.client.authURL())
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
@@ -117,14 +346,11 @@ func (a *Auth) RefreshUser(ctx context.Context, userToken string, refreshToken s
 	injectAuthorizationHeader(req, userToken)
 	req.Header.Set("Content-Type", "application/json")
 	res := AuthenticatedDetails{}
-	errRes := authenticationError{}
-	hasCustomError, err := a.client.sendCustomRequest(req, &res, &errRes)
-	if err != nil {
+	if err := a.sendRequest(req, &res); err != nil {
 		return nil, err
-	} else if hasCustomError {
-		return nil, errors.New(fmt.Sprintf("%s: %s", errRes.Error, errRes.ErrorDescription))
 	}
 
+	a.client.setSession(&res)
 	return &res, nil
 }
 
@@ -135,8 +361,8 @@ type ExchangeCodeOpts struct {
 
 // ExchangeCode takes an auth code and PCKE verifier and returns the current user if succeeded.
 func (a *Auth) ExchangeCode(ctx context.Context, opts ExchangeCodeOpts) (*AuthenticatedDetails, error) {
-	reqBody, _ := json.Marshal(opts)
-	reqURL := fmt.Sprintf("%s/%s/token?grant_type=pkce", a.client.BaseURL, AuthEndpoint)
+	reqBody, _ := a.client.marshalJSON(opts)
+	reqURL := fmt.Sprintf("%s/token?grant_type=pkce", a.client.authURL())
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
@@ -144,32 +370,66 @@ func (a *Auth) ExchangeCode(ctx context.Context, opts ExchangeCodeOpts) (*Authen
 
 	req.Header.Set("Content-Type", "application/json")
 	res := AuthenticatedDetails{}
-	errRes := exchangeError{}
-	hasCustomError, err := a.client.sendCustomRequest(req, &res, &errRes)
-	if err != nil {
+	if err := a.sendRequest(req, &res); err != nil {
 		return nil, err
-	} else if hasCustomError {
-		return nil, errors.New(errRes.Message)
 	}
 
+	a.client.setSession(&res)
 	return &res, err
 }
 
 // SendMagicLink sends a link to a specific e-mail address for passwordless auth.
 func (a *Auth) SendMagicLink(ctx context.Context, email string) error {
-	reqBody, _ := json.Marshal(map[string]string{"email": email})
-	reqURL := fmt.Sprintf("%s/%s/magiclink", a.client.BaseURL, AuthEndpoint)
+	return a.SendMagicLinkWithData(ctx, email, nil)
+}
+
+// SendMagicLinkWithData sends a magic link to the given email with data, which is
+// stored in the user metadata of new users and available to the email templates as
+// {{ .Data }}.
+func (a *Auth) SendMagicLinkWithData(ctx context.Context, email string, data map[string]interface{}) error {
+	return a.SendMagicLinkWithOptions(ctx, email, MagicLinkOptions{Data: data})
+}
+
+// MagicLinkOptions contains the optional parameters of a magic link request.
+type MagicLinkOptions struct {
+	// RedirectTo is the URL the user is sent to after following the magic link
+	RedirectTo string
+	// CreateUser controls whether a user is created for unknown emails, true if nil
+	CreateUser *bool
+	// CaptchaToken is required when captcha protection is enabled on the project
+	CaptchaToken string
+	// Data is stored in the user metadata of new users and available to the email templates
+	Data map[string]interface{}
+}
+
+type magicLinkParams struct {
+	Email              string                 `json:"email"`
+	CreateUser         *bool                  `json:"create_user,omitempty"`
+	Data               map[string]interface{} `json:"data,omitempty"`
+	GotrueMetaSecurity *gotrueMetaSecurity    `json:"gotrue_meta_security,omitempty"`
+}
+
+// SendMagicLinkWithOptions sends a magic link to the given email with the given options.
+func (a *Auth) SendMagicLinkWithOptions(ctx context.Context, email string, opts MagicLinkOptions) error {
+	params := magicLinkParams{Email: email, CreateUser: opts.CreateUser, Data: opts.Data}
+	if opts.CaptchaToken != "" {
+		params.GotrueMetaSecurity = &gotrueMetaSecurity{CaptchaToken: opts.CaptchaToken}
+	}
+
+	reqBody, _ := a.client.marshalJSON(params)
+	// unlike /magiclink, /otp supports create_user
+	reqURL := fmt.Sprintf("%s/otp", a.client.authURL())
+	if opts.RedirectTo != "" {
+		reqURL += "?" + url.Values{"redirect_to": {opts.RedirectTo}}.Encode()
+	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return err
 	}
 
-	errRes := authError{}
-	hasCustomError, err := a.client.sendCustomRequest(req, nil, &errRes)
-	if err != nil {
+	req.Header.Set("Content-Type", "application/json")
+	if err := a.sendRequest(req, nil); err != nil {
 		return err
-	} else if hasCustomError {
-		return errors.New(fmt.Sprintf("%s", errRes.Message))
 	}
 
 	return nil
@@ -214,7 +474,7 @@ func (a *Auth) SignInWithProvider(opts ProviderSignInOptions) (*ProviderSignInDe
 		params.Add("code_challenge_method", p.ChallengeMethod)
 
 		details := ProviderSignInDetails{
-			URL:          fmt.Sprintf("%s/%s/authorize?%s", a.client.BaseURL, AuthEndpoint, params.Encode()),
+			URL:          fmt.Sprintf("%s/authorize?%s", a.client.authURL(), params.Encode()),
 			Provider:     opts.Provider,
 			CodeVerifier: p.Verifier,
 		}
@@ -224,7 +484,7 @@ func (a *Auth) SignInWithProvider(opts ProviderSignInOptions) (*ProviderSignInDe
 
 	// Implicit flow
 	details := ProviderSignInDetails{
-		URL:      fmt.Sprintf("%s/%s/authorize?%s", a.client.BaseURL, AuthEndpoint, params.Encode()),
+		URL:      fmt.Sprintf("%s/authorize?%s", a.client.authURL(), params.Encode()),
 		Provider: opts.Provider,
 	}
 
@@ -233,7 +493,7 @@ func (a *Auth) SignInWithProvider(opts ProviderSignInOptions) (*ProviderSignInDe
 
 // User retrieves the user information based on the given token
 func (a *Auth) User(ctx context.Context, userToken string) (*User, error) {
-	reqURL := fmt.Sprintf("%s/%s/user", a.client.BaseURL, AuthEndpoint)
+	reqURL := fmt.Sprintf("%s/user", a.client.authURL())
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return nil, err
@@ -241,12 +501,8 @@ func (a *Auth) User(ctx context.Context, userToken string) (*User, error) {
 
 	injectAuthorizationHeader(req, userToken)
 	res := User{}
-	errRes := authError{}
-	hasCustomError, err := a.client.sendCustomRequest(req, &res, &errRes)
-	if err != nil {
+	if err := a.sendRequest(req, &res); err != nil {
 		return nil, err
-	} else if hasCustomError {
-		return nil, errors.New(fmt.Sprintf("%s", errRes.Message))
 	}
 
 	return &res, nil
@@ -254,8 +510,45 @@ func (a *Auth) User(ctx context.Context, userToken string) (*User, error) {
 
 // UpdateUser updates the user information
 func (a *Auth) UpdateUser(ctx context.Context, userToken string, updateData map[string]interface{}) (*User, error) {
-	reqBody, _ := json.Marshal(updateData)
-	reqURL := fmt.Sprintf("%s/%s/user", a.client.BaseURL, AuthEndpoint)
+	return a.updateUser(ctx, userToken, updateData, "")
+}
+
+// UpdateUserParams contains the user information to be updated, empty fields are left unchanged.
+type UpdateUserParams struct {
+	Email    string `json:"email,omitempty"`
+	Phone    string `json:"phone,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Data is stored in the user metadata
+	Data map[string]interface{} `json:"data,omitempty"`
+	// Nonce is the reauthentication OTP required to change the password when secure password change is enabled
+	Nonce string `json:"nonce,omitempty"`
+}
+
+// UpdateUserWithParams updates the user information with the given params
+func (a *Auth) UpdateUserWithParams(ctx context.Context, userToken string, params UpdateUserParams) (*User, error) {
+	return a.updateUser(ctx, userToken, params, "")
+}
+
+// UpdateEmail changes the email address of the user. When email confirmation is
+// enabled, the change is pending until confirmed and the returned user has
+// NewEmail and EmailChangeSentAt set.
+func (a *Auth) UpdateEmail(ctx context.Context, userToken string, newEmail string, redirectTo string) (*User, error) {
+	return a.updateUser(ctx, userToken, UpdateUserParams{Email: newEmail}, redirectTo)
+}
+
+// UpdatePhone changes the phone number of the user. When phone confirmation is
+// enabled, the change is pending until the OTP is verified and the returned
+// user has NewPhone and PhoneChangeSentAt set.
+func (a *Auth) UpdatePhone(ctx context.Context, userToken string, newPhone string) (*User, error) {
+	return a.updateUser(ctx, userToken, UpdateUserParams{Phone: newPhone}, "")
+}
+
+func (a *Auth) updateUser(ctx context.Context, userToken string, updateData interface{}, redirectTo string) (*User, error) {
+	reqBody, _ := a.client.marshalJSON(updateData)
+	reqURL := fmt.Sprintf("%s/user", a.client.authURL())
+	if redirectTo != "" {
+		reqURL += "?" + url.Values{"redirect_to": {redirectTo}}.Encode()
+	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPut, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
@@ -265,39 +558,122 @@ func (a *Auth) UpdateUser(ctx context.Context, userToken string, updateData map[
 	injectAuthorizationHeader(req, userToken)
 
 	res := User{}
-	errRes := authError{}
-	hasCustomError, err := a.client.sendCustomRequest(req, &res, &errRes)
-	if err != nil {
+	if err := a.sendRequest(req, &res); err != nil {
 		return nil, err
-	} else if hasCustomError {
-		return nil, errors.New(fmt.Sprintf("%s", errRes.Message))
 	}
 
 	return &res, nil
 }
 
+// EmailChangePending reports whether an email change is waiting for confirmation.
+func (u *User) EmailChangePending() bool {
+	return u.NewEmail != ""
+}
+
+// PhoneChangePending reports whether a phone change is waiting for confirmation.
+func (u *User) PhoneChangePending() bool {
+	return u.NewPhone != ""
+}
+
 // ResetPasswordForEmail sends a password recovery link to the given e-mail address.
 func (a *Auth) ResetPasswordForEmail(ctx context.Context, email string, redirectTo string) error {
-	reqBody, _ := json.Marshal(map[string]string{"email": email})
-	reqURL := fmt.Sprintf("%s/%s/recover", a.client.BaseURL, AuthEndpoint)
-	if len(redirectTo) > 0 {
-		reqURL += fmt.Sprintf("?redirect_to=%s", redirectTo)
+	return a.ResetPasswordForEmailWithOptions(ctx, email, ResetPasswordOptions{RedirectTo: redirectTo})
+}
+
+// ResetPasswordOptions contains the optional parameters of a password recovery request.
+// GoTrue renders the recovery email with the stored user metadata as {{ .Data }},
+// use Admin.UpdateUserWithParams to set it beforehand.
+type ResetPasswordOptions struct {
+	// RedirectTo is the URL the user is sent to after following the recovery link
+	RedirectTo string
+	// CaptchaToken is required when captcha protection is enabled on the project
+	CaptchaToken string
+}
+
+// gotrueMetaSecurity carries the captcha token of a request.
+type gotrueMetaSecurity struct {
+	CaptchaToken string `json:"captcha_token,omitempty"`
+}
+
+// ResetPasswordForEmailWithOptions sends a password recovery link to the given e-mail address.
+func (a *Auth) ResetPasswordForEmailWithOptions(ctx context.Context, email string, opts ResetPasswordOptions) error {
+	params := map[string]interface{}{"email": email}
+	if opts.CaptchaToken != "" {
+		params["gotrue_meta_security"] = gotrueMetaSecurity{CaptchaToken: opts.CaptchaToken}
+	}
+
+	reqBody, _ := a.client.marshalJSON(params)
+	reqURL := fmt.Sprintf("%s/recover", a.client.authURL())
+	if opts.RedirectTo != "" {
+		reqURL += "?" + url.Values{"redirect_to": {opts.RedirectTo}}.Encode()
 	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return err
 	}
 
-	if err = a.client.sendRequest(req, nil); err != nil {
+	req.Header.Set("Content-Type", "application/json")
+	if err = a.sendRequest(req, nil); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// SignOut revokes the users token and session.
+// UpdatePasswordWithRecoveryToken sets a new password for the user of a password
+// recovery link. The recovery flow is:
+//
+//  1. ResetPasswordForEmail sends the recovery link to the user.
+//  2. Following the link verifies the recovery token and redirects to the
+//     redirect URL with an access token in the URL fragment. When the link
+//     contains a token hash instead, exchange it with VerifyOtp using
+//     EmailOtpTypeRecovery to obtain the access token.
+//  3. UpdatePasswordWithRecoveryToken updates the password with that access token.
+func (a *Auth) UpdatePasswordWithRecoveryToken(ctx context.Context, accessToken string, newPassword string) (*User, error) {
+	if accessToken == "" {
+		return nil, errors.New("recovery access token is required")
+	}
+	if newPassword == "" {
+		return nil, errors.New("new password is required")
+	}
+
+	return a.UpdateUserWithParams(ctx, accessToken, UpdateUserParams{Password: newPassword})
+}
+
+// SignOutScope selects the sessions revoked by SignOutWithScope.
+type SignOutScope string
+
+const (
+	// SignOutScopeGlobal revokes all sessions of the user
+	SignOutScopeGlobal SignOutScope = "global"
+	// SignOutScopeLocal revokes only the session of the token
+	SignOutScopeLocal SignOutScope = "local"
+	// SignOutScopeOthers revokes all sessions except the one of the token
+	SignOutScopeOthers SignOutScope = "others"
+)
+
+// SignOut revokes the users token and all sessions of the user.
 func (a *Auth) SignOut(ctx context.Context, userToken string) error {
-	reqURL := fmt.Sprintf("%s/%s/logout", a.client.BaseURL, AuthEndpoint)
+	return a.SignOutWithScope(ctx, userToken, SignOutScopeGlobal)
+}
+
+// SignOutWithScope revokes the sessions of the user selected by the scope.
+func (a *Auth) SignOutWithScope(ctx context.Context, userToken string, scope SignOutScope) error {
+	return a.signOut(ctx, userToken, scope)
+}
+
+// RevokeOtherSessions signs the user out of all sessions except the one of the
+// given token, e.g. to log out other devices. GoTrue has no endpoint for
+// listing or revoking individual sessions of a user.
+func (a *Auth) RevokeOtherSessions(ctx context.Context, userToken string) error {
+	return a.signOut(ctx, userToken, SignOutScopeOthers)
+}
+
+func (a *Auth) signOut(ctx context.Context, userToken string, scope SignOutScope) error {
+	reqURL := fmt.Sprintf("%s/logout", a.client.authURL())
+	if scope != "" {
+		reqURL += "?" + url.Values{"scope": {string(scope)}}.Encode()
+	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, nil)
 	if err != nil {
 		return err
@@ -305,10 +681,14 @@ func (a *Auth) SignOut(ctx context.Context, userToken string) error {
 
 	injectAuthorizationHeader(req, userToken)
 	req.Header.Set("Content-Type", "application/json")
-	if err = a.client.sendRequest(req, nil); err != nil {
+	if err = a.sendRequest(req, nil); err != nil {
 		return err
 	}
 
+	// the session of the token stays valid when only other sessions are revoked
+	if scope != SignOutScopeOthers {
+		a.client.clearSession(userToken)
+	}
 	return nil
 }
 
@@ -323,8 +703,8 @@ func (a *Auth) InviteUserByEmailWithData(ctx context.Context, email string, data
 		params["redirectTo"] = redirectTo
 	}
 
-	reqBody, _ := json.Marshal(params)
-	reqURL := fmt.Sprintf("%s/%s/invite", a.client.BaseURL, AuthEndpoint)
+	reqBody, _ := a.client.marshalJSON(params)
+	reqURL := fmt.Sprintf("%s/invite", a.client.authURL())
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
@@ -333,7 +713,7 @@ func (a *Auth) InviteUserByEmailWithData(ctx context.Context, email string, data
 	injectAuthorizationHeader(req, a.client.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 	res := User{}
-	if err := a.client.sendRequest(req, &res); err != nil {
+	if err := a.sendRequest(req, &res); err != nil {
 		return nil, err
 	}
 
@@ -382,6 +762,15 @@ const (
 	PhoneOtpTypePhoneChange PhoneOtpType = "phone_change"
 )
 
+// Valid reports whether the type is a phone OTP type known to GoTrue.
+func (t PhoneOtpType) Valid() bool {
+	switch t {
+	case PhoneOtpTypeSMS, PhoneOtpTypePhoneChange:
+		return true
+	}
+	return false
+}
+
 // VerifyPhoneOtpCredentials is the struct for verifying OTPs sent to a phone number.
 type VerifyPhoneOtpCredentials struct {
 	Phone      string       `mapstructure:"phone"`
@@ -391,20 +780,53 @@ type VerifyPhoneOtpCredentials struct {
 	RedirectTo string       `mapstructure:"redirect_to,omitempty"`
 }
 
+// NewPhoneOtpVerification returns the credentials verifying an OTP sent to a phone number.
+func NewPhoneOtpVerification(phone string, token string, otpType PhoneOtpType) VerifyPhoneOtpCredentials {
+	return VerifyPhoneOtpCredentials{Phone: phone, Token: token, Type: otpType}
+}
+
 func (c VerifyPhoneOtpCredentials) OtpType() string {
 	return string(c.Type)
 }
 
+// Validate checks that the credentials have a known type and a token or token hash.
+func (c VerifyPhoneOtpCredentials) Validate() error {
+	if !c.Type.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidOtpType, c.Type)
+	}
+	if c.TokenHash == "" && (c.Phone == "" || c.Token == "") {
+		return errors.New("a phone number and a token, or a token hash, are required")
+	}
+	return nil
+}
+
 // EmailOtpType is the type of email OTP.
 type EmailOtpType string
 
 const (
 	EmailOtpTypeEmail       EmailOtpType = "email"
-	EmailOtpTypeReceovery   EmailOtpType = "recovery"
+	EmailOtpTypeSignup      EmailOtpType = "signup"
+	EmailOtpTypeMagicLink   EmailOtpType = "magiclink"
+	EmailOtpTypeRecovery    EmailOtpType = "recovery"
 	EmailOtpTypeInvite      EmailOtpType = "invite"
 	EmailOtpTypeEmailChange EmailOtpType = "email_change"
+
+	// Deprecated: use EmailOtpTypeRecovery.
+	EmailOtpTypeReceovery = EmailOtpTypeRecovery
 )
 
+// ErrInvalidOtpType is returned by VerifyOtp for an OTP type unknown to GoTrue.
+var ErrInvalidOtpType = errors.New("invalid OTP type")
+
+// Valid reports whether the type is an email OTP type known to GoTrue.
+func (t EmailOtpType) Valid() bool {
+	switch t {
+	case EmailOtpTypeEmail, EmailOtpTypeSignup, EmailOtpTypeMagicLink, EmailOtpTypeRecovery, EmailOtpTypeInvite, EmailOtpTypeEmailChange:
+		return true
+	}
+	return false
+}
+
 // VerifyEmailOtpCredentials is the struct for verifying OTPs sent to an email address.
 type VerifyEmailOtpCredentials struct {
 	Email      string       `mapstructure:"email"`
@@ -414,11 +836,27 @@ type VerifyEmailOtpCredentials struct {
 	RedirectTo string       `mapstructure:"redirect_to,omitempty"`
 }
 
+// NewEmailOtpVerification returns the credentials verifying an OTP sent to an email address.
+func NewEmailOtpVerification(email string, token string, otpType EmailOtpType) VerifyEmailOtpCredentials {
+	return VerifyEmailOtpCredentials{Email: email, Token: token, Type: otpType}
+}
+
 // OtpType returns the type of OTP.
 func (c VerifyEmailOtpCredentials) OtpType() string {
 	return string(c.Type)
 }
 
+// Validate checks that the credentials have a known type and a token or token hash.
+func (c VerifyEmailOtpCredentials) Validate() error {
+	if !c.Type.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidOtpType, c.Type)
+	}
+	if c.TokenHash == "" && (c.Email == "" || c.Token == "") {
+		return errors.New("an email and a token, or a token hash, are required")
+	}
+	return nil
+}
+
 // VerifyTokenHashOtpCredentials is the struct for verifying OTPs sent other than email or phone.
 type VerifyTokenHashOtpCredentials struct {
 	TokenHash  string `mapstructure:"token_hash"`
@@ -426,13 +864,38 @@ type VerifyTokenHashOtpCredentials struct {
 	RedirectTo string `mapstructure:"redirect_to,omitempty"`
 }
 
+// NewTokenHashVerification returns the credentials verifying the token hash of an email link.
+func NewTokenHashVerification(tokenHash string, otpType EmailOtpType) VerifyTokenHashOtpCredentials {
+	return VerifyTokenHashOtpCredentials{TokenHash: tokenHash, Type: string(otpType)}
+}
+
 // OtpType returns the type of OTP.
 func (c VerifyTokenHashOtpCredentials) OtpType() string {
 	return c.Type
 }
 
+// Validate checks that the credentials have a token hash and an email OTP type.
+func (c VerifyTokenHashOtpCredentials) Validate() error {
+	if !EmailOtpType(c.Type).Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidOtpType, c.Type)
+	}
+	if c.TokenHash == "" {
+		return errors.New("a token hash is required")
+	}
+	return nil
+}
+
 // MarshalVerifyOtpCredentials marshals the VerifyOtpCredentials into a JSON byte slice.
 func MarshalVerifyOtpCredentials(c VerifyOtpCredentials) ([]byte, error) {
+	result, err := verifyOtpParams(c)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// verifyOtpParams returns the fields of the credentials keyed by their GoTrue names.
+func verifyOtpParams(c VerifyOtpCredentials) (map[string]interface{}, error) {
 	result := map[string]interface{}{}
 
 	if err := mapstructure.Decode(c, &result); err != nil {
@@ -440,27 +903,90 @@ func MarshalVerifyOtpCredentials(c VerifyOtpCredentials) ([]byte, error) {
 	}
 
 	result["type"] = c.OtpType()
-	return json.Marshal(result)
+	return result, nil
+}
+
+// VerifyOtpOptions contains the optional parameters of VerifyOtpWithOptions.
+type VerifyOtpOptions struct {
+	// CaptchaToken is required when captcha protection is enabled on the project
+	CaptchaToken string
+}
+
+// VerifyOtpResult is the outcome of an OTP verification. Session is nil when
+// GoTrue only returns the user, e.g. for the first confirmation of an email
+// change with secure email change enabled.
+type VerifyOtpResult struct {
+	Session *AuthenticatedDetails
+	User    *User
 }
 
 // verify otp takes in a token hash and verify type, verifies the user and returns the the user if succeeded.
+// When GoTrue returns no session, the returned details only contain the user;
+// use VerifyOtpWithOptions to tell the two cases apart.
 func (a *Auth) VerifyOtp(ctx context.Context, credentials VerifyOtpCredentials) (*AuthenticatedDetails, error) {
-	reqBody, _ := json.Marshal(credentials)
-	reqURL := fmt.Sprintf("%s/%s/verify", a.client.BaseURL, AuthEndpoint)
+	res, err := a.VerifyOtpWithOptions(ctx, credentials, VerifyOtpOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if res.Session == nil {
+		return &AuthenticatedDetails{User: *res.User}, nil
+	}
+	return res.Session, nil
+}
+
+// VerifyOtpWithOptions verifies an OTP or a token hash. The session, if any,
+// is established like with SignIn.
+func (a *Auth) VerifyOtpWithOptions(ctx context.Context, credentials VerifyOtpCredentials, opts VerifyOtpOptions) (*VerifyOtpResult, error) {
+	if v, ok := credentials.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	params, err := verifyOtpParams(credentials)
+	if err != nil {
+		return nil, err
+	}
+	if opts.CaptchaToken != "" {
+		params["gotrue_meta_security"] = gotrueMetaSecurity{CaptchaToken: opts.CaptchaToken}
+	}
+
+	reqBody, _ := a.client.marshalJSON(params)
+	reqURL := fmt.Sprintf("%s/verify", a.client.authURL())
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	res := AuthenticatedDetails{}
-	errRes := authenticationError{}
-	hasCustomError, err := a.client.sendCustomRequest(req, &res, &errRes)
+	var body json.RawMessage
+	if err := a.sendRequest(req, &body); err != nil {
+		return nil, err
+	}
+
+	session, user, err := a.decodeSessionOrUser(body)
 	if err != nil {
 		return nil, err
-	} else if hasCustomError {
-		return nil, errors.New(fmt.Sprintf("%s: %s", errRes.Error, errRes.ErrorDescription))
 	}
+	return &VerifyOtpResult{Session: session, User: user}, nil
+}
 
-	return &res, nil
+// decodeSessionOrUser decodes the response of GoTrue endpoints returning
+// either a session or, when no session is issued yet, the user itself. The
+// session is nil in the latter case and established otherwise.
+func (a *Auth) decodeSessionOrUser(body []byte) (*AuthenticatedDetails, *User, error) {
+	session := AuthenticatedDetails{}
+	if err := a.client.unmarshalJSON(body, &session); err != nil {
+		return nil, nil, err
+	}
+	if session.AccessToken == "" {
+		user := User{}
+		if err := a.client.unmarshalJSON(body, &user); err != nil {
+			return nil, nil, err
+		}
+		return nil, &user, nil
+	}
+
+	a.client.setSession(&session)
+	return &session, &session.User, nil
 }
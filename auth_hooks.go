@@ -0,0 +1,121 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// CustomAccessTokenInput is the payload of the custom access token hook.
+type CustomAccessTokenInput struct {
+	UserID               string  `json:"user_id"`
+	Claims               JSONMap `json:"claims"`
+	AuthenticationMethod string  `json:"authentication_method"`
+}
+
+// CustomAccessTokenOutput contains the claims of the issued access token.
+type CustomAccessTokenOutput struct {
+	Claims JSONMap `json:"claims"`
+}
+
+// SendSMSInput is the payload of the send SMS hook.
+type SendSMSInput struct {
+	User User `json:"user"`
+	SMS  struct {
+		OTP string `json:"otp"`
+	} `json:"sms"`
+}
+
+// SendSMSOutput is the empty response of the send SMS hook.
+type SendSMSOutput struct{}
+
+// MFAVerificationAttemptInput is the payload of the MFA verification attempt hook.
+type MFAVerificationAttemptInput struct {
+	FactorID   string `json:"factor_id"`
+	FactorType string `json:"factor_type"`
+	UserID     string `json:"user_id"`
+	Valid      bool   `json:"valid"`
+}
+
+// HookDecision tells GoTrue whether to continue or reject a verification attempt.
+type HookDecision string
+
+const (
+	HookDecisionContinue HookDecision = "continue"
+	HookDecisionReject   HookDecision = "reject"
+)
+
+// MFAVerificationAttemptOutput is the response of the MFA verification attempt hook.
+type MFAVerificationAttemptOutput struct {
+	Decision HookDecision `json:"decision"`
+	// Message is shown to the user when the attempt is rejected
+	Message string `json:"message,omitempty"`
+}
+
+// AuthHookError is returned by an auth hook to make GoTrue fail the request
+// with the given status code and message.
+type AuthHookError struct {
+	HTTPCode int    `json:"http_code"`
+	Message  string `json:"message"`
+}
+
+func (err *AuthHookError) Error() string {
+	return err.Message
+}
+
+// AuthHookHandler serves an auth hook with fn, e.g.
+//
+//	http.Handle("/hooks/access-token", supabase.AuthHookHandler(secret,
+//		func(ctx context.Context, in *supabase.CustomAccessTokenInput) (*supabase.CustomAccessTokenOutput, error) {
+//			in.Claims["tenant_id"] = tenantOf(in.UserID)
+//			return &supabase.CustomAccessTokenOutput{Claims: in.Claims}, nil
+//		}))
+//
+// The signature of the request is verified with the secret of the hook, see
+// VerifyWebhookSignature. An *AuthHookError returned by fn is passed to GoTrue,
+// other errors fail the request with 500 Internal Server Error.
+func AuthHookHandler[In any, Out any](secret string, fn func(ctx context.Context, in *In) (*Out, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeErrorResponse(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		body, err := ReadWebhook(r, secret)
+		if err != nil {
+			writeErrorResponse(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		in := new(In)
+		if err := json.Unmarshal(body, in); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		out, err := fn(r.Context(), in)
+		var hookErr *AuthHookError
+		if errors.As(err, &hookErr) {
+			// GoTrue only reads the body of successful responses
+			writeHookResponse(w, struct {
+				Error *AuthHookError `json:"error"`
+			}{hookErr})
+			return
+		} else if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if out == nil {
+			out = new(Out)
+		}
+		writeHookResponse(w, out)
+	})
+}
+
+func writeHookResponse(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,90 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// PhoneSignUpOptions contains the optional parameters of SignUpWithPhone.
+type PhoneSignUpOptions struct {
+	// Channel delivers the confirmation OTP, sms if empty
+	Channel OtpChannel
+	// Data is stored in the user metadata
+	Data map[string]interface{}
+	// CaptchaToken is required when captcha protection is enabled on the project
+	CaptchaToken string
+}
+
+type phoneSignUpParams struct {
+	Phone              string                 `json:"phone"`
+	Password           string                 `json:"password"`
+	Channel            OtpChannel             `json:"channel,omitempty"`
+	Data               map[string]interface{} `json:"data,omitempty"`
+	GotrueMetaSecurity *gotrueMetaSecurity    `json:"gotrue_meta_security,omitempty"`
+}
+
+// PhoneSignUpResult is the outcome of SignUpWithPhone. Session is nil until
+// the phone number is confirmed with VerifyPhone, unless phone autoconfirm is
+// enabled on the project.
+type PhoneSignUpResult struct {
+	Session *AuthenticatedDetails
+	User    *User
+}
+
+// SignUpWithPhone registers a user with a phone number and a password. GoTrue
+// sends an OTP to the phone number through the SMS provider of the project,
+// with WhatsApp if opts.Channel is OtpChannelWhatsApp, which confirms the
+// number when verified with VerifyPhone. The OTP can be sent again with
+// ResendPhoneConfirmation.
+func (a *Auth) SignUpWithPhone(ctx context.Context, phone string, password string, opts PhoneSignUpOptions) (*PhoneSignUpResult, error) {
+	if phone == "" || password == "" {
+		return nil, errors.New("a phone number and a password are required")
+	}
+
+	params := phoneSignUpParams{Phone: phone, Password: password, Channel: opts.Channel, Data: opts.Data}
+	if opts.CaptchaToken != "" {
+		params.GotrueMetaSecurity = &gotrueMetaSecurity{CaptchaToken: opts.CaptchaToken}
+	}
+
+	req, err := a.newRequest(ctx, "/signup", "", params)
+	if err != nil {
+		return nil, err
+	}
+	var body json.RawMessage
+	if err := a.sendRequest(req, &body); err != nil {
+		return nil, err
+	}
+
+	// the response is only the user until the phone number is confirmed
+	session, user, err := a.decodeSessionOrUser(body)
+	if err != nil {
+		return nil, err
+	}
+	return &PhoneSignUpResult{Session: session, User: user}, nil
+}
+
+// VerifyPhone confirms the phone number of a user signed up with
+// SignUpWithPhone, or signs in a user with an OTP sent to their phone, with
+// the OTP received. The session is established like with SignIn.
+func (a *Auth) VerifyPhone(ctx context.Context, phone string, token string) (*AuthenticatedDetails, error) {
+	res, err := a.VerifyOtpWithOptions(ctx, NewPhoneOtpVerification(phone, token, PhoneOtpTypeSMS), VerifyOtpOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if res.Session == nil {
+		return nil, errors.New("phone verification returned no session")
+	}
+	return res.Session, nil
+}
+
+// ResendPhoneConfirmation sends the OTP confirming the phone number of a user
+// signed up with SignUpWithPhone again. GoTrue enforces a minimum interval
+// between two OTPs sent to the same number.
+func (a *Auth) ResendPhoneConfirmation(ctx context.Context, phone string) error {
+	req, err := a.newResendRequest(ctx, ResendParams{Type: ResendTypeSMS, Phone: phone})
+	if err != nil {
+		return err
+	}
+	return a.sendRequest(req, nil)
+}
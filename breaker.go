@@ -0,0 +1,170 @@
+package supabase
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for requests to a service whose circuit breaker
+// is open after repeated failures, see WithCircuitBreaker.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerConfig configures the circuit breakers of WithCircuitBreaker.
+// Zero values use the defaults.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures opening the circuit, 5 by default
+	FailureThreshold int
+	// OpenDuration is how long the circuit stays open before probing the service, 30s by default
+	OpenDuration time.Duration
+	// HalfOpenProbes is the number of requests let through at once to probe the service, 1 by default
+	HalfOpenProbes int
+}
+
+// WithCircuitBreaker adds a circuit breaker to each service (auth, rest,
+// storage and functions). After FailureThreshold consecutive network errors
+// or 5xx responses, requests to the service fail fast with ErrCircuitOpen for
+// OpenDuration. Then up to HalfOpenProbes requests probe the service, closing
+// the circuit on success or opening it again on failure.
+func WithCircuitBreaker(config CircuitBreakerConfig) ClientOption {
+	return func(c *Client) {
+		if config.FailureThreshold <= 0 {
+			config.FailureThreshold = 5
+		}
+		if config.OpenDuration <= 0 {
+			config.OpenDuration = 30 * time.Second
+		}
+		if config.HalfOpenProbes <= 0 {
+			config.HalfOpenProbes = 1
+		}
+		c.breakerConfig = &config
+	}
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+type circuitBreaker struct {
+	config    CircuitBreakerConfig
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openUntil time.Time
+	probes    int
+}
+
+// allow reports whether a request may be sent.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Now().Before(b.openUntil) {
+			return false
+		}
+		b.state, b.probes = breakerHalfOpen, 0
+		fallthrough
+	case breakerHalfOpen:
+		if b.probes >= b.config.HalfOpenProbes {
+			return false
+		}
+		b.probes++
+	}
+	return true
+}
+
+// release gives back the probe of a request without outcome, such as a canceled request.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// record updates the state of the breaker with the outcome of a request.
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.state, b.failures = breakerClosed, 0
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.state, b.openUntil = breakerOpen, time.Now().Add(b.config.OpenDuration)
+	}
+}
+
+// breakerTransport guards the requests to each service with its own circuit breaker.
+type breakerTransport struct {
+	client   *Client
+	parent   http.RoundTripper
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+func (t *breakerTransport) breaker(service string) *circuitBreaker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, ok := t.breakers[service]
+	if !ok {
+		b = &circuitBreaker{config: *t.client.breakerConfig}
+		t.breakers[service] = b
+	}
+	return b
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	service := t.client.serviceOf(req)
+	if service == "" {
+		return t.parent.RoundTrip(req)
+	}
+
+	b := t.breaker(service)
+	if !b.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	res, err := t.parent.RoundTrip(req)
+	if err != nil {
+		// canceled requests say nothing about the health of the service
+		if req.Context().Err() == nil {
+			b.record(true)
+		} else {
+			b.release()
+		}
+		return nil, err
+	}
+	b.record(res.StatusCode >= http.StatusInternalServerError)
+	return res, nil
+}
+
+// serviceOf returns the service a request is sent to, or an empty string for
+// requests to other hosts.
+func (c *Client) serviceOf(req *http.Request) string {
+	reqURL := req.URL.String()
+	for _, service := range []Service{ServiceAuth, ServiceRest, ServiceStorage, ServiceFunctions, ServiceGraphQL} {
+		serviceURL, _ := c.serviceURLOf(service)
+		if strings.HasPrefix(reqURL, serviceURL+"/") || reqURL == serviceURL {
+			return string(service)
+		}
+	}
+	for _, replica := range c.readReplicas {
+		if strings.HasPrefix(reqURL, c.serviceURLFrom(replica, "", RestEndpoint)+"/") {
+			return "rest-replica"
+		}
+	}
+	return ""
+}
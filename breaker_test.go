@@ -0,0 +1,234 @@
+package supabase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeService is a RoundTripper failing with a network error or responding
+// with a status code, and counting the requests reaching it.
+type fakeService struct {
+	mu       sync.Mutex
+	err      error
+	status   int
+	requests int
+	// block holds the requests until it is closed when not nil
+	block chan struct{}
+}
+
+func (s *fakeService) set(status int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.err = status, err
+}
+
+func (s *fakeService) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *fakeService) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	s.requests++
+	status, err, block := s.status, s.err, s.block
+	s.mu.Unlock()
+
+	if block != nil {
+		select {
+		case <-block:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func newBreakerTransport(service *fakeService) (*breakerTransport, *Client) {
+	client := NewClient("http://localhost:54321", "key")
+	client.breakerConfig = &CircuitBreakerConfig{FailureThreshold: 3, OpenDuration: 20 * time.Millisecond, HalfOpenProbes: 1}
+	return &breakerTransport{client: client, parent: service, breakers: map[string]*circuitBreaker{}}, client
+}
+
+func sendTo(t *testing.T, transport http.RoundTripper, ctx context.Context, url string) error {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := transport.RoundTrip(req)
+	if err == nil {
+		res.Body.Close()
+	}
+	return err
+}
+
+var errNetwork = errors.New("connection refused")
+
+func TestBreakerTransport_Opens(t *testing.T) {
+	service := &fakeService{err: errNetwork}
+	transport, client := newBreakerTransport(service)
+	restURL := client.restURL() + "/posts"
+
+	for i := 0; i < 3; i++ {
+		if err := sendTo(t, transport, context.Background(), restURL); !errors.Is(err, errNetwork) {
+			t.Fatalf("request %d error = %v, want %v", i, err, errNetwork)
+		}
+	}
+	if err := sendTo(t, transport, context.Background(), restURL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("request after the threshold error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if got := service.count(); got != 3 {
+		t.Errorf("the service got %d requests, want 3", got)
+	}
+
+	// the other services have their own breaker
+	if err := sendTo(t, transport, context.Background(), client.BaseURL+"/"+StorageEndpoint+"/bucket"); !errors.Is(err, errNetwork) {
+		t.Errorf("storage request error = %v, want %v", err, errNetwork)
+	}
+	// and requests to other hosts are not guarded
+	for i := 0; i < 5; i++ {
+		if err := sendTo(t, transport, context.Background(), "http://example.com/"); !errors.Is(err, errNetwork) {
+			t.Fatalf("request to another host error = %v, want %v", err, errNetwork)
+		}
+	}
+}
+
+func TestBreakerTransport_ServerErrors(t *testing.T) {
+	service := &fakeService{status: http.StatusServiceUnavailable}
+	transport, client := newBreakerTransport(service)
+	restURL := client.restURL() + "/posts"
+
+	// a success resets the consecutive failures
+	for _, status := range []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusNotFound, http.StatusBadGateway, http.StatusInternalServerError} {
+		service.set(status, nil)
+		if err := sendTo(t, transport, context.Background(), restURL); err != nil {
+			t.Fatalf("request with status %d error = %v", status, err)
+		}
+	}
+	service.set(http.StatusServiceUnavailable, nil)
+	if err := sendTo(t, transport, context.Background(), restURL); err != nil {
+		t.Fatalf("third consecutive failure error = %v", err)
+	}
+	if err := sendTo(t, transport, context.Background(), restURL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("request after the threshold error = %v, want %v", err, ErrCircuitOpen)
+	}
+}
+
+func TestBreakerTransport_HalfOpen(t *testing.T) {
+	service := &fakeService{err: errNetwork}
+	transport, client := newBreakerTransport(service)
+	restURL := client.restURL() + "/posts"
+
+	for i := 0; i < 3; i++ {
+		sendTo(t, transport, context.Background(), restURL)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	// a single probe is let through while the circuit is half open
+	service.set(http.StatusOK, nil)
+	service.block = make(chan struct{})
+	probe := make(chan error, 1)
+	go func() {
+		probe <- sendTo(t, transport, context.Background(), restURL)
+	}()
+	for service.count() != 4 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := sendTo(t, transport, context.Background(), restURL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("request during the probe error = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	// the circuit closes when the probe succeeds
+	close(service.block)
+	if err := <-probe; err != nil {
+		t.Fatalf("probe error = %v", err)
+	}
+	service.block = nil
+	for i := 0; i < 5; i++ {
+		if err := sendTo(t, transport, context.Background(), restURL); err != nil {
+			t.Fatalf("request %d after the probe error = %v", i, err)
+		}
+	}
+	if got := service.count(); got != 9 {
+		t.Errorf("the service got %d requests, want 9", got)
+	}
+}
+
+func TestBreakerTransport_FailedProbe(t *testing.T) {
+	service := &fakeService{err: errNetwork}
+	transport, client := newBreakerTransport(service)
+	restURL := client.restURL() + "/posts"
+
+	for i := 0; i < 3; i++ {
+		sendTo(t, transport, context.Background(), restURL)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	// a failed probe opens the circuit again right away
+	if err := sendTo(t, transport, context.Background(), restURL); !errors.Is(err, errNetwork) {
+		t.Fatalf("probe error = %v, want %v", err, errNetwork)
+	}
+	if err := sendTo(t, transport, context.Background(), restURL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("request after the failed probe error = %v, want %v", err, ErrCircuitOpen)
+	}
+}
+
+func TestBreakerTransport_CanceledProbe(t *testing.T) {
+	service := &fakeService{err: errNetwork}
+	transport, client := newBreakerTransport(service)
+	restURL := client.restURL() + "/posts"
+
+	for i := 0; i < 3; i++ {
+		sendTo(t, transport, context.Background(), restURL)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	// a canceled probe gives its place back without opening the circuit
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sendTo(t, transport, ctx, restURL); !errors.Is(err, errNetwork) {
+		t.Fatalf("canceled probe error = %v, want %v", err, errNetwork)
+	}
+	service.set(http.StatusOK, nil)
+	if err := sendTo(t, transport, context.Background(), restURL); err != nil {
+		t.Fatalf("probe after the canceled one error = %v", err)
+	}
+}
+
+func TestWithCircuitBreaker_Storage(t *testing.T) {
+	client := NewClient(refusedURL(t), "key", WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenDuration: time.Minute}))
+	files := client.Storage.From("avatars")
+
+	for i := 0; i < 2; i++ {
+		if _, err := files.Download("a.txt"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("download %d error = %v, want a network error", i, err)
+		}
+	}
+
+	// the storage calls fail fast instead of crashing once the circuit is open
+	if _, err := files.Download("a.txt"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Download error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if _, err := files.UploadWithContext(context.Background(), "a.txt", strings.NewReader("a"), nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("UploadWithContext error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if _, err := files.RemoveWithContext(context.Background(), []string{"a.txt"}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("RemoveWithContext error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if res := files.Upload("a.txt", strings.NewReader("a"), nil); !strings.Contains(res.Message, ErrCircuitOpen.Error()) {
+		t.Errorf("Upload message = %q, want %q", res.Message, ErrCircuitOpen)
+	}
+	if res := files.Remove([]string{"a.txt"}); !strings.Contains(res.Message, ErrCircuitOpen.Error()) {
+		t.Errorf("Remove message = %q, want %q", res.Message, ErrCircuitOpen)
+	}
+}
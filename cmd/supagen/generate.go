@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// initialisms are written in upper case in Go names, e.g. user_id is UserID.
+var initialisms = map[string]bool{
+	"api": true, "id": true, "ip": true, "json": true, "html": true, "http": true,
+	"sql": true, "ssl": true, "uid": true, "uri": true, "url": true, "uuid": true,
+}
+
+// goName converts a snake case or free-form identifier to an exported Go name.
+func goName(s string) string {
+	name := camelCase(s)
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// camelCase joins the words of s in camel case, which may start with a digit.
+func camelCase(s string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	return b.String()
+}
+
+// paramName converts a column name to the name of a function parameter.
+func paramName(s string) string {
+	name := goName(s)
+	if strings.ToUpper(name) == name {
+		name = strings.ToLower(name)
+	} else {
+		runes := []rune(name)
+		runes[0] = unicode.ToLower(runes[0])
+		name = string(runes)
+	}
+	if token.IsKeyword(name) || name == "ctx" || name == "t" {
+		name += "Value"
+	}
+	return name
+}
+
+// enum is a Postgres enum type used by a column.
+type enum struct {
+	GoName string
+	Values []string
+}
+
+type generator struct {
+	pkg     string
+	tables  []table
+	enums   map[string]*enum
+	imports map[string]bool
+	// used are the generated identifiers of the package
+	used map[string]bool
+	buf  bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// generate returns the formatted Go source of the bindings of the tables.
+func generate(pkg string, tables []table) ([]byte, error) {
+	g := &generator{pkg: pkg, tables: tables, enums: map[string]*enum{}, imports: map[string]bool{}, used: map[string]bool{}}
+	if err := g.reserveTableNames(); err != nil {
+		return nil, err
+	}
+	g.collectEnums()
+
+	// the body is generated first to know the imports it needs
+	for _, e := range g.sortedEnums() {
+		g.writeEnum(e)
+	}
+	for _, t := range g.tables {
+		g.writeTable(t)
+	}
+	body := g.buf.Bytes()
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by supagen. DO NOT EDIT.\n\npackage %s\n\n", g.pkg)
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			imports = append(imports, path)
+		}
+		sort.Strings(imports)
+		src.WriteString("import (\n")
+		for _, path := range imports {
+			if path != postgrestImport {
+				fmt.Fprintf(&src, "\t%q\n", path)
+			}
+		}
+		if g.imports[postgrestImport] {
+			fmt.Fprintf(&src, "\n\tpostgrest %q\n", postgrestImport)
+		}
+		src.WriteString(")\n\n")
+	}
+	src.Write(body)
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+const postgrestImport = "github.com/nedpals/supabase-go/postgrest/pkg"
+
+// reserveTableNames reserves the identifiers generated for the tables, which
+// must be distinct, e.g. posts and posts_row would both generate PostsRow.
+func (g *generator) reserveTableNames() error {
+	owners := map[string]string{}
+	for _, t := range g.tables {
+		name := goName(t.Name)
+		for _, suffix := range []string{"", "Row", "Insert", "Update", "Table"} {
+			if owner, ok := owners[name+suffix]; ok {
+				return fmt.Errorf("tables %s and %s both generate %s, exclude one of them with -tables", owner, t.Name, name+suffix)
+			}
+			owners[name+suffix] = t.Name
+			g.used[name+suffix] = true
+		}
+	}
+	return nil
+}
+
+// collectEnums names the enum types of the columns, avoiding the names of
+// the types generated for the tables.
+func (g *generator) collectEnums() {
+	for _, t := range g.tables {
+		for _, c := range t.Columns {
+			if len(c.Enum) == 0 || g.enums[c.Format] != nil {
+				continue
+			}
+			// drop the schema of the type, e.g. public.post_status
+			typeName := c.Format
+			if i := strings.LastIndex(typeName, "."); i >= 0 {
+				typeName = typeName[i+1:]
+			}
+			name := goName(typeName)
+			for g.used[name] {
+				name += "Enum"
+			}
+			g.used[name] = true
+			g.enums[c.Format] = &enum{GoName: name, Values: c.Enum}
+		}
+	}
+}
+
+func (g *generator) sortedEnums() []*enum {
+	enums := make([]*enum, 0, len(g.enums))
+	for _, e := range g.enums {
+		enums = append(enums, e)
+	}
+	sort.Slice(enums, func(i, j int) bool { return enums[i].GoName < enums[j].GoName })
+	return enums
+}
+
+func (g *generator) writeEnum(e *enum) {
+	g.printf("// %s is a value of a Postgres enum type.\n", e.GoName)
+	g.printf("type %s string\n\n", e.GoName)
+	g.printf("const (\n")
+	for _, value := range e.Values {
+		suffix := camelCase(value)
+		if suffix == "" {
+			suffix = "Empty"
+		}
+		name := e.GoName + suffix
+		for g.used[name] {
+			name += "_"
+		}
+		g.used[name] = true
+		g.printf("\t%s %s = %q\n", name, e.GoName, value)
+	}
+	g.printf(")\n\n")
+}
+
+// baseType returns the Go type of the non-null values of a column.
+func (g *generator) baseType(c column) string {
+	if e := g.enums[c.Format]; e != nil {
+		return e.GoName
+	}
+	if c.Type == "array" || strings.HasSuffix(c.Format, "[]") {
+		elemFormat := strings.TrimSuffix(c.Format, "[]")
+		if e := g.enums[elemFormat]; e != nil {
+			return "[]" + e.GoName
+		}
+		return "[]" + g.scalarType(elemFormat, c.ItemType)
+	}
+	return g.scalarType(c.Format, c.Type)
+}
+
+// scalarType maps a Postgres type, or the JSON type of unknown Postgres
+// types, to a Go type.
+func (g *generator) scalarType(pgType, jsonType string) string {
+	switch pgType {
+	case "smallint":
+		return "int16"
+	case "integer":
+		return "int32"
+	case "bigint":
+		return "int64"
+	case "real":
+		return "float32"
+	case "double precision", "numeric":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "json", "jsonb":
+		g.imports["encoding/json"] = true
+		return "json.RawMessage"
+	case "timestamp with time zone":
+		g.imports[postgrestImport] = true
+		return "postgrest.Timestamptz"
+	case "timestamp without time zone":
+		g.imports[postgrestImport] = true
+		return "postgrest.Timestamp"
+	case "date":
+		g.imports[postgrestImport] = true
+		return "postgrest.Date"
+	case "time without time zone":
+		g.imports[postgrestImport] = true
+		return "postgrest.TimeOfDay"
+	}
+	if pgType == "vector" || strings.HasSuffix(pgType, ".vector") {
+		g.imports[postgrestImport] = true
+		return "postgrest.Vector"
+	}
+
+	switch jsonType {
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "string":
+		return "string"
+	}
+	g.imports["encoding/json"] = true
+	return "json.RawMessage"
+}
+
+// nilable tells whether the zero value of a Go type encodes as null.
+func nilable(goType string) bool {
+	return strings.HasPrefix(goType, "[]") || goType == "json.RawMessage" || goType == "postgrest.Vector"
+}
+
+// optional returns the type of a column that may be omitted or null.
+func optional(goType string) string {
+	if nilable(goType) {
+		return goType
+	}
+	return "*" + goType
+}
+
+// columnComment returns the comment of a column without the notes PostgREST
+// appends about keys.
+func columnComment(c column) string {
+	comment, _, _ := strings.Cut(c.Description, "Note:\n")
+	return strings.Join(strings.Fields(comment), " ")
+}
+
+func (g *generator) writeStruct(name, doc string, cols []column, field func(c column) (goType string, tag string)) {
+	g.printf("// %s\n", doc)
+	g.printf("type %s struct {\n", name)
+	used := map[string]bool{}
+	for _, c := range cols {
+		fieldName := goName(c.Name)
+		for used[fieldName] {
+			fieldName += "_"
+		}
+		used[fieldName] = true
+
+		if comment := columnComment(c); comment != "" {
+			g.printf("\t// %s\n", comment)
+		}
+		goType, tag := field(c)
+		g.printf("\t%s %s `json:%q`\n", fieldName, goType, tag)
+	}
+	g.printf("}\n\n")
+}
+
+func (g *generator) writeTable(t table) {
+	name := goName(t.Name)
+	rowType, insertType, updateType, tableType := name+"Row", name+"Insert", name+"Update", name+"Table"
+
+	rowDoc := fmt.Sprintf("%s is a row of %s.", rowType, t.Name)
+	if comment := strings.Join(strings.Fields(t.Description), " "); comment != "" {
+		rowDoc += "\n//\n// " + comment
+	}
+	g.writeStruct(rowType, rowDoc, t.Columns, func(c column) (string, string) {
+		goType := g.baseType(c)
+		if c.Nullable {
+			goType = optional(goType)
+		}
+		return goType, c.Name
+	})
+	if t.Methods["POST"] {
+		doc := fmt.Sprintf("%s is a row inserted into %s. Nil fields are omitted, so the columns get their default value.", insertType, t.Name)
+		g.writeStruct(insertType, doc, t.Columns, func(c column) (string, string) {
+			if !c.Nullable && !c.HasDefault && !c.PrimaryKey {
+				return g.baseType(c), c.Name
+			}
+			return optional(g.baseType(c)), c.Name + ",omitempty"
+		})
+	}
+	if t.Methods["PATCH"] {
+		doc := fmt.Sprintf("%s contains the columns of %s to update, nil fields are left unchanged.", updateType, t.Name)
+		g.writeStruct(updateType, doc, t.Columns, func(c column) (string, string) {
+			return optional(g.baseType(c)), c.Name + ",omitempty"
+		})
+	}
+
+	g.imports["context"] = true
+	g.imports[postgrestImport] = true
+	g.printf("// %s queries %s.\n", tableType, t.Name)
+	g.printf("type %s struct {\n\tdb *postgrest.Client\n}\n\n", tableType)
+	g.printf("// %s returns the queries of %s, sent with db.\n", name, t.Name)
+	g.printf("func %s(db *postgrest.Client) %s {\n\treturn %s{db: db}\n}\n\n", name, tableType, tableType)
+
+	if t.Methods["GET"] {
+		g.printf("// Select returns the rows of %s, filtered, ordered and paginated by build if not nil.\n", t.Name)
+		g.printf("func (t %s) Select(ctx context.Context, build func(q *postgrest.SelectRequestBuilder)) ([]%s, error) {\n", tableType, rowType)
+		g.printf("\tq := t.db.From(%q).Select(\"*\")\n", t.Name)
+		g.printf("\tif build != nil {\n\t\tbuild(q)\n\t}\n")
+		g.printf("\tvar rows []%s\n", rowType)
+		g.printf("\tif err := q.ExecuteWithContext(ctx, &rows); err != nil {\n\t\treturn nil, err\n\t}\n")
+		g.printf("\treturn rows, nil\n}\n\n")
+		g.writeGet(t, tableType, rowType)
+	}
+	if t.Methods["POST"] {
+		g.printf("// Insert inserts rows into %s and returns them as inserted. The columns omitted\n", t.Name)
+		g.printf("// from some of several rows get their default value with PostgREST 12 or later.\n")
+		g.printf("func (t %s) Insert(ctx context.Context, rows ...%s) ([]%s, error) {\n", tableType, insertType, rowType)
+		g.printf("\tif len(rows) == 0 {\n\t\treturn nil, nil\n\t}\n")
+		g.printf("\tq := t.db.From(%q).Insert(rows)\n", t.Name)
+		g.printf("\tif len(rows) > 1 {\n")
+		g.printf("\t\t// PostgREST takes the columns of the first row otherwise\n")
+		g.printf("\t\tq.Columns(%s).MissingDefault()\n\t}\n", quotedColumns(t.Columns))
+		g.printf("\tvar inserted []%s\n", rowType)
+		g.printf("\tif err := q.ExecuteWithContext(ctx, &inserted); err != nil {\n\t\treturn nil, err\n\t}\n")
+		g.printf("\treturn inserted, nil\n}\n\n")
+	}
+	if t.Methods["PATCH"] {
+		g.printf("// Update sets the columns of values in the rows of %s matched by filter and\n", t.Name)
+		g.printf("// returns the updated rows. Supabase rejects updates without a filter.\n")
+		g.printf("func (t %s) Update(ctx context.Context, values %s, filter func(q *postgrest.FilterRequestBuilder)) ([]%s, error) {\n", tableType, updateType, rowType)
+		g.printf("\tq := t.db.From(%q).Update(values)\n", t.Name)
+		g.printf("\tif filter != nil {\n\t\tfilter(q)\n\t}\n")
+		g.printf("\tvar updated []%s\n", rowType)
+		g.printf("\tif err := q.ExecuteWithContext(ctx, &updated); err != nil {\n\t\treturn nil, err\n\t}\n")
+		g.printf("\treturn updated, nil\n}\n\n")
+	}
+	if t.Methods["DELETE"] {
+		g.printf("// Delete deletes the rows of %s matched by filter. Supabase rejects deletes\n", t.Name)
+		g.printf("// without a filter.\n")
+		g.printf("func (t %s) Delete(ctx context.Context, filter func(q *postgrest.FilterRequestBuilder)) error {\n", tableType)
+		g.printf("\tq := t.db.From(%q).Delete()\n", t.Name)
+		g.printf("\tif filter != nil {\n\t\tfilter(q)\n\t}\n")
+		g.printf("\treturn q.ExecuteWithContext(ctx, nil)\n}\n\n")
+	}
+}
+
+// writeGet writes the Get method of tables with a single column primary key
+// of a type formatting as its literal with fmt.Sprint.
+func (g *generator) writeGet(t table, tableType, rowType string) {
+	var keys []column
+	for _, c := range t.Columns {
+		if c.PrimaryKey {
+			keys = append(keys, c)
+		}
+	}
+	if len(keys) != 1 {
+		return
+	}
+
+	key := keys[0]
+	goType := g.baseType(key)
+	switch {
+	case goType == "string", goType == "int16", goType == "int32", goType == "int64":
+	case g.enums[key.Format] != nil:
+	default:
+		return
+	}
+
+	g.imports["fmt"] = true
+	param := paramName(key.Name)
+	g.printf("// Get returns the row of %s with the given %s, nil if there is none.\n", t.Name, key.Name)
+	g.printf("func (t %s) Get(ctx context.Context, %s %s) (*%s, error) {\n", tableType, param, goType, rowType)
+	g.printf("\trows, err := t.Select(ctx, func(q *postgrest.SelectRequestBuilder) {\n")
+	g.printf("\t\tq.Eq(%q, fmt.Sprint(%s))\n\t\tq.Limit(1)\n\t})\n", key.Name, param)
+	g.printf("\tif err != nil || len(rows) == 0 {\n\t\treturn nil, err\n\t}\n")
+	g.printf("\treturn &rows[0], nil\n}\n\n")
+}
+
+func quotedColumns(cols []column) string {
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = fmt.Sprintf("%q", c.Name)
+	}
+	return strings.Join(names, ", ")
+}
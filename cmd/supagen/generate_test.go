@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func loadSpec(t *testing.T) *openAPI {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "openapi.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec openAPI
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+	return &spec
+}
+
+func TestGenerate(t *testing.T) {
+	tables, err := loadSpec(t).tables(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate("database", tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "tables.golden")
+	if *update {
+		if err := os.WriteFile(golden, src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Errorf("generated code differs from %s, run go test -update to update it:\n%s", golden, src)
+	}
+
+	// compare with the alignment of gofmt collapsed
+	code := strings.Join(strings.Fields(string(src)), " ")
+	for _, fragment := range []string{
+		"PostStatusInReview PostStatus = \"in review\"",
+		"Labels []string `json:\"labels\"`",
+		"Scores []int32 `json:\"scores\"`",
+		"func (t PostsTable) Get(ctx context.Context, id int64)",
+		"func (t TagsTable) Insert(",
+	} {
+		if !strings.Contains(code, fragment) {
+			t.Errorf("expected the generated code to contain %q", fragment)
+		}
+	}
+	// the view only allows GET and tags has no primary key
+	for _, fragment := range []string{"PostStatsInsert", "PostStatsUpdate", "func (t PostStatsTable) Delete", "func (t TagsTable) Get"} {
+		if strings.Contains(code, fragment) {
+			t.Errorf("expected the generated code not to contain %q", fragment)
+		}
+	}
+}
+
+func TestGenerate_Compiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the build of the generated code in short mode")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	tables, err := loadSpec(t).tables(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate("database", tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the package is built inside the module to resolve the postgrest import,
+	// the underscore keeps it out of ./... patterns
+	dir, err := os.MkdirTemp(".", "_generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "tables_gen.go"), src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(goBin, "vet", "./"+filepath.Base(dir))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generated code does not compile: %v\n%s", err, out)
+	}
+}
+
+func TestGenerate_NameClash(t *testing.T) {
+	tables := []table{
+		{Name: "posts", Methods: map[string]bool{"GET": true}},
+		{Name: "posts_row", Methods: map[string]bool{"GET": true}},
+	}
+	_, err := generate("database", tables)
+	if err == nil || !strings.Contains(err.Error(), "PostsRow") {
+		t.Errorf("expected an error about PostsRow, got %v", err)
+	}
+}
+
+func TestTables_Unknown(t *testing.T) {
+	if _, err := loadSpec(t).tables([]string{"missing"}); err == nil {
+		t.Errorf("expected an error selecting a missing table")
+	}
+
+	tables, err := loadSpec(t).tables([]string{"tags"})
+	if err != nil || len(tables) != 1 || tables[0].Name != "tags" {
+		t.Errorf("expected only the tags table, got %v %v", tables, err)
+	}
+}
@@ -0,0 +1,82 @@
+// Command supagen generates Go bindings of the tables and views of a Supabase
+// database from the OpenAPI description of PostgREST, like supabase gen types
+// does for TypeScript.
+//
+// For each table or view, it generates a Row struct of its columns, Insert and
+// Update structs if it is writable, and a Table type whose Select, Get,
+// Insert, Update and Delete methods send typed queries with a postgrest
+// client. Enum types are generated as string types with a constant per value.
+// Database functions are not generated.
+//
+// Usage:
+//
+//	supagen [-url URL] [-key KEY] [-schema public] [-package database] [-tables a,b] [-o file]
+//
+// The URL and the key default to the SUPABASE_URL and SUPABASE_KEY
+// environment variables. Recent PostgREST versions only describe the schema to
+// the service role, so use the service key. The bindings can be regenerated
+// with go generate:
+//
+//	//go:generate go run github.com/nedpals/supabase-go/cmd/supagen -package database -o tables_gen.go
+//
+// The generated queries use the schema of the postgrest client, so bindings of
+// a schema other than public need a client created with postgrest.WithSchema.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	baseURL := flag.String("url", os.Getenv("SUPABASE_URL"), "URL of the Supabase project")
+	apiKey := flag.String("key", os.Getenv("SUPABASE_KEY"), "API key of the Supabase project, preferably the service key")
+	schema := flag.String("schema", "", "database schema, the default schema of PostgREST if empty")
+	pkg := flag.String("package", "database", "package name of the generated code")
+	tableList := flag.String("tables", "", "comma separated tables and views to generate, all if empty")
+	output := flag.String("o", "", "output file, standard output if empty")
+	flag.Parse()
+
+	if err := run(*baseURL, *apiKey, *schema, *pkg, *tableList, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "supagen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(baseURL, apiKey, schema, pkg, tableList, output string) error {
+	if baseURL == "" || apiKey == "" {
+		return fmt.Errorf("the project URL and API key are required, set -url and -key or SUPABASE_URL and SUPABASE_KEY")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	spec, err := fetchSchema(ctx, baseURL, apiKey, schema)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, name := range strings.Split(tableList, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	tables, err := spec.tables(names)
+	if err != nil {
+		return err
+	}
+
+	src, err := generate(pkg, tables)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(output, src, 0o644)
+}
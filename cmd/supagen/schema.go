@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	supabase "github.com/nedpals/supabase-go"
+)
+
+// openAPI is the part of the OpenAPI (Swagger 2.0) description of PostgREST
+// describing the tables and views.
+type openAPI struct {
+	Definitions map[string]definition      `json:"definitions"`
+	Paths       map[string]json.RawMessage `json:"paths"`
+}
+
+type definition struct {
+	Description string     `json:"description"`
+	Properties  properties `json:"properties"`
+	Required    []string   `json:"required"`
+}
+
+type property struct {
+	Name        string          `json:"-"`
+	Type        string          `json:"type"`
+	Format      string          `json:"format"`
+	Description string          `json:"description"`
+	Default     json.RawMessage `json:"default"`
+	Enum        []string        `json:"enum"`
+	Items       *property       `json:"items"`
+}
+
+// properties keeps the properties in the order of the description, which
+// PostgREST sorts by column position.
+type properties []property
+
+func (p *properties) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		prop := property{Name: token.(string)}
+		if err := dec.Decode(&prop); err != nil {
+			return err
+		}
+		*p = append(*p, prop)
+	}
+	return nil
+}
+
+// table is a table or view of the schema.
+type table struct {
+	Name        string
+	Description string
+	Columns     []column
+	// Methods are the HTTP methods PostgREST accepts for the table, views
+	// that are not updatable only accept GET
+	Methods map[string]bool
+}
+
+type column struct {
+	Name        string
+	Description string
+	Format      string
+	Type        string
+	Enum        []string
+	// ItemType is the JSON type of the elements of array columns
+	ItemType   string
+	Nullable   bool
+	HasDefault bool
+	PrimaryKey bool
+}
+
+// fetchSchema gets the OpenAPI description of the schema from PostgREST.
+func fetchSchema(ctx context.Context, baseURL, apiKey, schema string) (*openAPI, error) {
+	reqURL := strings.TrimSuffix(baseURL, "/") + "/" + supabase.RestEndpoint + "/"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("apikey", apiKey)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Accept", "application/openapi+json")
+	if schema != "" {
+		req.Header.Set("Accept-Profile", schema)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with %s: %s", reqURL, res.Status, bytes.TrimSpace(body))
+	}
+
+	var spec openAPI
+	if err := json.Unmarshal(body, &spec); err != nil {
+		return nil, fmt.Errorf("decode OpenAPI description: %w", err)
+	}
+	return &spec, nil
+}
+
+// tables returns the tables and views of the description sorted by name,
+// only the given ones if names is not empty.
+func (spec *openAPI) tables(names []string) ([]table, error) {
+	selected := map[string]bool{}
+	for _, name := range names {
+		if _, ok := spec.Definitions[name]; !ok {
+			return nil, fmt.Errorf("table %s not found in the schema", name)
+		}
+		selected[name] = true
+	}
+
+	tables := make([]table, 0, len(spec.Definitions))
+	for name, def := range spec.Definitions {
+		if len(selected) > 0 && !selected[name] {
+			continue
+		}
+
+		t := table{Name: name, Description: def.Description, Methods: map[string]bool{}}
+		var methods map[string]json.RawMessage
+		if path, ok := spec.Paths["/"+name]; ok {
+			if err := json.Unmarshal(path, &methods); err != nil {
+				return nil, fmt.Errorf("decode path of %s: %w", name, err)
+			}
+		}
+		for method := range methods {
+			t.Methods[strings.ToUpper(method)] = true
+		}
+
+		required := map[string]bool{}
+		for _, name := range def.Required {
+			required[name] = true
+		}
+		for _, prop := range def.Properties {
+			col := column{
+				Name:        prop.Name,
+				Description: prop.Description,
+				Format:      prop.Format,
+				Type:        prop.Type,
+				Enum:        prop.Enum,
+				Nullable:    !required[prop.Name],
+				HasDefault:  len(prop.Default) > 0,
+				// PostgREST marks primary keys in the description
+				PrimaryKey: strings.Contains(prop.Description, "<pk/>"),
+			}
+			if prop.Items != nil {
+				col.ItemType = prop.Items.Type
+			}
+			t.Columns = append(t.Columns, col)
+		}
+		tables = append(tables, t)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables, nil
+}
@@ -0,0 +1,91 @@
+package supabase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	postgrest "github.com/nedpals/supabase-go/postgrest/pkg"
+)
+
+// APIError is an error response of a Supabase service. It is implemented by
+// *AuthError, *FileErrorResponse, *ErrorResponse, *HTTPError and
+// *postgrest.RequestError, so the status of any error response can be read
+// with errors.As:
+//
+//	var apiErr supabase.APIError
+//	if errors.As(err, &apiErr) && apiErr.HTTPStatus() == http.StatusNotFound {
+//		// ...
+//	}
+type APIError interface {
+	error
+	// HTTPStatus returns the HTTP status code of the response, 0 if unknown
+	HTTPStatus() int
+}
+
+var _ APIError = (*postgrest.RequestError)(nil)
+
+// NetworkError is returned when a request could not be sent or its response
+// could not be received, e.g. on a DNS failure, a refused or reset connection
+// or a timeout. It is usually wrapped in the *url.Error of the HTTP client,
+// which already names the request in its message.
+type NetworkError struct {
+	Method string
+	// URL is the URL of the request without its query, which may contain tokens
+	URL string
+	Err error
+}
+
+func (err *NetworkError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *NetworkError) Unwrap() error {
+	return err.Err
+}
+
+// DecodeError is returned when the body of a successful response cannot be
+// decoded into the result.
+type DecodeError = postgrest.DecodeError
+
+// IsRetryable reports whether the request that failed with err may succeed if
+// sent again: network errors other than a cancellation, and error responses
+// with a 408, 429, 500, 502, 503 or 504 status. Other error responses such as
+// 4xx are permanent, as are decode errors.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var netErr *NetworkError
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var apiErr APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.HTTPStatus() {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
+
+// networkTransport wraps the errors of the transport in a *NetworkError.
+type networkTransport struct {
+	parent http.RoundTripper
+}
+
+func (t *networkTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.parent.RoundTrip(req)
+	if err != nil {
+		return nil, &NetworkError{
+			Method: req.Method,
+			URL:    req.URL.Scheme + "://" + req.URL.Host + req.URL.Path,
+			Err:    err,
+		}
+	}
+	return res, nil
+}
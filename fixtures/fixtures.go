@@ -0,0 +1,252 @@
+// Package fixtures loads rows into the tables of a database through PostgREST,
+// e.g. to seed a local Supabase stack in integration tests.
+//
+// Fixtures map table names to their rows, optionally listing the tables they
+// reference so they are loaded after them:
+//
+//	{
+//		"users": [{"id": 1, "email": "alice@example.com"}],
+//		"posts": {"depends_on": ["users"], "rows": [{"id": 1, "user_id": 1, "title": "Hello"}]}
+//	}
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	postgrest "github.com/nedpals/supabase-go/postgrest/pkg"
+)
+
+// DefaultTruncateFunction is the database function called to truncate the
+// tables before loading them.
+const DefaultTruncateFunction = "truncate_tables"
+
+// TruncateFunctionSQL creates the function called by Load to truncate the
+// tables of the fixtures. Only create it in test databases.
+const TruncateFunctionSQL = `create or replace function truncate_tables(tables text[]) returns void
+language plpgsql security definer as $$
+begin
+	execute 'truncate table ' || (select string_agg(quote_ident(t), ', ') from unnest(tables) t) || ' restart identity cascade';
+end;
+$$;`
+
+// Table is the rows loaded into a table.
+type Table struct {
+	Name string
+	// DependsOn lists the tables loaded before this one
+	DependsOn []string
+	Rows      []map[string]interface{}
+}
+
+// Loader loads fixtures into the tables of a database.
+type Loader struct {
+	db               *postgrest.Client
+	truncateFunction string
+	unmarshal        func([]byte, interface{}) error
+}
+
+// Option configures a Loader created with New.
+type Option func(l *Loader)
+
+// WithTruncateFunction sets the database function called with the names of
+// the tables to truncate them before loading, an empty name disables it.
+func WithTruncateFunction(name string) Option {
+	return func(l *Loader) {
+		l.truncateFunction = name
+	}
+}
+
+// WithUnmarshal sets the function decoding fixture files, json.Unmarshal by
+// default. Fixtures can be written in YAML with the Unmarshal function of a
+// YAML package decoding mappings into map[string]interface{}, such as
+// gopkg.in/yaml.v3.
+func WithUnmarshal(unmarshal func([]byte, interface{}) error) Option {
+	return func(l *Loader) {
+		l.unmarshal = unmarshal
+	}
+}
+
+// New creates a fixture loader inserting rows with the given client, which
+// needs the privileges to write to the tables, e.g. with the service key.
+func New(db *postgrest.Client, opts ...Option) *Loader {
+	l := &Loader{
+		db:               db,
+		truncateFunction: DefaultTruncateFunction,
+		unmarshal:        json.Unmarshal,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// LoadFile parses the fixtures of a file and loads them.
+func (l *Loader) LoadFile(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	tables, err := l.Parse(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return l.Load(ctx, tables)
+}
+
+// Parse decodes fixtures mapping table names to a list of rows, or to an
+// object with the rows and the tables they depend on.
+func (l *Loader) Parse(data []byte) ([]Table, error) {
+	var raw map[string]interface{}
+	if err := l.unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	tables := make([]Table, 0, len(raw))
+	for name, value := range raw {
+		table := Table{Name: name}
+		rows := value
+		if spec, ok := value.(map[string]interface{}); ok {
+			rows = spec["rows"]
+			dependsOn, err := tableNames(spec["depends_on"])
+			if err != nil {
+				return nil, fmt.Errorf("depends_on of %s: %w", name, err)
+			}
+			table.DependsOn = dependsOn
+		}
+
+		list, ok := rows.([]interface{})
+		if !ok && rows != nil {
+			return nil, fmt.Errorf("rows of %s must be a list", name)
+		}
+		for i, row := range list {
+			fields, ok := row.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("row %d of %s must be an object", i, name)
+			}
+			table.Rows = append(table.Rows, fields)
+		}
+		tables = append(tables, table)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables, nil
+}
+
+// tableNames converts a decoded list of table names.
+func tableNames(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be a list of table names")
+	}
+	names := make([]string, len(list))
+	for i, item := range list {
+		name, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be a list of table names")
+		}
+		names[i] = name
+	}
+	return names, nil
+}
+
+// Load truncates the tables, unless the truncate function is disabled, and
+// inserts their rows, each table after the ones it depends on.
+func (l *Loader) Load(ctx context.Context, tables []Table) error {
+	ordered, err := Order(tables)
+	if err != nil {
+		return err
+	}
+
+	if l.truncateFunction != "" && len(ordered) > 0 {
+		names := make([]string, len(ordered))
+		for i, table := range ordered {
+			names[i] = table.Name
+		}
+		err := l.db.Rpc(l.truncateFunction, map[string]interface{}{"tables": names}).ExecuteWithContext(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("truncate tables: %w", err)
+		}
+	}
+
+	for _, table := range ordered {
+		if len(table.Rows) == 0 {
+			continue
+		}
+		// rows may have different keys, missing columns are set to their default
+		err := l.db.From(table.Name).Insert(table.Rows).Columns(columns(table.Rows)...).MissingDefault().ExecuteWithContext(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", table.Name, err)
+		}
+	}
+	return nil
+}
+
+// columns returns the sorted union of the keys of the rows.
+func columns(rows []map[string]interface{}) []string {
+	keys := map[string]bool{}
+	for _, row := range rows {
+		for key := range row {
+			keys[key] = true
+		}
+	}
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Order sorts the tables so each one comes after the tables it depends on,
+// keeping the given order otherwise. Dependencies on tables missing from the
+// fixtures and of a table on itself, e.g. a parent_id column, are ignored, and
+// cyclic dependencies are an error.
+func Order(tables []Table) ([]Table, error) {
+	byName := make(map[string]Table, len(tables))
+	for _, table := range tables {
+		byName[table.Name] = table
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	ordered := make([]Table, 0, len(tables))
+	var visit func(table Table) error
+	visit = func(table Table) error {
+		switch state[table.Name] {
+		case visiting:
+			return fmt.Errorf("cyclic dependency on table %s", table.Name)
+		case visited:
+			return nil
+		}
+
+		state[table.Name] = visiting
+		for _, name := range table.DependsOn {
+			if name == table.Name {
+				continue
+			}
+			if dependency, ok := byName[name]; ok {
+				if err := visit(dependency); err != nil {
+					return err
+				}
+			}
+		}
+		state[table.Name] = visited
+		ordered = append(ordered, table)
+		return nil
+	}
+
+	for _, table := range tables {
+		if err := visit(table); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
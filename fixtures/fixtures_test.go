@@ -0,0 +1,93 @@
+package fixtures
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func tableOrder(tables []Table) []string {
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		names[i] = table.Name
+	}
+	return names
+}
+
+func TestOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		tables []Table
+		want   []string
+	}{
+		{
+			name: "no dependencies",
+			tables: []Table{
+				{Name: "tags"},
+				{Name: "users"},
+			},
+			want: []string{"tags", "users"},
+		},
+		{
+			name: "linear chain",
+			tables: []Table{
+				{Name: "comments", DependsOn: []string{"posts"}},
+				{Name: "posts", DependsOn: []string{"users"}},
+				{Name: "users"},
+			},
+			want: []string{"users", "posts", "comments"},
+		},
+		{
+			name: "diamond",
+			tables: []Table{
+				{Name: "post_tags", DependsOn: []string{"posts", "tags"}},
+				{Name: "posts", DependsOn: []string{"users"}},
+				{Name: "tags", DependsOn: []string{"users"}},
+				{Name: "users"},
+			},
+			want: []string{"users", "posts", "tags", "post_tags"},
+		},
+		{
+			name: "self reference",
+			tables: []Table{
+				{Name: "comments", DependsOn: []string{"comments", "users"}},
+				{Name: "users"},
+			},
+			want: []string{"users", "comments"},
+		},
+		{
+			name: "missing dependency",
+			tables: []Table{
+				{Name: "posts", DependsOn: []string{"users"}},
+			},
+			want: []string{"posts"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered, err := Order(tt.tables)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tableOrder(ordered); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Order() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrder_Cycle(t *testing.T) {
+	tables := []Table{
+		{Name: "users"},
+		{Name: "teams", DependsOn: []string{"users", "projects"}},
+		{Name: "projects", DependsOn: []string{"teams"}},
+	}
+
+	_, err := Order(tables)
+	if err == nil {
+		t.Fatal("Order() of cyclic dependencies succeeded")
+	}
+	if want := "cyclic dependency on table teams"; !strings.Contains(err.Error(), want) {
+		t.Errorf("Order() error = %q, want %q", err, want)
+	}
+}
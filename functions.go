@@ -0,0 +1,275 @@
+package supabase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+type Functions struct {
+	client *Client
+}
+
+// FunctionEvent is an event of a streaming function response. For responses
+// that are not server-sent events, each chunk of the body is sent as an event
+// with only Data set.
+type FunctionEvent struct {
+	ID    string
+	Event string
+	Data  []byte
+	// Err is set on the last event if reading the stream failed
+	Err error
+}
+
+// FunctionRegion is the region a function is invoked in.
+type FunctionRegion string
+
+const (
+	FunctionRegionAny          FunctionRegion = "any"
+	FunctionRegionApNortheast1 FunctionRegion = "ap-northeast-1"
+	FunctionRegionApSoutheast1 FunctionRegion = "ap-southeast-1"
+	FunctionRegionApSouth1     FunctionRegion = "ap-south-1"
+	FunctionRegionEuCentral1   FunctionRegion = "eu-central-1"
+	FunctionRegionEuWest1      FunctionRegion = "eu-west-1"
+	FunctionRegionEuWest2      FunctionRegion = "eu-west-2"
+	FunctionRegionSaEast1      FunctionRegion = "sa-east-1"
+	FunctionRegionUsEast1      FunctionRegion = "us-east-1"
+	FunctionRegionUsWest1      FunctionRegion = "us-west-1"
+)
+
+// FunctionInvokeOptions contains the optional parameters of a function invocation.
+type FunctionInvokeOptions struct {
+	// Body is encoded as JSON, no body is sent if nil
+	Body interface{}
+	// Method defaults to POST
+	Method string
+	// Region selects the region the function runs in with the x-region header
+	Region FunctionRegion
+	// Headers are added to the request
+	Headers map[string]string
+}
+
+// newRequest creates a request invoking the function with the given name.
+// The Authorization header carries the session access token when
+// WithAutoSessionToken is enabled and the API key otherwise.
+func (f *Functions) newRequest(ctx context.Context, name string, opts FunctionInvokeOptions) (*http.Request, error) {
+	body := opts.Body
+	var reqBody io.Reader
+	if body != nil {
+		data, err := f.client.marshalJSON(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewBuffer(data)
+	}
+
+	reqURL := fmt.Sprintf("%s/%s", f.client.functionsURL(), name)
+	method := opts.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	f.client.injectAuthHeaders(req)
+	if opts.Region != "" && opts.Region != FunctionRegionAny {
+		req.Header.Set("x-region", string(opts.Region))
+	}
+	for key, value := range opts.Headers {
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+// Invoke calls the function with the given name and decodes its JSON response into out.
+func (f *Functions) Invoke(ctx context.Context, name string, body interface{}, out interface{}) error {
+	return f.InvokeWithOptions(ctx, name, FunctionInvokeOptions{Body: body}, out)
+}
+
+// InvokeWithOptions calls the function with the given name and decodes its JSON response into out.
+func (f *Functions) InvokeWithOptions(ctx context.Context, name string, opts FunctionInvokeOptions, out interface{}) error {
+	req, err := f.newRequest(ctx, name, opts)
+	if err != nil {
+		return err
+	}
+
+	res, err := f.client.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	resBody, err := f.client.readBody(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= 300 {
+		return newHTTPError(res, resBody)
+	}
+
+	if out == nil || len(resBody) == 0 {
+		return nil
+	}
+	if err := f.client.unmarshalJSON(resBody, out); err != nil {
+		return &DecodeError{Err: err}
+	}
+	return nil
+}
+
+// InvokeStream calls the function with the given name and sends the events of
+// its text/event-stream response to the returned channel, which is closed when
+// the response ends, ctx is done or the client is closed.
+func (f *Functions) InvokeStream(ctx context.Context, name string, body interface{}) (<-chan FunctionEvent, error) {
+	return f.InvokeStreamWithOptions(ctx, name, FunctionInvokeOptions{Body: body})
+}
+
+// InvokeStreamWithOptions is like InvokeStream with the given options.
+func (f *Functions) InvokeStreamWithOptions(ctx context.Context, name string, opts FunctionInvokeOptions) (<-chan FunctionEvent, error) {
+	// the stream is cancelled when the client is closed, unblocking reads of the body
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(f.client.closed, cancel)
+	release := func() {
+		stop()
+		cancel()
+	}
+
+	req, err := f.newRequest(withoutServiceTimeout(ctx), name, opts)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	// the client timeout would cut off long running streams, ctx bounds the request instead
+	client := &http.Client{Transport: f.client.roundTripper}
+	res, err := client.Do(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= 300 {
+		defer release()
+		defer res.Body.Close()
+		resBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, newHTTPError(res, resBody)
+	}
+
+	events := make(chan FunctionEvent)
+	f.client.goBackground("function stream "+name, func() {
+		defer close(events)
+		defer res.Body.Close()
+		defer release()
+
+		send := func(event FunctionEvent) bool {
+			select {
+			case events <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		var err error
+		if isEventStream(res) {
+			err = readEventStream(res.Body, send)
+		} else {
+			err = readChunks(res.Body, send)
+		}
+		if err != nil && ctx.Err() == nil {
+			send(FunctionEvent{Err: err})
+		}
+	})
+
+	return events, nil
+}
+
+func isEventStream(res *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	return err == nil && mediaType == "text/event-stream"
+}
+
+// readEventStream parses server-sent events until the stream ends or send returns false
+func readEventStream(r io.Reader, send func(FunctionEvent) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var (
+		event   FunctionEvent
+		data    [][]byte
+		hasData bool
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// a blank line dispatches the event
+			if hasData {
+				event.Data = bytes.Join(data, []byte("\n"))
+				if !send(event) {
+					return nil
+				}
+			}
+			event, data, hasData = FunctionEvent{}, nil, false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// comment
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data = append(data, []byte(value))
+			hasData = true
+		case "id":
+			event.ID = value
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if hasData {
+		event.Data = bytes.Join(data, []byte("\n"))
+		send(event)
+	}
+	return nil
+}
+
+// readChunks sends each chunk read from r until it ends or send returns false
+func readChunks(r io.Reader, send func(FunctionEvent) bool) error {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			chunk := make([]byte, n)
+			copy(chunk, buf[:n])
+			if !send(FunctionEvent{Data: chunk}) {
+				return nil
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package supabase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type GraphQL struct {
+	client *Client
+}
+
+// GraphQLRequest is a GraphQL operation sent to the pg_graphql endpoint.
+type GraphQLRequest struct {
+	Query         string
+	Variables     map[string]interface{}
+	OperationName string
+	// Persisted sends only the SHA-256 hash of the query first, following the
+	// automatic persisted queries protocol, and the full query if the server
+	// does not know the hash.
+	Persisted bool
+}
+
+// GraphQLErrorLocation is a position in the query a GraphQL error refers to.
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLError is an error returned in the errors list of a GraphQL response.
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Locations  []GraphQLErrorLocation `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (err GraphQLError) Error() string {
+	return err.Message
+}
+
+// GraphQLErrors is returned when a GraphQL response contains errors. The data
+// of the response, if any, is still decoded.
+type GraphQLErrors []GraphQLError
+
+func (errs GraphQLErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Message
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+type graphqlPersistedQuery struct {
+	Version    int    `json:"version"`
+	SHA256Hash string `json:"sha256Hash"`
+}
+
+type graphqlRequestBody struct {
+	Query         string                 `json:"query,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors GraphQLErrors   `json:"errors"`
+}
+
+// Query executes a GraphQL query or mutation and decodes the data of the response into out.
+func (g *GraphQL) Query(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	return g.Execute(ctx, GraphQLRequest{Query: query, Variables: variables}, out)
+}
+
+// Execute executes a GraphQL operation and decodes the data of the response into out.
+func (g *GraphQL) Execute(ctx context.Context, request GraphQLRequest, out interface{}) error {
+	body := graphqlRequestBody{
+		Query:         request.Query,
+		Variables:     request.Variables,
+		OperationName: request.OperationName,
+	}
+	if !request.Persisted {
+		return g.send(ctx, body, out)
+	}
+
+	hash := sha256.Sum256([]byte(request.Query))
+	body.Extensions = map[string]interface{}{
+		"persistedQuery": graphqlPersistedQuery{Version: 1, SHA256Hash: hex.EncodeToString(hash[:])},
+	}
+	body.Query = ""
+	err := g.send(ctx, body, out)
+	if !isPersistedQueryNotFound(err) {
+		return err
+	}
+
+	// register the query with the server
+	body.Query = request.Query
+	return g.send(ctx, body, out)
+}
+
+func (g *GraphQL) send(ctx context.Context, body graphqlRequestBody, out interface{}) error {
+	data, err := g.client.marshalJSON(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.client.graphqlURL(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	g.client.injectAuthHeaders(req)
+
+	res, err := g.client.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	resBody, err := g.client.readBody(res.Body)
+	if err != nil {
+		return err
+	}
+
+	var gqlRes graphqlResponse
+	if err := g.client.unmarshalJSON(resBody, &gqlRes); err != nil {
+		return newHTTPError(res, resBody)
+	}
+	if len(gqlRes.Errors) == 0 && (res.StatusCode < http.StatusOK || res.StatusCode >= 300) {
+		return newHTTPError(res, resBody)
+	}
+
+	if out != nil && len(gqlRes.Data) > 0 && string(gqlRes.Data) != "null" {
+		if err := g.client.unmarshalJSON(gqlRes.Data, out); err != nil {
+			return &DecodeError{Err: err}
+		}
+	}
+	if len(gqlRes.Errors) > 0 {
+		return gqlRes.Errors
+	}
+	return nil
+}
+
+// isPersistedQueryNotFound reports whether the server asked for the full query of a persisted query.
+func isPersistedQueryNotFound(err error) bool {
+	errs, ok := err.(GraphQLErrors)
+	if !ok {
+		return false
+	}
+	for _, err := range errs {
+		if err.Message == "PersistedQueryNotFound" || err.Extensions["code"] == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
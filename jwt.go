@@ -0,0 +1,206 @@
+package supabase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrTokenNotYetValid = errors.New("token not valid yet")
+)
+
+// Claims are the claims of an access token issued by GoTrue. Embed Claims in
+// a custom struct to decode additional claims with ClaimsFromToken.
+type Claims struct {
+	Subject      string  `json:"sub"`
+	Audience     string  `json:"aud"`
+	Issuer       string  `json:"iss"`
+	ExpiresAt    int64   `json:"exp"`
+	IssuedAt     int64   `json:"iat"`
+	Email        string  `json:"email"`
+	Phone        string  `json:"phone"`
+	Role         string  `json:"role"`
+	SessionID    string  `json:"session_id"`
+	AAL          string  `json:"aal"`
+	IsAnonymous  bool    `json:"is_anonymous"`
+	AppMetadata  JSONMap `json:"app_metadata"`
+	UserMetadata JSONMap `json:"user_metadata"`
+}
+
+// Roles returns the Postgres role of the token and the application roles set
+// in the app metadata as "role" or "roles".
+func (c *Claims) Roles() []string {
+	var roles []string
+	if c.Role != "" {
+		roles = append(roles, c.Role)
+	}
+	if role, ok := c.AppMetadata["role"].(string); ok && role != "" {
+		roles = append(roles, role)
+	}
+	if appRoles, ok := c.AppMetadata["roles"].([]interface{}); ok {
+		for _, role := range appRoles {
+			if role, ok := role.(string); ok && role != "" {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles
+}
+
+// HasRole reports whether the token has any of the given roles.
+func (c *Claims) HasRole(roles ...string) bool {
+	for _, have := range c.Roles() {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ClaimsFromToken verifies an access token signed with the JWT secret of the
+// project (HS256) and decodes its claims into T, e.g. a struct embedding Claims
+// with typed app metadata:
+//
+//	type TenantClaims struct {
+//		supabase.Claims
+//		AppMetadata struct {
+//			TenantID string `json:"tenant_id"`
+//		} `json:"app_metadata"`
+//	}
+//
+//	claims, err := supabase.ClaimsFromToken[TenantClaims](token, jwtSecret)
+func ClaimsFromToken[T any](token string, jwtSecret string) (*T, error) {
+	payload, err := verifyToken(token, jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := new(T)
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// verifyToken checks the signature and the validity period of a token and
+// returns its payload.
+func verifyToken(token string, jwtSecret string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var alg struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &alg); err != nil || alg.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	mac := hmac.New(sha256.New, []byte(jwtSecret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var validity struct {
+		ExpiresAt int64 `json:"exp"`
+		NotBefore int64 `json:"nbf"`
+	}
+	if err := json.Unmarshal(payload, &validity); err != nil {
+		return nil, ErrInvalidToken
+	}
+	now := time.Now().Unix()
+	if validity.ExpiresAt != 0 && now >= validity.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	if validity.NotBefore != 0 && now < validity.NotBefore {
+		return nil, ErrTokenNotYetValid
+	}
+	return payload, nil
+}
+
+type claimsKey struct{}
+
+// ClaimsFromContext returns the claims stored by AuthMiddleware.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+type middlewareConfig struct {
+	roles []string
+}
+
+// MiddlewareOption configures AuthMiddleware.
+type MiddlewareOption func(c *middlewareConfig)
+
+// WithRequiredRoles rejects requests whose token has none of the given roles
+// with 403 Forbidden, see Claims.Roles.
+func WithRequiredRoles(roles ...string) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.roles = append(c.roles, roles...)
+	}
+}
+
+// AuthMiddleware verifies the bearer token of requests with the JWT secret of
+// the project and stores its claims in the request context, see
+// ClaimsFromContext. Requests without a valid token are rejected with 401
+// Unauthorized.
+func AuthMiddleware(jwtSecret string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	config := middlewareConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				writeErrorResponse(w, http.StatusUnauthorized, "missing bearer token")
+				return
+			}
+
+			claims, err := ClaimsFromToken[Claims](token, jwtSecret)
+			if err != nil {
+				writeErrorResponse(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+			if len(config.roles) > 0 && !claims.HasRole(config.roles...) {
+				writeErrorResponse(w, http.StatusForbidden, "insufficient role")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims)))
+		})
+	}
+}
+
+func writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(ErrorResponse{Code: statusCode, Message: message})
+}
@@ -0,0 +1,174 @@
+package supabase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testJWTSecret = "super-secret-jwt-token-with-at-least-32-characters"
+
+func encodeSegment(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// signToken signs a token with the header and the payload as is.
+func signToken(header, payload, secret string) string {
+	unsigned := encodeSegment(header) + "." + encodeSegment(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signClaims(payload string) string {
+	return signToken(`{"alg":"HS256","typ":"JWT"}`, payload, testJWTSecret)
+}
+
+func TestVerifyToken(t *testing.T) {
+	now := time.Now().Unix()
+	valid := signClaims(`{"sub":"user-1","role":"authenticated","exp":` + itoa(now+3600) + `}`)
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "valid", token: valid},
+		{name: "no expiry", token: signClaims(`{"sub":"user-1"}`)},
+		{name: "nbf in the past", token: signClaims(`{"sub":"user-1","nbf":` + itoa(now-60) + `}`)},
+		{
+			name:    "tampered payload",
+			token:   parts[0] + "." + encodeSegment(`{"sub":"user-2","role":"service_role"}`) + "." + parts[2],
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "tampered signature",
+			token:   parts[0] + "." + parts[1] + "." + base64.RawURLEncoding.EncodeToString([]byte("not the signature")),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "wrong secret",
+			token:   signToken(`{"alg":"HS256"}`, `{"sub":"user-1"}`, "another-secret"),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "expired",
+			token:   signClaims(`{"sub":"user-1","exp":` + itoa(now-1) + `}`),
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name:    "nbf in the future",
+			token:   signClaims(`{"sub":"user-1","nbf":` + itoa(now+3600) + `}`),
+			wantErr: ErrTokenNotYetValid,
+		},
+		{
+			name:    "alg none",
+			token:   encodeSegment(`{"alg":"none"}`) + "." + parts[1] + ".",
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "alg RS256",
+			token:   signToken(`{"alg":"RS256"}`, `{"sub":"user-1"}`, testJWTSecret),
+			wantErr: ErrInvalidToken,
+		},
+		{name: "two segments", token: parts[0] + "." + parts[1], wantErr: ErrInvalidToken},
+		{name: "four segments", token: valid + "." + parts[2], wantErr: ErrInvalidToken},
+		{name: "empty", token: "", wantErr: ErrInvalidToken},
+		{name: "header not base64", token: "!!!." + parts[1] + "." + parts[2], wantErr: ErrInvalidToken},
+		{name: "header not JSON", token: signToken(`HS256`, `{"sub":"user-1"}`, testJWTSecret), wantErr: ErrInvalidToken},
+		{name: "payload not JSON", token: signClaims(`user-1`), wantErr: ErrInvalidToken},
+		{name: "signature not base64", token: parts[0] + "." + parts[1] + ".!!!", wantErr: ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifyToken(tt.token, testJWTSecret)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("verifyToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClaimsFromToken(t *testing.T) {
+	token := signClaims(`{"sub":"user-1","role":"authenticated","app_metadata":{"roles":["admin","member"]}}`)
+
+	claims, err := ClaimsFromToken[Claims](token, testJWTSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user-1" || claims.Role != "authenticated" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if !claims.HasRole("admin") || claims.HasRole("moderator") {
+		t.Errorf("Roles() = %v", claims.Roles())
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	handler := AuthMiddleware(testJWTSecret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ClaimsFromContext(r.Context())
+		if !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(claims.Subject))
+	}))
+	adminOnly := AuthMiddleware(testJWTSecret, WithRequiredRoles(RoleAdmin))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	valid := signClaims(`{"sub":"user-1","role":"authenticated"}`)
+	tests := []struct {
+		name          string
+		handler       http.Handler
+		authorization string
+		wantStatus    int
+		wantBody      string
+	}{
+		{name: "valid token", handler: handler, authorization: "Bearer " + valid, wantStatus: http.StatusOK, wantBody: "user-1"},
+		{name: "missing token", handler: handler, wantStatus: http.StatusUnauthorized, wantBody: "missing bearer token"},
+		{name: "not a bearer token", handler: handler, authorization: "Basic dXNlcjpwYXNz", wantStatus: http.StatusUnauthorized},
+		{name: "invalid token", handler: handler, authorization: "Bearer " + valid + "x", wantStatus: http.StatusUnauthorized, wantBody: ErrInvalidToken.Error()},
+		{
+			name:          "expired token",
+			handler:       handler,
+			authorization: "Bearer " + signClaims(`{"sub":"user-1","exp":1}`),
+			wantStatus:    http.StatusUnauthorized,
+			wantBody:      ErrTokenExpired.Error(),
+		},
+		{name: "missing role", handler: adminOnly, authorization: "Bearer " + valid, wantStatus: http.StatusForbidden},
+		{
+			name:          "required role",
+			handler:       adminOnly,
+			authorization: "Bearer " + signClaims(`{"sub":"user-1","app_metadata":{"role":"admin"}}`),
+			wantStatus:    http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want %q", rec.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,103 @@
+package supabase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithKeepAlive pre-establishes the connections to the auth, rest and storage
+// services when the client is created and keeps them warm by sending a
+// lightweight request to each service every interval, so the first calls of
+// a latency-sensitive server do not pay for DNS, TCP and TLS handshakes. The
+// interval should be shorter than the idle connection timeout of the
+// transport and of any proxy in between, 90 seconds by default. The pings
+// stop when the client is closed.
+func WithKeepAlive(interval time.Duration) ClientOption {
+	return func(c *Client) {
+		c.keepAliveInterval = interval
+	}
+}
+
+// startKeepAlive warms up the connections in the background until the client is closed.
+func (c *Client) startKeepAlive() {
+	if c.keepAliveInterval <= 0 {
+		return
+	}
+
+	c.goBackground("keep-alive", func() {
+		ticker := time.NewTicker(c.keepAliveInterval)
+		defer ticker.Stop()
+
+		for {
+			ctx, cancel := context.WithTimeout(c.closed, c.keepAliveInterval)
+			c.Prewarm(ctx)
+			cancel()
+
+			select {
+			case <-ticker.C:
+			case <-c.closed.Done():
+				return
+			}
+		}
+	})
+}
+
+// Prewarm sends a lightweight request to the health endpoints of the auth,
+// rest and storage services concurrently, opening a connection to each of
+// them. Only network errors are returned, any HTTP response warms the
+// connection. The requests are canceled when the client is closed.
+func (c *Client) Prewarm(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(c.closed, cancel)
+	defer stop()
+
+	targets := []struct {
+		method string
+		url    string
+	}{
+		{http.MethodGet, c.authURL() + "/health"},
+		{http.MethodHead, c.restURL() + "/"},
+		{http.MethodGet, c.storageURL() + "/status"},
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, target := range targets {
+		wg.Add(1)
+		go func(method string, url string) {
+			defer wg.Done()
+			if err := c.ping(ctx, method, url); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(target.method, target.url)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (c *Client) ping(ctx context.Context, method string, url string) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return err
+	}
+	c.injectAPIKey(req)
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	// the body is drained so the connection is returned to the pool
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+	return res.Body.Close()
+}
@@ -0,0 +1,66 @@
+package supabase
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// hangingServer holds the requests until they are canceled, sending the path
+// of each canceled request to the returned channel.
+func hangingServer(t *testing.T) (*httptest.Server, <-chan string) {
+	canceled := make(chan string, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			canceled <- r.URL.Path
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, canceled
+}
+
+func waitCanceled(t *testing.T, canceled <-chan string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-canceled:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%d of %d requests were canceled when the client was closed", i, n)
+		}
+	}
+}
+
+func TestPrewarm_Close(t *testing.T) {
+	server, canceled := hangingServer(t)
+	client := NewClient(server.URL, "key")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Prewarm(context.Background())
+	}()
+	time.Sleep(50 * time.Millisecond)
+	client.Close()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected the canceled requests to fail")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Prewarm did not return after the client was closed")
+	}
+	waitCanceled(t, canceled, 3)
+}
+
+func TestWithKeepAlive_Close(t *testing.T) {
+	server, canceled := hangingServer(t)
+	client := NewClient(server.URL, "key", WithKeepAlive(time.Hour))
+
+	time.Sleep(50 * time.Millisecond)
+	client.Close()
+	waitCanceled(t, canceled, 3)
+}
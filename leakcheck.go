@@ -0,0 +1,183 @@
+package supabase
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// leakCheckTimeout is how long the goroutines of a closed client are waited for.
+const leakCheckTimeout = time.Second
+
+// LeakReporter reports the leaks found by WithLeakCheck. It is implemented by
+// *testing.T and *testing.B.
+type LeakReporter interface {
+	Errorf(format string, args ...interface{})
+	Cleanup(f func())
+}
+
+// WithLeakCheck enables a test mode reporting resource leaks of the client to
+// the test, e.g.
+//
+//	client := supabase.NewClient(url, key, supabase.WithLeakCheck(t))
+//
+// Requests sent with a context that is never cancelled, such as
+// context.Background() without a timeout, are reported when they are sent,
+// even though HTTPClient bounds them with its timeout.
+// When the test ends, the client is closed and the response bodies that were
+// not closed, and the background goroutines such as the pings of
+// WithKeepAlive or the readers of function streams that did not exit within a
+// second, are reported. It adds bookkeeping to every request and should not
+// be used outside tests.
+func WithLeakCheck(reporter LeakReporter) ClientOption {
+	return func(c *Client) {
+		c.leakCheck = &leakChecker{
+			reporter:   reporter,
+			bodies:     map[*trackedBody]string{},
+			goroutines: map[string]int{},
+		}
+	}
+}
+
+// leakChecker tracks the open response bodies and running goroutines of a client.
+type leakChecker struct {
+	reporter   LeakReporter
+	mu         sync.Mutex
+	bodies     map[*trackedBody]string
+	goroutines map[string]int
+}
+
+// watch closes the client at the end of the test and reports what leaked.
+func (l *leakChecker) watch(c *Client) {
+	l.reporter.Cleanup(func() {
+		c.Close()
+		l.verify()
+	})
+}
+
+func (l *leakChecker) verify() {
+	deadline := time.Now().Add(leakCheckTimeout)
+	for l.running() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, name := range sortedKeys(l.goroutines) {
+		l.reporter.Errorf("supabase: %d %s goroutine(s) still running after Close", l.goroutines[name], name)
+	}
+	requests := make([]string, 0, len(l.bodies))
+	for _, request := range l.bodies {
+		requests = append(requests, request)
+	}
+	sort.Strings(requests)
+	for _, request := range requests {
+		l.reporter.Errorf("supabase: response body of %s was not closed", request)
+	}
+}
+
+func (l *leakChecker) running() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.goroutines)
+}
+
+func (l *leakChecker) started(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.goroutines[name]++
+}
+
+func (l *leakChecker) exited(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.goroutines[name]--; l.goroutines[name] <= 0 {
+		delete(l.goroutines, name)
+	}
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// goBackground runs f in a goroutine that must exit when the client is
+// closed, tracking it if the leak check is enabled.
+func (c *Client) goBackground(name string, f func()) {
+	if c.leakCheck == nil {
+		go f()
+		return
+	}
+
+	c.leakCheck.started(name)
+	go func() {
+		defer c.leakCheck.exited(name)
+		f()
+	}()
+}
+
+// leakCheckTransport checks the contexts of the requests and tracks their
+// response bodies until they are closed. It applies the timeout of
+// HTTPClient itself, after checking the context, as http.Client wraps the
+// context of the requests in the deadline of its timeout.
+type leakCheckTransport struct {
+	checker *leakChecker
+	parent  http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *leakCheckTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the query is left out as it may contain tokens, e.g. of signed URLs
+	request := req.Method + " " + req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+	if req.Context().Done() == nil {
+		t.checker.reporter.Errorf("supabase: %s was sent with a context that is never cancelled", request)
+	}
+
+	res, err := t.roundTrip(req)
+	if err != nil || res.Body == nil {
+		return res, err
+	}
+
+	body := &trackedBody{ReadCloser: res.Body, checker: t.checker}
+	t.checker.mu.Lock()
+	t.checker.bodies[body] = request
+	t.checker.mu.Unlock()
+	res.Body = body
+	return res, nil
+}
+
+// roundTrip sends the request bounded by the timeout.
+func (t *leakCheckTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.parent.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	res, err := t.parent.RoundTrip(req.WithContext(ctx))
+	if err != nil || res.Body == nil {
+		cancel()
+		return res, err
+	}
+	res.Body = &cancelOnCloseBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// trackedBody is a response body that is untracked when closed.
+type trackedBody struct {
+	io.ReadCloser
+	checker *leakChecker
+}
+
+func (b *trackedBody) Close() error {
+	b.checker.mu.Lock()
+	delete(b.checker.bodies, b)
+	b.checker.mu.Unlock()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,161 @@
+package supabase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeReporter records the leaks reported by WithLeakCheck and runs the
+// cleanups when the test asks for them.
+type fakeReporter struct {
+	mu       sync.Mutex
+	errors   []string
+	cleanups []func()
+}
+
+func (r *fakeReporter) Errorf(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *fakeReporter) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+func (r *fakeReporter) runCleanups() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+func (r *fakeReporter) reported(substr string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, err := range r.errors {
+		if strings.Contains(err, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+func newLeakCheckServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWithLeakCheck_Contexts(t *testing.T) {
+	server := newLeakCheckServer(t)
+	reporter := &fakeReporter{}
+	client := NewClient(server.URL, "key", WithLeakCheck(reporter))
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/functions/v1/hello", nil)
+	res, err := client.HTTPClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if n := reporter.reported("GET " + server.URL + "/functions/v1/hello was sent with a context that is never cancelled"); n != 1 {
+		t.Errorf("expected the request with context.Background() to be reported once, got %v", reporter.errors)
+	}
+
+	if err := client.DB.From("items").Select("*").Execute(nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := reporter.reported("/rest/v1/items was sent with a context that is never cancelled"); n != 1 {
+		t.Errorf("expected the DB request sent with Execute to be reported once, got %v", reporter.errors)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := client.DB.From("bounded").Select("*").ExecuteWithContext(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := reporter.reported("/rest/v1/bounded"); n != 0 {
+		t.Errorf("expected the request with a timeout not to be reported, got %v", reporter.errors)
+	}
+
+	reporter.runCleanups()
+	if len(reporter.errors) != 2 {
+		t.Errorf("expected no other leak, got %v", reporter.errors)
+	}
+}
+
+func TestWithLeakCheck_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	reporter := &fakeReporter{}
+	shortTimeout := func(c *Client) { c.HTTPClient.Timeout = 50 * time.Millisecond }
+	client := NewClient(server.URL, "key", shortTimeout, WithLeakCheck(reporter))
+	defer reporter.runCleanups()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/slow", nil)
+	if _, err := client.HTTPClient.Do(req); err == nil {
+		t.Errorf("expected the timeout of HTTPClient to still apply")
+	}
+}
+
+func TestWithLeakCheck_Bodies(t *testing.T) {
+	server := newLeakCheckServer(t)
+	reporter := &fakeReporter{}
+	client := NewClient(server.URL, "key", WithLeakCheck(reporter))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, path := range []string{"/closed", "/leaked?token=secret"} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		res, err := client.HTTPClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path == "/closed" {
+			res.Body.Close()
+		}
+	}
+
+	reporter.runCleanups()
+	if n := reporter.reported("response body of GET " + server.URL + "/leaked was not closed"); n != 1 {
+		t.Errorf("expected the unclosed body to be reported once, got %v", reporter.errors)
+	}
+	if n := reporter.reported("secret"); n != 0 {
+		t.Errorf("expected the query to be left out of the report, got %v", reporter.errors)
+	}
+	if len(reporter.errors) != 1 {
+		t.Errorf("expected a single leak, got %v", reporter.errors)
+	}
+}
+
+func TestWithLeakCheck_Goroutines(t *testing.T) {
+	reporter := &fakeReporter{}
+	client := NewClient("http://localhost", "key", WithLeakCheck(reporter))
+
+	release := make(chan struct{})
+	defer close(release)
+	client.goBackground("stuck", func() { <-release })
+	client.goBackground("stopped", func() { <-client.closed.Done() })
+
+	reporter.runCleanups()
+	if n := reporter.reported("1 stuck goroutine(s) still running after Close"); n != 1 {
+		t.Errorf("expected the stuck goroutine to be reported once, got %v", reporter.errors)
+	}
+	if n := reporter.reported("stopped"); n != 0 {
+		t.Errorf("expected the goroutine exiting on Close not to be reported, got %v", reporter.errors)
+	}
+}
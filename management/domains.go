@@ -0,0 +1,61 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// CustomDomain is the custom hostname configuration of a project.
+type CustomDomain struct {
+	Status         string                 `json:"status"`
+	CustomHostname string                 `json:"custom_hostname"`
+	Data           map[string]interface{} `json:"data"`
+}
+
+func customHostnamePath(ref string) string {
+	return "/projects/" + url.PathEscape(ref) + "/custom-hostname"
+}
+
+// GetCustomDomain returns the custom hostname configuration of the project.
+func (c *Client) GetCustomDomain(ctx context.Context, ref string) (*CustomDomain, error) {
+	var domain CustomDomain
+	if err := c.do(ctx, http.MethodGet, customHostnamePath(ref), nil, nil, &domain); err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// CreateCustomDomain starts the setup of a custom hostname. The DNS records
+// returned in Data must be created before calling VerifyCustomDomain.
+func (c *Client) CreateCustomDomain(ctx context.Context, ref string, hostname string) (*CustomDomain, error) {
+	body := map[string]string{"custom_hostname": hostname}
+	var domain CustomDomain
+	if err := c.do(ctx, http.MethodPost, customHostnamePath(ref)+"/initialize", nil, body, &domain); err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// VerifyCustomDomain checks the DNS records of the custom hostname.
+func (c *Client) VerifyCustomDomain(ctx context.Context, ref string) (*CustomDomain, error) {
+	var domain CustomDomain
+	if err := c.do(ctx, http.MethodPost, customHostnamePath(ref)+"/reverify", nil, nil, &domain); err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// ActivateCustomDomain makes the verified custom hostname serve the project.
+func (c *Client) ActivateCustomDomain(ctx context.Context, ref string) (*CustomDomain, error) {
+	var domain CustomDomain
+	if err := c.do(ctx, http.MethodPost, customHostnamePath(ref)+"/activate", nil, nil, &domain); err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+// DeleteCustomDomain removes the custom hostname of the project.
+func (c *Client) DeleteCustomDomain(ctx context.Context, ref string) error {
+	return c.do(ctx, http.MethodDelete, customHostnamePath(ref), nil, nil, nil)
+}
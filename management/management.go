@@ -0,0 +1,120 @@
+// Package management is a client for the Supabase Management API, which
+// administers the projects of an account with a personal access token.
+package management
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the URL of the Supabase Management API.
+const DefaultBaseURL = "https://api.supabase.com/v1"
+
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// token is a personal access token created in the dashboard
+	token string
+}
+
+// ClientOption configures a Client created with NewClient.
+type ClientOption func(c *Client)
+
+// WithBaseURL sets the URL of the Management API.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.BaseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send requests.
+func WithHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.HTTPClient = client
+	}
+}
+
+// NewClient creates a Management API client authenticated with a personal access token.
+func NewClient(token string, opts ...ClientOption) *Client {
+	client := &Client{
+		BaseURL: DefaultBaseURL,
+		HTTPClient: &http.Client{
+			Timeout: time.Minute,
+		},
+		token: token,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// Error is returned when the Management API responds with an error status.
+type Error struct {
+	StatusCode int
+	Message    string `json:"message"`
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("management api: %s (status code: %d)", err.Message, err.StatusCode)
+}
+
+// do sends a request to path, encoding body as JSON if not nil, and decodes the response into out.
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body interface{}, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	reqURL := c.BaseURL + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: res.StatusCode}
+		if err := json.Unmarshal(resBody, apiErr); err != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(resBody))
+		}
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(res.StatusCode)
+		}
+		return apiErr
+	}
+
+	if out == nil || len(resBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resBody, out)
+}
@@ -0,0 +1,220 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// request is a request received by the fake Management API.
+type request struct {
+	method        string
+	uri           string
+	authorization string
+	contentType   string
+	body          string
+}
+
+// newTestClient returns a client of a fake Management API which records the
+// requests and responds with status and body.
+func newTestClient(t *testing.T, status int, body string) (*Client, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{
+			method:        r.Method,
+			uri:           r.URL.RequestURI(),
+			authorization: r.Header.Get("Authorization"),
+			contentType:   r.Header.Get("Content-Type"),
+			body:          string(data),
+		})
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return NewClient("sbp_token", WithBaseURL(server.URL+"/v1/")), &requests
+}
+
+func TestClient_Requests(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		call   func(c *Client) error
+		method string
+		uri    string
+		body   string
+	}{
+		{
+			name:   "list projects",
+			call:   func(c *Client) error { _, err := c.ListProjects(ctx); return err },
+			method: http.MethodGet,
+			uri:    "/v1/projects",
+		},
+		{
+			name:   "get project",
+			call:   func(c *Client) error { _, err := c.GetProject(ctx, "abc/def"); return err },
+			method: http.MethodGet,
+			uri:    "/v1/projects/abc%2Fdef",
+		},
+		{
+			name: "create project",
+			call: func(c *Client) error {
+				_, err := c.CreateProject(ctx, CreateProjectParams{Name: "app", OrganizationID: "org", DBPassword: "pass", Region: "us-east-1"})
+				return err
+			},
+			method: http.MethodPost,
+			uri:    "/v1/projects",
+			body:   `{"name":"app","organization_id":"org","db_pass":"pass","region":"us-east-1"}`,
+		},
+		{
+			name:   "delete project",
+			call:   func(c *Client) error { return c.DeleteProject(ctx, "ref") },
+			method: http.MethodDelete,
+			uri:    "/v1/projects/ref",
+		},
+		{
+			name:   "run query",
+			call:   func(c *Client) error { return c.RunQuery(ctx, "ref", "select 1", nil) },
+			method: http.MethodPost,
+			uri:    "/v1/projects/ref/database/query",
+			body:   `{"query":"select 1"}`,
+		},
+		{
+			name:   "list secrets",
+			call:   func(c *Client) error { _, err := c.ListSecrets(ctx, "ref"); return err },
+			method: http.MethodGet,
+			uri:    "/v1/projects/ref/secrets",
+		},
+		{
+			name:   "create secrets",
+			call:   func(c *Client) error { return c.CreateSecrets(ctx, "ref", []Secret{{Name: "KEY", Value: "value"}}) },
+			method: http.MethodPost,
+			uri:    "/v1/projects/ref/secrets",
+			body:   `[{"name":"KEY","value":"value"}]`,
+		},
+		{
+			name:   "delete secrets",
+			call:   func(c *Client) error { return c.DeleteSecrets(ctx, "ref", "KEY", "OTHER") },
+			method: http.MethodDelete,
+			uri:    "/v1/projects/ref/secrets",
+			body:   `["KEY","OTHER"]`,
+		},
+		{
+			name:   "list snippets",
+			call:   func(c *Client) error { _, err := c.ListSnippets(ctx, ""); return err },
+			method: http.MethodGet,
+			uri:    "/v1/snippets",
+		},
+		{
+			name:   "list snippets of a project",
+			call:   func(c *Client) error { _, err := c.ListSnippets(ctx, "ref"); return err },
+			method: http.MethodGet,
+			uri:    "/v1/snippets?project_ref=ref",
+		},
+		{
+			name:   "get snippet",
+			call:   func(c *Client) error { _, err := c.GetSnippet(ctx, "id"); return err },
+			method: http.MethodGet,
+			uri:    "/v1/snippets/id",
+		},
+		{
+			name:   "get custom domain",
+			call:   func(c *Client) error { _, err := c.GetCustomDomain(ctx, "ref"); return err },
+			method: http.MethodGet,
+			uri:    "/v1/projects/ref/custom-hostname",
+		},
+		{
+			name:   "create custom domain",
+			call:   func(c *Client) error { _, err := c.CreateCustomDomain(ctx, "ref", "api.example.com"); return err },
+			method: http.MethodPost,
+			uri:    "/v1/projects/ref/custom-hostname/initialize",
+			body:   `{"custom_hostname":"api.example.com"}`,
+		},
+		{
+			name:   "verify custom domain",
+			call:   func(c *Client) error { _, err := c.VerifyCustomDomain(ctx, "ref"); return err },
+			method: http.MethodPost,
+			uri:    "/v1/projects/ref/custom-hostname/reverify",
+		},
+		{
+			name:   "activate custom domain",
+			call:   func(c *Client) error { _, err := c.ActivateCustomDomain(ctx, "ref"); return err },
+			method: http.MethodPost,
+			uri:    "/v1/projects/ref/custom-hostname/activate",
+		},
+		{
+			name:   "delete custom domain",
+			call:   func(c *Client) error { return c.DeleteCustomDomain(ctx, "ref") },
+			method: http.MethodDelete,
+			uri:    "/v1/projects/ref/custom-hostname",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := newTestClient(t, http.StatusOK, "")
+			if err := tt.call(client); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(*requests) != 1 {
+				t.Fatalf("expected a single request, got %d", len(*requests))
+			}
+
+			req := (*requests)[0]
+			if req.method != tt.method || req.uri != tt.uri {
+				t.Errorf("expected %s %s, got %s %s", tt.method, tt.uri, req.method, req.uri)
+			}
+			if req.authorization != "Bearer sbp_token" {
+				t.Errorf("expected the access token as bearer token, got %q", req.authorization)
+			}
+			if req.body != tt.body {
+				t.Errorf("expected body %s, got %s", tt.body, req.body)
+			}
+			if wantJSON := tt.body != ""; wantJSON != (req.contentType == "application/json") {
+				t.Errorf("expected a JSON content type only with a body, got %q", req.contentType)
+			}
+		})
+	}
+}
+
+func TestClient_Response(t *testing.T) {
+	client, _ := newTestClient(t, http.StatusOK, `[{"id":"ref","name":"app","status":"ACTIVE_HEALTHY","database":{"host":"db.ref.supabase.co"}}]`)
+
+	projects, err := client.ListProjects(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(projects) != 1 || projects[0].ID != "ref" || projects[0].Database == nil || projects[0].Database.Host != "db.ref.supabase.co" {
+		t.Errorf("expected the decoded project, got %+v", projects)
+	}
+}
+
+func TestClient_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		message string
+	}{
+		{name: "json", status: http.StatusNotFound, body: `{"message":"Project not found"}`, message: "Project not found"},
+		{name: "json without message", status: http.StatusBadRequest, body: `{"error":"bad"}`, message: `{"error":"bad"}`},
+		{name: "text", status: http.StatusBadGateway, body: "upstream unavailable\n", message: "upstream unavailable"},
+		{name: "empty", status: http.StatusUnauthorized, message: "Unauthorized"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestClient(t, tt.status, tt.body)
+
+			_, err := client.GetProject(context.Background(), "ref")
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an *Error, got %v", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Message != tt.message {
+				t.Errorf("expected status %d and message %q, got %d and %q", tt.status, tt.message, apiErr.StatusCode, apiErr.Message)
+			}
+		})
+	}
+}
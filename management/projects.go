@@ -0,0 +1,68 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+type Project struct {
+	ID             string `json:"id"`
+	OrganizationID string `json:"organization_id"`
+	Name           string `json:"name"`
+	Region         string `json:"region"`
+	Status         string `json:"status"`
+	CreatedAt      string `json:"created_at"`
+	Database       *struct {
+		Host    string `json:"host"`
+		Version string `json:"version"`
+	} `json:"database,omitempty"`
+}
+
+// CreateProjectParams are the parameters of a new project.
+type CreateProjectParams struct {
+	Name           string `json:"name"`
+	OrganizationID string `json:"organization_id"`
+	DBPassword     string `json:"db_pass"`
+	Region         string `json:"region"`
+	Plan           string `json:"plan,omitempty"`
+}
+
+// ListProjects returns the projects the access token has access to.
+func (c *Client) ListProjects(ctx context.Context) ([]Project, error) {
+	var projects []Project
+	if err := c.do(ctx, http.MethodGet, "/projects", nil, nil, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// GetProject returns the project with the given reference ID.
+func (c *Client) GetProject(ctx context.Context, ref string) (*Project, error) {
+	var project Project
+	if err := c.do(ctx, http.MethodGet, "/projects/"+url.PathEscape(ref), nil, nil, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// CreateProject creates a project. The project is provisioned asynchronously,
+// its Status tells when it is ready.
+func (c *Client) CreateProject(ctx context.Context, params CreateProjectParams) (*Project, error) {
+	var project Project
+	if err := c.do(ctx, http.MethodPost, "/projects", nil, params, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// DeleteProject deletes the project with the given reference ID.
+func (c *Client) DeleteProject(ctx context.Context, ref string) error {
+	return c.do(ctx, http.MethodDelete, "/projects/"+url.PathEscape(ref), nil, nil, nil)
+}
+
+// RunQuery runs a SQL query on the database of the project and decodes the returned rows into out.
+func (c *Client) RunQuery(ctx context.Context, ref string, query string, out interface{}) error {
+	body := map[string]string{"query": query}
+	return c.do(ctx, http.MethodPost, "/projects/"+url.PathEscape(ref)+"/database/query", nil, body, out)
+}
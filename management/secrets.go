@@ -0,0 +1,32 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Secret is a secret available to the edge functions of a project.
+type Secret struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ListSecrets returns the secrets of the project. The values are returned as digests.
+func (c *Client) ListSecrets(ctx context.Context, ref string) ([]Secret, error) {
+	var secrets []Secret
+	if err := c.do(ctx, http.MethodGet, "/projects/"+url.PathEscape(ref)+"/secrets", nil, nil, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// CreateSecrets creates the given secrets, replacing existing secrets with the same name.
+func (c *Client) CreateSecrets(ctx context.Context, ref string, secrets []Secret) error {
+	return c.do(ctx, http.MethodPost, "/projects/"+url.PathEscape(ref)+"/secrets", nil, secrets, nil)
+}
+
+// DeleteSecrets deletes the secrets with the given names.
+func (c *Client) DeleteSecrets(ctx context.Context, ref string, names ...string) error {
+	return c.do(ctx, http.MethodDelete, "/projects/"+url.PathEscape(ref)+"/secrets", nil, names, nil)
+}
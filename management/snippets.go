@@ -0,0 +1,57 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Snippet is a SQL snippet saved in the SQL editor of the dashboard.
+type Snippet struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	Visibility  string `json:"visibility"`
+	InsertedAt  string `json:"inserted_at"`
+	UpdatedAt   string `json:"updated_at"`
+	Project     struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"project"`
+	Content *SnippetContent `json:"content,omitempty"`
+}
+
+type SnippetContent struct {
+	SchemaVersion string `json:"schema_version"`
+	SQL           string `json:"sql"`
+	Favorite      bool   `json:"favorite"`
+}
+
+type snippetList struct {
+	Data []Snippet `json:"data"`
+}
+
+// ListSnippets returns the SQL snippets of the user, only those of the given
+// project if ref is not empty. The content of the snippets is not included.
+func (c *Client) ListSnippets(ctx context.Context, ref string) ([]Snippet, error) {
+	query := url.Values{}
+	if ref != "" {
+		query.Set("project_ref", ref)
+	}
+
+	var list snippetList
+	if err := c.do(ctx, http.MethodGet, "/snippets", query, nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Data, nil
+}
+
+// GetSnippet returns the SQL snippet with the given ID, including its content.
+func (c *Client) GetSnippet(ctx context.Context, id string) (*Snippet, error) {
+	var snippet Snippet
+	if err := c.do(ctx, http.MethodGet, "/snippets/"+url.PathEscape(id), nil, nil, &snippet); err != nil {
+		return nil, err
+	}
+	return &snippet, nil
+}
@@ -0,0 +1,186 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	postgrest "github.com/nedpals/supabase-go/postgrest/pkg"
+)
+
+// ErrRowNotFound is returned by the methods of Model when no row has the given primary key.
+var ErrRowNotFound = errors.New("row not found")
+
+// Tabler is implemented by models naming their table with a method instead of a tag.
+type Tabler interface {
+	TableName() string
+}
+
+// Model maps the rows of a table to values of the struct type T. The table
+// and the primary key are declared with supabase tags, columns are mapped
+// with json tags like the rows of DB queries:
+//
+//	type Todo struct {
+//		_     struct{} `supabase:"table=todos"`
+//		ID    int64    `json:"id,omitempty" supabase:"pk"`
+//		Title string   `json:"title"`
+//	}
+//
+//	todos, err := supabase.NewModel[Todo](client)
+//
+// The table can also be named by a TableName method. The builder of the table
+// stays available with Query for the queries the model does not cover.
+type Model[T any] struct {
+	client *Client
+	db     *postgrest.Client
+	table  string
+	pk     string
+	pkPath []int
+}
+
+// NewModel returns the model of T, failing if T is not a struct or does not
+// declare its table or primary key.
+func NewModel[T any](client *Client) (*Model[T], error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model %s is not a struct", t)
+	}
+
+	m := &Model[T]{client: client, db: client.DB}
+	if tabler, ok := reflect.New(t).Interface().(Tabler); ok {
+		m.table = tabler.TableName()
+	}
+	for _, field := range reflect.VisibleFields(t) {
+		for _, opt := range strings.Split(field.Tag.Get("supabase"), ",") {
+			switch {
+			case strings.HasPrefix(opt, "table="):
+				m.table = strings.TrimPrefix(opt, "table=")
+			case opt == "pk":
+				if m.pkPath != nil {
+					return nil, fmt.Errorf("model %s has more than one primary key", t)
+				}
+				// the column as sent by the client, so Create leaves it out of the row
+				if m.pk = client.DB.ColumnName(field); m.pk == "" {
+					return nil, fmt.Errorf("model %s has a primary key which is not a column", t)
+				}
+				m.pkPath = field.Index
+			}
+		}
+	}
+
+	if m.table == "" {
+		return nil, fmt.Errorf("model %s has no table, tag a field with supabase:\"table=name\"", t)
+	}
+	if m.pkPath == nil {
+		return nil, fmt.Errorf("model %s has no primary key, tag a field with supabase:\"pk\"", t)
+	}
+	return m, nil
+}
+
+// Table returns the name of the table of the model.
+func (m *Model[T]) Table() string {
+	return m.table
+}
+
+// Query starts building a request to the table of the model.
+func (m *Model[T]) Query() *postgrest.RequestBuilder {
+	return m.db.From(m.table)
+}
+
+// Find returns the row with the given primary key, or ErrRowNotFound.
+func (m *Model[T]) Find(ctx context.Context, id interface{}) (*T, error) {
+	var rows []T
+	err := m.Query().Select("*").Limit(1).Eq(m.pk, formatKey(id)).ExecuteWithContext(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrRowNotFound
+	}
+	return &rows[0], nil
+}
+
+// FindAll returns all the rows of the table. Use Query to filter or page them.
+func (m *Model[T]) FindAll(ctx context.Context) ([]T, error) {
+	rows := []T{}
+	if err := m.Query().Select("*").ExecuteWithContext(ctx, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Create inserts row and updates it with the inserted row, including the
+// values set by the database. A zero primary key is left out so the column
+// default generates it.
+func (m *Model[T]) Create(ctx context.Context, row *T) error {
+	var payload interface{} = row
+	if m.key(row).IsZero() {
+		fields, err := m.fields(row)
+		if err != nil {
+			return err
+		}
+		delete(fields, m.pk)
+		payload = fields
+	}
+	return m.executeOne(ctx, m.Query().Insert(payload), row)
+}
+
+// Save updates the row with the primary key of row, or inserts row with
+// Create when its primary key is zero. row is updated with the stored row.
+func (m *Model[T]) Save(ctx context.Context, row *T) error {
+	key := m.key(row)
+	if key.IsZero() {
+		return m.Create(ctx, row)
+	}
+
+	builder := m.Query().Update(row).Eq(m.pk, formatKey(key.Interface()))
+	return m.executeOne(ctx, &builder.QueryRequestBuilder, row)
+}
+
+// Delete deletes the row with the primary key of row.
+func (m *Model[T]) Delete(ctx context.Context, row *T) error {
+	return m.Query().Delete().Eq(m.pk, formatKey(m.key(row).Interface())).ExecuteWithContext(ctx, nil)
+}
+
+// executeOne executes a request returning the affected row into row, failing
+// with ErrRowNotFound if no row was affected.
+func (m *Model[T]) executeOne(ctx context.Context, builder *postgrest.QueryRequestBuilder, row *T) error {
+	var rows []T
+	if err := builder.ExecuteWithContext(ctx, &rows); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return ErrRowNotFound
+	}
+	*row = rows[0]
+	return nil
+}
+
+// key returns the primary key field of row.
+func (m *Model[T]) key(row *T) reflect.Value {
+	return reflect.ValueOf(row).Elem().FieldByIndex(m.pkPath)
+}
+
+// fields returns the columns of row as encoded by the client.
+func (m *Model[T]) fields(row *T) (map[string]json.RawMessage, error) {
+	data, err := m.db.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := m.client.unmarshalJSON(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// formatKey formats a primary key value for a filter.
+func formatKey(id interface{}) string {
+	if stringer, ok := id.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprint(id)
+}
@@ -0,0 +1,51 @@
+package supabase
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	postgrest "github.com/nedpals/supabase-go/postgrest/pkg"
+)
+
+type namedTodo struct {
+	_         struct{} `supabase:"table=todos"`
+	TodoID    int64    `supabase:"pk"`
+	Title     string
+	CreatedBy string
+}
+
+func TestModel_CreateWithNamingStrategy(t *testing.T) {
+	var inserted map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &inserted); err != nil {
+			t.Errorf("expected a JSON row, got %s", body)
+		}
+		w.Write([]byte(`[{"todo_id":7,"title":"write tests","created_by":"alice"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "key")
+	postgrest.WithNamingStrategy(postgrest.SnakeCase)(client.DB)
+	todos, err := NewModel[namedTodo](client)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	todo := namedTodo{Title: "write tests", CreatedBy: "alice"}
+	if err := todos.Create(context.Background(), &todo); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// the zero primary key is left out under the column name it is sent as
+	if _, ok := inserted["todo_id"]; ok || len(inserted) != 2 || inserted["created_by"] != "alice" {
+		t.Errorf("expected the row without its primary key, got %v", inserted)
+	}
+	if todo.TodoID != 7 {
+		t.Errorf("expected the generated primary key, got %d", todo.TodoID)
+	}
+}
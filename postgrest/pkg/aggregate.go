@@ -0,0 +1,127 @@
+package postgrest_go
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AggregateFunction is an aggregate function of PostgREST.
+type AggregateFunction string
+
+const (
+	AggregateCount AggregateFunction = "count"
+	AggregateSum   AggregateFunction = "sum"
+	AggregateAvg   AggregateFunction = "avg"
+	AggregateMin   AggregateFunction = "min"
+	AggregateMax   AggregateFunction = "max"
+)
+
+// Aggregate is an aggregate selected with SelectAggregate.
+type Aggregate struct {
+	Function AggregateFunction
+	// Column is the aggregated column, empty to count the rows with count()
+	Column string
+	// Alias is the key of the result, the name of the function by default
+	Alias string
+}
+
+// key returns the key of the aggregate in the result rows.
+func (a Aggregate) key() string {
+	if a.Alias != "" {
+		return a.Alias
+	}
+	return string(a.Function)
+}
+
+// expression returns the select expression of the aggregate.
+func (a Aggregate) expression() string {
+	expr := CountRows()
+	if a.Column != "" {
+		expr = aggregate(a.Column, string(a.Function))
+	}
+	if a.Alias != "" {
+		expr = Alias(a.Alias, expr)
+	}
+	return expr
+}
+
+func (a Aggregate) validate() error {
+	switch a.Function {
+	case AggregateCount:
+	case AggregateSum, AggregateAvg, AggregateMin, AggregateMax:
+		if a.Column == "" {
+			return fmt.Errorf("aggregate %s requires a column", a.Function)
+		}
+	default:
+		return fmt.Errorf("unknown aggregate function %q", a.Function)
+	}
+	return nil
+}
+
+// AggregateRow is a row of the result of SelectAggregate. Numbers are
+// decoded as json.Number, so sums and averages of numeric and bigint columns
+// keep their precision.
+type AggregateRow map[string]interface{}
+
+// Number returns the number with the given key, false if it is missing or not a number.
+func (r AggregateRow) Number(key string) (json.Number, bool) {
+	n, ok := r[key].(json.Number)
+	return n, ok
+}
+
+// SelectAggregate starts building a SELECT request of aggregates grouped by
+// the groupBy columns, e.g.
+//
+//	var rows []postgrest.AggregateRow
+//	err := client.From("orders").SelectAggregate([]string{"status"},
+//		postgrest.Aggregate{Function: postgrest.AggregateCount},
+//		postgrest.Aggregate{Function: postgrest.AggregateSum, Column: "amount", Alias: "total"},
+//	).ExecuteWithContext(ctx, &rows)
+//
+// PostgREST groups the rows by the selected columns that are not aggregates.
+// Aggregates, whether selected with SelectAggregate or with CountRows, CountOf,
+// Sum, Avg, Min and Max, require PostgREST 12 with db-aggregates-enabled.
+//
+// The request fails without being sent if an aggregate has no column where one
+// is required, if a group by column is an aggregate, or if two results have
+// the same key. Numbers decoded into interface{} values, e.g. of AggregateRow,
+// are json.Number.
+func (b *RequestBuilder) SelectAggregate(groupBy []string, aggregates ...Aggregate) *SelectRequestBuilder {
+	columns := make([]string, 0, len(groupBy)+len(aggregates))
+	for _, column := range groupBy {
+		columns = append(columns, SanitizeParam(column))
+	}
+	for _, a := range aggregates {
+		columns = append(columns, a.expression())
+	}
+
+	builder := b.Select(columns...)
+	builder.useNumber = true
+	builder.err = validateAggregates(groupBy, aggregates)
+	return builder
+}
+
+func validateAggregates(groupBy []string, aggregates []Aggregate) error {
+	if len(aggregates) == 0 {
+		return fmt.Errorf("select aggregate requires at least one aggregate")
+	}
+
+	keys := map[string]bool{}
+	for _, column := range groupBy {
+		if column == "" || column == "*" || strings.Contains(column, "()") {
+			return fmt.Errorf("invalid group by column %q", column)
+		}
+		keys[column] = true
+	}
+	for _, a := range aggregates {
+		if err := a.validate(); err != nil {
+			return err
+		}
+		if keys[a.key()] {
+			return fmt.Errorf("duplicate result key %q, set the Alias of the aggregate", a.key())
+		}
+		keys[a.key()] = true
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package postgrest_go
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestRequestBuilder_SelectAggregate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("select"); got != "status,count(),total:amount.sum()" {
+			t.Errorf("expected param select to be status,count(),total:amount.sum(), got %s", got)
+		}
+		w.Write([]byte(`[{"status":"paid","count":2,"total":90071992547409.93}]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	var rows []AggregateRow
+	err := client.From("orders").SelectAggregate([]string{"status"},
+		Aggregate{Function: AggregateCount},
+		Aggregate{Function: AggregateSum, Column: "amount", Alias: "total"},
+	).ExecuteWithContext(context.Background(), &rows)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(rows) != 1 || rows[0]["status"] != "paid" {
+		t.Fatalf("expected 1 row grouped by status, got %v", rows)
+	}
+	if total, ok := rows[0].Number("total"); !ok || total.String() != "90071992547409.93" {
+		t.Errorf("expected the total to keep its precision, got %v", rows[0]["total"])
+	}
+	if count, ok := rows[0].Number("count"); !ok || count.String() != "2" {
+		t.Errorf("expected the count to be 2, got %v", rows[0]["count"])
+	}
+}
+
+func TestRequestBuilder_SelectAggregateValidation(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"})
+
+	tests := map[string]*SelectRequestBuilder{
+		"no aggregate":      client.From("orders").SelectAggregate([]string{"status"}),
+		"missing column":    client.From("orders").SelectAggregate(nil, Aggregate{Function: AggregateSum}),
+		"unknown function":  client.From("orders").SelectAggregate(nil, Aggregate{Function: "median", Column: "amount"}),
+		"aggregate group":   client.From("orders").SelectAggregate([]string{"count()"}, Aggregate{Function: AggregateCount}),
+		"duplicate keys":    client.From("orders").SelectAggregate(nil, Aggregate{Function: AggregateSum, Column: "a"}, Aggregate{Function: AggregateSum, Column: "b"}),
+		"group by key used": client.From("orders").SelectAggregate([]string{"total"}, Aggregate{Function: AggregateSum, Column: "amount", Alias: "total"}),
+	}
+	for name, builder := range tests {
+		if _, err := builder.Build(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
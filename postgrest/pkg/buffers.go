@@ -0,0 +1,47 @@
+package postgrest_go
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// nullJSON is the body of requests without a JSON payload.
+var nullJSON = []byte("null")
+
+// maxPooledBuffer is the capacity above which response buffers are not
+// pooled, so a single large response does not stay in memory.
+const maxPooledBuffer = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// readBody reads a response body into a pooled buffer, failing with a
+// *ResponseTooLargeError if it is larger than limit when limit > 0. The buffer
+// is returned to the pool with releaseBuffer once the body is decoded.
+func readBody(r io.Reader, limit int64) (*bytes.Buffer, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	if limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	if _, err := buf.ReadFrom(r); err != nil {
+		releaseBuffer(buf)
+		return nil, err
+	}
+	if limit > 0 && int64(buf.Len()) > limit {
+		releaseBuffer(buf)
+		return nil, &ResponseTooLargeError{Limit: limit}
+	}
+	return buf, nil
+}
+
+func releaseBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
@@ -4,18 +4,44 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Client struct {
 	session        http.Client
 	Debug          bool
+	headersMu      sync.RWMutex
 	defaultHeaders http.Header
-	Transport      *PostgrestTransport
+	defaultTimeout time.Duration
+	naming         NamingStrategy
+	// useNumber decodes numbers into json.Number, see WithUseNumber
+	useNumber   bool
+	maxResponse int64
+	// jsonMarshal and jsonUnmarshal are set by WithJSONCodec
+	jsonMarshal   func(v interface{}) ([]byte, error)
+	jsonUnmarshal func(data []byte, v interface{}) error
+	// compressMin is the size of the bodies compressed, see WithRequestCompression
+	compressMin int
+	// softDeleteColumn is the timestamp column set by SoftDelete
+	softDeleteColumn string
+	Transport        *PostgrestTransport
+	// replicas are read replicas used by SELECT requests with UseReplica
+	replicas    []url.URL
+	nextReplica atomic.Uint64
+	// pins route replica reads to the primary after writes, see WithReadYourWrites
+	pins *primaryPins
+	// pinKey tells apart the users of requests without an Authorization header, see WithPinKey
+	pinKey func(ctx context.Context) string
+	// queue stores the mutations of ExecuteOrQueue while the network is unavailable
+	queue MutationQueue
 }
 
 type ClientOption func(c *Client)
@@ -62,95 +88,249 @@ type RpcRequestBuilder struct {
 	path       string
 	header     http.Header
 	httpMethod string
-	params     map[string]interface{}
+	params     interface{}
+	timeout    time.Duration
 }
 
 func (c *Client) Rpc(f string, params map[string]interface{}) *RpcRequestBuilder {
 	return &RpcRequestBuilder{
 		client:     c,
-		path:       c.Transport.baseURL.String() + "rpc/" + f,
+		path:       "/rpc/" + f,
 		header:     http.Header{},
 		httpMethod: http.MethodPost,
 		params:     params,
 	}
 }
 
+// WithTimeout sets the timeout of the RPC request.
+func (r *RpcRequestBuilder) WithTimeout(d time.Duration) *RpcRequestBuilder {
+	r.timeout = d
+	return r
+}
+
+// Execute sends the RPC request using the client default timeout.
 func (r *RpcRequestBuilder) Execute(result interface{}) error {
-	return r.ExecuteWithContext(context.Background(), result)
+	ctx, cancel := r.client.defaultContext()
+	defer cancel()
+	return r.ExecuteWithContext(ctx, result)
 }
 
 func (r *RpcRequestBuilder) ExecuteWithContext(ctx context.Context, result interface{}) error {
-	data, err := json.Marshal(r.params)
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	req, err := r.newRequest(ctx)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, r.httpMethod, r.path, bytes.NewBuffer(data))
+	resp, err := r.client.session.Do(req)
 	if err != nil {
 		return err
 	}
 
-	req.Header = r.client.Headers()
+	respBody, err := responseBody(resp)
+	if err != nil {
+		return err
+	}
+	defer respBody.Close()
+	buf, err := readBody(respBody, r.client.maxResponse)
+	if err != nil {
+		return err
+	}
+	defer releaseBuffer(buf)
+	body := buf.Bytes()
+
+	statusOK := resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !statusOK {
+		return r.client.decodeRequestError(resp.StatusCode, body)
+	}
+	r.client.recordWrite(ctx, r.httpMethod, req.Header.Get("Authorization"))
 
-	// inject/override custom headers
-	for key, vals := range r.header {
-		for _, val := range vals {
-			req.Header.Set(key, val)
+	if resp.StatusCode != http.StatusNoContent && result != nil {
+		if err = r.client.unmarshal(body, result, r.client.useNumber); err != nil {
+			return &DecodeError{Err: err}
 		}
 	}
 
-	req.URL.Path = req.URL.Path[1:]
-	req.URL = r.client.Transport.baseURL.ResolveReference(req.URL)
+	return nil
+}
 
-	resp, err := r.client.session.Do(req)
+// Build returns the fully composed RPC request without sending it.
+func (r *RpcRequestBuilder) Build() (*http.Request, error) {
+	return r.newRequest(context.Background())
+}
+
+// String returns the method, URL, headers and body of the RPC request.
+func (r *RpcRequestBuilder) String() string {
+	req, err := r.Build()
 	if err != nil {
-		return err
+		return "error: " + err.Error()
 	}
+	return formatRequest(req)
+}
 
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+func (r *RpcRequestBuilder) newRequest(ctx context.Context) (*http.Request, error) {
+	data, err := r.encodeParams()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	statusOK := resp.StatusCode >= 200 && resp.StatusCode < 300
-	if !statusOK {
-		reqError := RequestError{HTTPStatusCode: resp.StatusCode}
-
-		if err = json.Unmarshal(body, &reqError); err != nil {
-			return err
+	reqURL := r.client.resolveURL(r.path)
+	var body io.Reader = bytes.NewBuffer(data)
+	if r.httpMethod == http.MethodGet {
+		query, err := rpcQuery(data)
+		if err != nil {
+			return nil, err
 		}
+		reqURL.RawQuery = encodeQuery(query)
+		body = nil
+	}
 
-		return &reqError
+	req, err := http.NewRequestWithContext(ctx, r.httpMethod, reqURL.String(), body)
+	if err != nil {
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusNoContent && r != nil {
-		if err = json.Unmarshal(body, result); err != nil {
-			return err
+	req.Header = r.client.requestHeaders(r.header)
+	return req, nil
+}
+
+// redactedHeaders are the credential headers masked by formatRequest.
+var redactedHeaders = map[string]bool{"Authorization": true, "Apikey": true}
+
+// formatRequest formats a request built by Build for logging, reading its body
+// through GetBody. Credentials are masked.
+func formatRequest(req *http.Request) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s\n", req.Method, req.URL.String())
+
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, val := range req.Header[key] {
+			if redactedHeaders[http.CanonicalHeaderKey(key)] {
+				val = "[redacted]"
+			}
+			fmt.Fprintf(&sb, "%s: %s\n", key, val)
 		}
 	}
 
-	return nil
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			if len(data) > 0 && string(data) != "null" {
+				sb.WriteString("\n")
+				sb.Write(data)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// defaultContext returns the context used by Execute, bounded by the client default timeout if set.
+func (c *Client) defaultContext() (context.Context, context.CancelFunc) {
+	if c.defaultTimeout > 0 {
+		return context.WithTimeout(context.Background(), c.defaultTimeout)
+	}
+	return context.Background(), func() {}
+}
+
+// BaseURL returns the URL of the PostgREST instance.
+func (c *Client) BaseURL() url.URL {
+	return c.Transport.baseURL
+}
+
+// resolveURL joins the path of a table or function to the base URL,
+// keeping the path prefix of the base URL.
+func (c *Client) resolveURL(path string) *url.URL {
+	base := c.Transport.baseURL
+	return base.JoinPath(path)
+}
+
+// resolveReplicaURL joins the path to the URL of the next read replica in
+// round-robin order, or to the base URL if there are no replicas.
+func (c *Client) resolveReplicaURL(path string) *url.URL {
+	if len(c.replicas) == 0 {
+		return c.resolveURL(path)
+	}
+	base := c.replicas[(c.nextReplica.Add(1)-1)%uint64(len(c.replicas))]
+	return base.JoinPath(path)
 }
 
 func (c *Client) CloseIdleConnections() {
 	c.session.CloseIdleConnections()
 }
 
+// Headers returns a copy of the default headers sent with every request.
 func (c *Client) Headers() http.Header {
+	c.headersMu.RLock()
+	defer c.headersMu.RUnlock()
 	return c.defaultHeaders.Clone()
 }
 
+// requestHeaders returns a copy of the default headers overridden by the
+// custom headers of a request, the last value of each custom header replacing
+// the default one. All values share a single allocation.
+func (c *Client) requestHeaders(custom http.Header) http.Header {
+	c.headersMu.RLock()
+	defer c.headersMu.RUnlock()
+
+	n := 0
+	for _, vals := range c.defaultHeaders {
+		n += len(vals)
+	}
+	n += len(custom)
+	values := make([]string, 0, n)
+
+	header := make(http.Header, len(c.defaultHeaders)+len(custom))
+	for key, vals := range c.defaultHeaders {
+		values = append(values, vals...)
+		header[key] = values[len(values)-len(vals) : len(values) : len(values)]
+	}
+	for key, vals := range custom {
+		if len(vals) == 0 {
+			continue
+		}
+		values = append(values, vals[len(vals)-1])
+		header[http.CanonicalHeaderKey(key)] = values[len(values)-1 : len(values) : len(values)]
+	}
+	return header
+}
+
+// AddHeader sets a default header. It is safe to call while requests are in flight.
 func (c *Client) AddHeader(key string, value string) {
+	c.headersMu.Lock()
+	defer c.headersMu.Unlock()
 	c.defaultHeaders.Set(key, value)
 }
 
+// SetAuth replaces the bearer token of the Authorization header, e.g. when a
+// user token is rotated. It is safe for concurrent use.
+func (c *Client) SetAuth(token string) {
+	c.AddHeader("Authorization", "Bearer "+token)
+}
+
 func WithTokenAuth(token string) ClientOption {
 	return func(c *Client) {
 		c.AddHeader("Authorization", "Bearer "+token)
 	}
 }
 
+// WithAPIKey sets the apikey header sent with every query and RPC request,
+// as required by the Supabase API gateway.
+func WithAPIKey(key string) ClientOption {
+	return func(c *Client) {
+		c.AddHeader("apikey", key)
+	}
+}
+
 func WithBasicAuth(username, password string) ClientOption {
 	return func(c *Client) {
 		c.AddHeader("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
@@ -163,3 +343,36 @@ func WithSchema(schema string) ClientOption {
 		c.AddHeader("Content-Profile", schema)
 	}
 }
+
+// WithBaseURL sets the URL of the PostgREST instance, e.g. one served under
+// a path prefix behind a reverse proxy.
+func WithBaseURL(baseURL url.URL) ClientOption {
+	return func(c *Client) {
+		c.Transport.baseURL = baseURL
+	}
+}
+
+// WithReadReplica adds read replicas of the database. SELECT requests marked
+// with UseReplica are sent to the replicas in round-robin order, all other
+// requests go to the primary at the base URL. See WithReadYourWrites to read
+// from the primary after writes.
+func WithReadReplica(replicaURLs ...url.URL) ClientOption {
+	return func(c *Client) {
+		c.replicas = append(c.replicas, replicaURLs...)
+	}
+}
+
+// WithMaxResponseBytes limits the size of response bodies, so a query missing a
+// limit cannot exhaust memory. Larger responses fail with *ResponseTooLargeError.
+func WithMaxResponseBytes(n int64) ClientOption {
+	return func(c *Client) {
+		c.maxResponse = n
+	}
+}
+
+// WithDefaultTimeout sets the timeout of requests sent with Execute, i.e. without a context.
+func WithDefaultTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.defaultTimeout = d
+	}
+}
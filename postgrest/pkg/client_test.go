@@ -1,8 +1,13 @@
 package postgrest_go
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestPostgrestClient_Constructor(t *testing.T) {
@@ -59,3 +64,248 @@ func TestPostgrestClient_Schema(t *testing.T) {
 		t.Errorf("expected header Content-Profile == %s, got %s", "private", got)
 	}
 }
+
+func TestPostgrestClient_SetAuth(t *testing.T) {
+	client := NewClient(
+		url.URL{Scheme: "https", Host: "example.com"},
+		WithTokenAuth("s3cr3t"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client.SetAuth("r0t4t3d")
+		}()
+		go func() {
+			defer wg.Done()
+			client.Headers()
+		}()
+	}
+	wg.Wait()
+
+	if got := client.Headers().Get("Authorization"); got != "Bearer r0t4t3d" {
+		t.Errorf("expected header Authorization == %s, got %s", "Bearer r0t4t3d", got)
+	}
+}
+
+func TestPostgrestClient_BaseURLPathPrefix(t *testing.T) {
+	var gotPaths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPaths = append(gotPaths, r.URL.Path)
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	for _, basePath := range []string{"/proxy/rest/v1", "/proxy/rest/v1/"} {
+		gotPaths = nil
+		baseURL := *serverURL
+		baseURL.Path = basePath
+		client := NewClient(url.URL{Scheme: "https", Host: "example.com"}, WithBaseURL(baseURL))
+
+		if got := client.BaseURL(); got != baseURL {
+			t.Errorf("expected BaseURL == %s, got %s", baseURL.String(), got.String())
+		}
+		if err := client.From("example_table").Select("*").Execute(nil); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		var result []interface{}
+		if err := client.Rpc("example_function", nil).Execute(&result); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		expected := []string{"/proxy/rest/v1/example_table", "/proxy/rest/v1/rpc/example_function"}
+		if len(gotPaths) != len(expected) || gotPaths[0] != expected[0] || gotPaths[1] != expected[1] {
+			t.Errorf("expected paths == %v for base path %s, got %v", expected, basePath, gotPaths)
+		}
+	}
+}
+
+func TestPostgrestClient_ReadReplica(t *testing.T) {
+	var hits [3]int
+	newServer := func(i int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits[i]++
+			w.Write([]byte(`[]`))
+		}))
+	}
+	primary, replica1, replica2 := newServer(0), newServer(1), newServer(2)
+	defer primary.Close()
+	defer replica1.Close()
+	defer replica2.Close()
+
+	primaryURL, _ := url.Parse(primary.URL + "/")
+	replica1URL, _ := url.Parse(replica1.URL + "/")
+	replica2URL, _ := url.Parse(replica2.URL + "/")
+	client := NewClient(*primaryURL, WithReadReplica(*replica1URL, *replica2URL))
+
+	for i := 0; i < 4; i++ {
+		if err := client.From("example_table").Select("*").UseReplica().Execute(nil); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if err := client.From("example_table").Select("*").Execute(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := client.From("example_table").Insert(map[string]string{"name": "x"}).Execute(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if hits != [3]int{2, 2, 2} {
+		t.Errorf("expected hits == %v, got %v", [3]int{2, 2, 2}, hits)
+	}
+}
+
+func TestPostgrestClient_ReadYourWrites(t *testing.T) {
+	var primaryHits, replicaHits int
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHits++
+		w.Write([]byte(`[]`))
+	}))
+	defer primary.Close()
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		replicaHits++
+		w.Write([]byte(`[]`))
+	}))
+	defer replica.Close()
+
+	primaryURL, _ := url.Parse(primary.URL + "/")
+	replicaURL, _ := url.Parse(replica.URL + "/")
+	client := NewClient(*primaryURL, WithReadReplica(*replicaURL), WithReadYourWrites(50*time.Millisecond))
+
+	read := func(token string) {
+		builder := client.From("example_table").Select("*").UseReplica()
+		builder.header.Set("Authorization", "Bearer "+token)
+		if err := builder.Execute(nil); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	read("alice")
+	insert := client.From("example_table").Insert(map[string]string{"name": "x"})
+	insert.header.Set("Authorization", "Bearer alice")
+	if err := insert.Execute(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	read("alice")
+	read("bob")
+	if primaryHits != 2 || replicaHits != 2 {
+		t.Errorf("expected the read of alice after her write to go to the primary, got %d primary and %d replica hits", primaryHits, replicaHits)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	read("alice")
+	if replicaHits != 3 {
+		t.Errorf("expected the reads of alice to go to the replica once the pin expired, got %d replica hits", replicaHits)
+	}
+}
+
+type pinUserKey struct{}
+
+func TestPostgrestClient_ReadYourWritesPinKey(t *testing.T) {
+	var primaryHits, replicaHits int
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHits++
+		w.Write([]byte(`[]`))
+	}))
+	defer primary.Close()
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		replicaHits++
+		w.Write([]byte(`[]`))
+	}))
+	defer replica.Close()
+
+	// the users of the requests are only known from their context, like when
+	// the transport adds the Authorization header
+	primaryURL, _ := url.Parse(primary.URL + "/")
+	replicaURL, _ := url.Parse(replica.URL + "/")
+	client := NewClient(*primaryURL, WithReadReplica(*replicaURL), WithReadYourWrites(time.Minute),
+		WithPinKey(func(ctx context.Context) string {
+			user, _ := ctx.Value(pinUserKey{}).(string)
+			return user
+		}))
+	alice := context.WithValue(context.Background(), pinUserKey{}, "alice")
+	bob := context.WithValue(context.Background(), pinUserKey{}, "bob")
+
+	if err := client.From("example_table").Insert(map[string]string{"name": "x"}).ExecuteWithContext(alice, nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, ctx := range []context.Context{alice, bob} {
+		if err := client.From("example_table").Select("*").UseReplica().ExecuteWithContext(ctx, nil); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if primaryHits != 2 || replicaHits != 1 {
+		t.Errorf("expected only the read of alice to go to the primary, got %d primary and %d replica hits", primaryHits, replicaHits)
+	}
+}
+
+func TestPostgrestClient_APIKey(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.Path+" "+r.Header.Get("apikey"))
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL, WithAPIKey("anon-key"))
+
+	if err := client.From("example_table").Select("*").Execute(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := client.From("example_table").Insert(map[string]string{"name": "x"}).Execute(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := client.Rpc("example_function", nil).Execute(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := client.RpcWithParams("example_function", nil).Get().Execute(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []string{
+		"GET /example_table anon-key",
+		"POST /example_table anon-key",
+		"POST /rpc/example_function anon-key",
+		"GET /rpc/example_function anon-key",
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected requests == %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected request == %s, got %s", expected[i], got[i])
+		}
+	}
+}
+
+func TestPostgrestClient_RequestHeaders(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"}, WithTokenAuth("default"))
+	client.AddHeader("apikey", "key")
+
+	builder := client.From("items").Select("*")
+	builder.header.Add("authorization", "Bearer first")
+	builder.header.Add("authorization", "Bearer user")
+
+	req, err := builder.Build()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := req.Header.Values("Authorization"); len(got) != 1 || got[0] != "Bearer user" {
+		t.Errorf("expected Authorization == [Bearer user], got %v", got)
+	}
+	if got := req.Header.Get("apikey"); got != "key" {
+		t.Errorf("expected apikey == key, got %s", got)
+	}
+
+	req.Header.Add("Apikey", "other")
+	req.Header["Accept-Profile"][0] = "other"
+	if got := client.Headers().Values("apikey"); len(got) != 1 || got[0] != "key" {
+		t.Errorf("expected default apikey to be unchanged, got %v", got)
+	}
+	if got := client.Headers().Get("Accept-Profile"); got != "public" {
+		t.Errorf("expected default Accept-Profile to be unchanged, got %s", got)
+	}
+}
@@ -0,0 +1,30 @@
+package postgrest_go
+
+import "encoding/json"
+
+// WithJSONCodec sets the functions encoding request bodies and decoding
+// responses, json.Marshal and json.Unmarshal by default, e.g. to use a faster
+// drop-in replacement of encoding/json such as goccy/go-json or sonic. They
+// must honor json struct tags and the json.Marshaler and json.Unmarshaler
+// interfaces. Responses decoded with json.Number numbers, see WithUseNumber,
+// or into rows with sql.Scanner fields still use encoding/json.
+func WithJSONCodec(marshal func(v interface{}) ([]byte, error), unmarshal func(data []byte, v interface{}) error) ClientOption {
+	return func(c *Client) {
+		c.jsonMarshal = marshal
+		c.jsonUnmarshal = unmarshal
+	}
+}
+
+func (c *Client) jsonEncode(v interface{}) ([]byte, error) {
+	if c.jsonMarshal == nil {
+		return json.Marshal(v)
+	}
+	return c.jsonMarshal(v)
+}
+
+func (c *Client) jsonDecode(data []byte, v interface{}) error {
+	if c.jsonUnmarshal == nil {
+		return json.Unmarshal(data, v)
+	}
+	return c.jsonUnmarshal(data, v)
+}
@@ -0,0 +1,53 @@
+package postgrest_go
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestWithJSONCodec(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") == "eq.2" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"PGRST116","message":"not found"}`))
+			return
+		}
+		w.Write([]byte(`[{"id":1,"name":"a"}]`))
+	}))
+	defer server.Close()
+
+	var marshaled, unmarshaled int
+	baseURL, _ := url.Parse(server.URL)
+	client := NewClient(*baseURL, WithJSONCodec(
+		func(v interface{}) ([]byte, error) {
+			marshaled++
+			return json.Marshal(v)
+		},
+		func(data []byte, v interface{}) error {
+			unmarshaled++
+			return json.Unmarshal(data, v)
+		},
+	))
+
+	var rows []map[string]interface{}
+	if err := client.From("items").Insert(map[string]interface{}{"name": "a"}).Execute(&rows); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(rows) != 1 || rows[0]["name"] != "a" {
+		t.Errorf("expected the inserted row, got %v", rows)
+	}
+	if marshaled != 1 || unmarshaled != 1 {
+		t.Errorf("expected the codec to encode the body and decode the response, got %d marshal and %d unmarshal calls", marshaled, unmarshaled)
+	}
+
+	err := client.From("items").Select("*").Eq("id", "2").Execute(&rows)
+	if reqErr, ok := err.(*RequestError); !ok || reqErr.Code != "PGRST116" {
+		t.Errorf("expected the decoded request error, got %v", err)
+	}
+	if unmarshaled != 2 {
+		t.Errorf("expected the codec to decode the error, got %d unmarshal calls", unmarshaled)
+	}
+}
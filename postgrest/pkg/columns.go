@@ -0,0 +1,55 @@
+package postgrest_go
+
+// Cast returns the select expression of a column cast to castType, e.g.
+// Cast("price", "text") selects price::text.
+func Cast(column, castType string) string {
+	return SanitizeParam(column) + "::" + castType
+}
+
+// Alias returns a select expression renamed to alias in the result, e.g.
+// Alias("total", Sum("amount")) selects total:amount.sum().
+func Alias(alias, expr string) string {
+	return SanitizeParam(alias) + ":" + expr
+}
+
+// CountRows returns the count() aggregate, the number of rows of each group.
+// The rows are grouped by the other selected columns, see SelectAggregate for
+// the requirements of aggregates.
+func CountRows() string {
+	return "count()"
+}
+
+// CountOf returns the count aggregate of the non-null values of a column.
+func CountOf(column string) string {
+	return aggregate(column, "count")
+}
+
+// Sum returns the sum aggregate of a column.
+func Sum(column string) string {
+	return aggregate(column, "sum")
+}
+
+// Avg returns the avg aggregate of a column.
+func Avg(column string) string {
+	return aggregate(column, "avg")
+}
+
+// Min returns the min aggregate of a column.
+func Min(column string) string {
+	return aggregate(column, "min")
+}
+
+// Max returns the max aggregate of a column.
+func Max(column string) string {
+	return aggregate(column, "max")
+}
+
+func aggregate(column, function string) string {
+	return SanitizeParam(column) + "." + function + "()"
+}
+
+// SelectWithCast starts building a SELECT request of a column cast to
+// castType, followed by the other columns.
+func (b *RequestBuilder) SelectWithCast(column, castType string, columns ...string) *SelectRequestBuilder {
+	return b.Select(append([]string{Cast(column, castType)}, columns...)...)
+}
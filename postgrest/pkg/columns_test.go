@@ -0,0 +1,37 @@
+package postgrest_go
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestSelectExpressions(t *testing.T) {
+	tests := map[string]string{
+		Cast("price", "text"):             "price::text",
+		Alias("total", Sum("amount")):     "total:amount.sum()",
+		CountRows():                       "count()",
+		CountOf("email"):                  "email.count()",
+		Avg("amount"):                     "amount.avg()",
+		Min("created_at"):                 "created_at.min()",
+		Max("created_at"):                 "created_at.max()",
+		Cast("weird.name", "int"):         `"weird.name"::int`,
+		Alias("n", Cast("amount", "int")): "n:amount::int",
+	}
+	for got, expected := range tests {
+		if got != expected {
+			t.Errorf("expected %s, got %s", expected, got)
+		}
+	}
+}
+
+func TestRequestBuilder_SelectWithCast(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"})
+
+	req, err := client.From("orders").SelectWithCast("amount", "text", "id").Build()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := req.URL.Query().Get("select"); got != "amount::text,id" {
+		t.Errorf("expected param select to be amount::text,id, got %s", got)
+	}
+}
@@ -0,0 +1,82 @@
+package postgrest_go
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// WithRequestCompression compresses the JSON bodies of INSERT, UPSERT and
+// UPDATE requests of at least minSize bytes with gzip, sent with a
+// Content-Encoding: gzip header, e.g. for bulk imports over slow links.
+// PostgREST does not decompress request bodies itself, so the API must be
+// served behind a gateway that does.
+//
+// Responses are decompressed regardless of this option: by the transport when
+// it asked for gzip itself, and by the client when the Accept-Encoding header
+// is set explicitly, e.g. with AddHeader.
+func WithRequestCompression(minSize int) ClientOption {
+	return func(c *Client) {
+		c.compressMin = minSize
+	}
+}
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(io.Discard)
+	},
+}
+
+// compressBody returns the body compressed with gzip if compression is
+// enabled and the body of a write is large enough, false otherwise.
+func (c *Client) compressBody(method string, data []byte) ([]byte, bool, error) {
+	if c.compressMin <= 0 || len(data) < c.compressMin {
+		return data, false, nil
+	}
+	switch method {
+	case http.MethodPost, http.MethodPatch, http.MethodPut:
+	default:
+		return data, false, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(zw)
+	zw.Reset(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, false, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, false, err
+	}
+	return buf.Bytes(), true, nil
+}
+
+// responseBody returns the body of a response, decompressing it if it is
+// gzip encoded and the transport left it compressed. Closing the returned body
+// closes the body of the response, which is closed on error.
+func responseBody(resp *http.Response) (io.ReadCloser, error) {
+	if resp.Uncompressed || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return resp.Body, nil
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return &gzipBody{Reader: zr, body: resp.Body}, nil
+}
+
+// gzipBody is a decompressed response body.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
@@ -0,0 +1,139 @@
+package postgrest_go
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWithRequestCompression(t *testing.T) {
+	// the handler records the decoding error for the test goroutine, which
+	// checks it after each request
+	var encoding, body string
+	var decodeErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding, decodeErr = r.Header.Get("Content-Encoding"), nil
+		var reader io.Reader = r.Body
+		if encoding == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				decodeErr = err
+				w.Write([]byte("[]"))
+				return
+			}
+			reader = zr
+		}
+		data, _ := io.ReadAll(reader)
+		body = string(data)
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL)
+	client := NewClient(*baseURL, WithRequestCompression(64))
+
+	large := map[string]string{"name": strings.Repeat("a", 100)}
+	if err := client.From("items").Insert(large).Execute(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if decodeErr != nil {
+		t.Fatalf("expected a gzip body, got %v", decodeErr)
+	}
+	if encoding != "gzip" {
+		t.Errorf("expected Content-Encoding == gzip, got %q", encoding)
+	}
+	if expected := `{"name":"` + strings.Repeat("a", 100) + `"}`; body != expected {
+		t.Errorf("expected body == %s, got %s", expected, body)
+	}
+
+	if err := client.From("items").Insert(map[string]string{"name": "a"}).Execute(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if encoding != "" {
+		t.Errorf("expected small bodies to be sent uncompressed, got Content-Encoding %q", encoding)
+	}
+
+	if err := client.From("items").Delete().Eq("name", strings.Repeat("a", 100)).Execute(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if encoding != "" {
+		t.Errorf("expected requests without a payload to be sent uncompressed, got Content-Encoding %q", encoding)
+	}
+}
+
+func TestCompressedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			w.Write([]byte(`[{"id":1}]`))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write([]byte(`[{"id":1}]`))
+		zw.Close()
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL)
+	client := NewClient(*baseURL)
+	client.AddHeader("Accept-Encoding", "gzip")
+
+	var rows []map[string]int
+	if err := client.From("items").Select("id").Execute(&rows); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(rows) != 1 || rows[0]["id"] != 1 {
+		t.Errorf("expected the decompressed rows, got %v", rows)
+	}
+}
+
+// closeTracker records whether a response body was closed.
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCompressedResponseClosesBody(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(`[{"id":1}]`))
+	zw.Close()
+
+	for name, data := range map[string][]byte{"valid": compressed.Bytes(), "invalid": []byte("not gzip")} {
+		t.Run(name, func(t *testing.T) {
+			var body *closeTracker
+			baseURL, _ := url.Parse("http://localhost:3000")
+			client := NewClient(*baseURL)
+			client.Transport.Parent = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				body = &closeTracker{Reader: bytes.NewReader(data)}
+				header := http.Header{"Content-Encoding": {"gzip"}}
+				return &http.Response{StatusCode: http.StatusOK, Header: header, Body: body, Request: req}, nil
+			})
+
+			client.From("items").Select("id").Execute(nil)
+			if !body.closed {
+				t.Error("expected the response body to be closed")
+			}
+			client.Rpc("items", nil).Execute(nil)
+			if !body.closed {
+				t.Error("expected the RPC response body to be closed")
+			}
+		})
+	}
+}
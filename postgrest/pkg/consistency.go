@@ -0,0 +1,86 @@
+package postgrest_go
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithReadYourWrites sends the SELECT requests marked with UseReplica to the
+// primary for d after a write, so rows just written are read back instead of
+// missing from a lagging replica. Writes are INSERT, UPSERT, UPDATE and DELETE
+// requests and RPC calls other than GET. They are tracked per Authorization
+// header, so the users of a shared client are pinned separately. Requests whose
+// Authorization header is only set by the transport share a single pin, unless
+// WithPinKey tells their users apart.
+func WithReadYourWrites(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.pins = &primaryPins{duration: d, until: map[string]time.Time{}}
+	}
+}
+
+// WithPinKey sets how WithReadYourWrites tells apart the users of requests
+// without an Authorization header of their own, e.g. when the transport adds
+// the access token of the current session. key returns an identifier of the
+// user a request is sent for, given the context of the request.
+func WithPinKey(key func(ctx context.Context) string) ClientOption {
+	return func(c *Client) {
+		c.pinKey = key
+	}
+}
+
+// primaryPins tracks until when the reads of each Authorization header go to the primary.
+type primaryPins struct {
+	duration time.Duration
+	mu       sync.Mutex
+	until    map[string]time.Time
+}
+
+// pinOf returns the key of the pin of a request with the given Authorization header.
+func (c *Client) pinOf(ctx context.Context, authorization string) string {
+	if authorization != "" || c.pinKey == nil {
+		return "authorization:" + authorization
+	}
+	return "key:" + c.pinKey(ctx)
+}
+
+// recordWrite pins the reads of the user of a request to the primary,
+// dropping the pins that expired.
+func (c *Client) recordWrite(ctx context.Context, method, authorization string) {
+	if c.pins == nil || method == http.MethodGet || method == http.MethodHead {
+		return
+	}
+
+	now := time.Now()
+	c.pins.mu.Lock()
+	defer c.pins.mu.Unlock()
+	for key, until := range c.pins.until {
+		if now.After(until) {
+			delete(c.pins.until, key)
+		}
+	}
+	c.pins.until[c.pinOf(ctx, authorization)] = now.Add(c.pins.duration)
+}
+
+// pinnedToPrimary tells whether the reads of the user of a request go to the primary.
+func (c *Client) pinnedToPrimary(ctx context.Context, authorization string) bool {
+	if c.pins == nil {
+		return false
+	}
+
+	c.pins.mu.Lock()
+	defer c.pins.mu.Unlock()
+	until, ok := c.pins.until[c.pinOf(ctx, authorization)]
+	return ok && time.Now().Before(until)
+}
+
+// authorization returns the Authorization header the request will be sent with.
+func (b *QueryRequestBuilder) authorization() string {
+	if authorization := b.header.Get("Authorization"); authorization != "" {
+		return authorization
+	}
+	b.client.headersMu.RLock()
+	defer b.client.headersMu.RUnlock()
+	return b.client.defaultHeaders.Get("Authorization")
+}
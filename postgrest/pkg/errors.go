@@ -0,0 +1,108 @@
+package postgrest_go
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrFunctionNotFound matches errors of RPC calls to a function missing from the schema cache (PGRST202).
+	ErrFunctionNotFound = errors.New("function not found")
+	// ErrAmbiguousFunction matches errors of RPC calls matching several overloaded functions (PGRST203).
+	ErrAmbiguousFunction = errors.New("ambiguous function")
+	// ErrRelationNotFound matches errors of requests to a missing table or view (PGRST205, 42P01).
+	ErrRelationNotFound = errors.New("relation not found")
+	// ErrRaisedException matches errors raised by a function with RAISE EXCEPTION without an explicit SQLSTATE (P0001).
+	ErrRaisedException = errors.New("raised exception")
+)
+
+var errorsByCode = map[string]error{
+	"PGRST202": ErrFunctionNotFound,
+	"PGRST203": ErrAmbiguousFunction,
+	"PGRST205": ErrRelationNotFound,
+	"42P01":    ErrRelationNotFound,
+	"P0001":    ErrRaisedException,
+}
+
+// Is reports whether the target is the sentinel error of the error code, e.g.
+// errors.Is(err, ErrFunctionNotFound) for a missing RPC function.
+func (rq *RequestError) Is(target error) bool {
+	sentinel, ok := errorsByCode[rq.Code]
+	return ok && sentinel == target
+}
+
+// IsPostgrestError reports whether the error was raised by PostgREST itself,
+// e.g. when parsing the request or looking up the schema cache.
+func (rq *RequestError) IsPostgrestError() bool {
+	return strings.HasPrefix(rq.Code, "PGRST")
+}
+
+// IsDatabaseError reports whether the error was raised by Postgres while
+// executing the request, in which case Code is the SQLSTATE of the error.
+func (rq *RequestError) IsDatabaseError() bool {
+	return len(rq.Code) == 5 && !rq.IsPostgrestError()
+}
+
+// HTTPStatus returns the HTTP status code of the response.
+func (rq *RequestError) HTTPStatus() int {
+	return rq.HTTPStatusCode
+}
+
+// maxErrorMessage is the maximum number of bytes of a body that is not JSON
+// kept as the message of a RequestError.
+const maxErrorMessage = 512
+
+// decodeRequestError decodes the body of an error response. Bodies that are
+// not a JSON error, e.g. an HTML page of a proxy, are kept as the message.
+func (c *Client) decodeRequestError(status int, body []byte) *RequestError {
+	reqError := &RequestError{HTTPStatusCode: status}
+	if err := c.jsonDecode(body, reqError); err != nil {
+		if len(body) > maxErrorMessage {
+			body = body[:maxErrorMessage]
+		}
+		reqError = &RequestError{HTTPStatusCode: status, Message: strings.TrimSpace(string(body))}
+	}
+	return reqError
+}
+
+// DecodeError is returned when the body of a successful response cannot be
+// decoded into the result.
+type DecodeError struct {
+	Err error
+}
+
+func (err *DecodeError) Error() string {
+	return "decode response: " + err.Err.Error()
+}
+
+func (err *DecodeError) Unwrap() error {
+	return err.Err
+}
+
+// ResponseTooLargeError is returned when a response body exceeds the limit set with WithMaxResponseBytes.
+type ResponseTooLargeError struct {
+	Limit int64
+}
+
+func (err *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response body exceeds the limit of %d bytes", err.Limit)
+}
+
+// ReadLimited reads r until EOF like io.ReadAll, failing with a *ResponseTooLargeError
+// once more than limit bytes are read. A limit <= 0 disables the check.
+func ReadLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, &ResponseTooLargeError{Limit: limit}
+	}
+	return data, nil
+}
@@ -0,0 +1,98 @@
+package postgrest_go
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestRequestError_Is(t *testing.T) {
+	tests := []struct {
+		status   int
+		body     string
+		target   error
+		database bool
+	}{
+		{http.StatusNotFound, `{"code":"PGRST202","message":"Could not find the function public.missing without parameters in the schema cache"}`, ErrFunctionNotFound, false},
+		{http.StatusBadRequest, `{"code":"P0001","message":"insufficient funds"}`, ErrRaisedException, true},
+		{http.StatusNotFound, `{"code":"42P01","message":"relation \"public.missing\" does not exist"}`, ErrRelationNotFound, true},
+	}
+
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+			w.Write([]byte(test.body))
+		}))
+
+		baseURL, _ := url.Parse(server.URL + "/")
+		client := NewClient(*baseURL)
+
+		err := client.Rpc("example_function", nil).Execute(nil)
+		server.Close()
+
+		if !errors.Is(err, test.target) {
+			t.Errorf("expected error == %v, got %v", test.target, err)
+		}
+
+		var reqErr *RequestError
+		if !errors.As(err, &reqErr) {
+			t.Fatalf("expected *RequestError, got %T", err)
+		}
+		if reqErr.IsDatabaseError() != test.database {
+			t.Errorf("expected IsDatabaseError == %v for code %s, got %v", test.database, reqErr.Code, reqErr.IsDatabaseError())
+		}
+		if reqErr.IsPostgrestError() == test.database {
+			t.Errorf("expected IsPostgrestError == %v for code %s, got %v", !test.database, reqErr.Code, reqErr.IsPostgrestError())
+		}
+	}
+}
+
+func TestRequestError_NotJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html>502 Bad Gateway</html>\n"))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL)
+	client := NewClient(*baseURL)
+
+	err := client.From("items").Select("*").Execute(nil)
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) {
+		t.Fatalf("expected *RequestError, got %T", err)
+	}
+	if reqErr.HTTPStatus() != http.StatusBadGateway {
+		t.Errorf("expected HTTPStatus == %d, got %d", http.StatusBadGateway, reqErr.HTTPStatus())
+	}
+	if reqErr.Message != "<html>502 Bad Gateway</html>" {
+		t.Errorf("expected the body as message, got %q", reqErr.Message)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"not a number"}]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL)
+	client := NewClient(*baseURL)
+
+	var rows []struct {
+		ID int `json:"id"`
+	}
+	err := client.From("items").Select("id").Execute(&rows)
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("expected *DecodeError, got %T", err)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Errorf("expected the DecodeError to wrap *json.UnmarshalTypeError, got %v", decodeErr.Err)
+	}
+}
@@ -0,0 +1,221 @@
+package postgrest_go
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ExportFormat is the format of the rows written by ExportTable.
+type ExportFormat string
+
+const (
+	ExportFormatCSV    ExportFormat = "csv"
+	ExportFormatNDJSON ExportFormat = "ndjson"
+)
+
+// ExportOptions controls ExportTable. Zero values use the defaults.
+type ExportOptions struct {
+	// BatchSize is the number of rows fetched per request, 1000 by default
+	BatchSize int
+	// KeyColumn is a unique, non-null column the rows are paged by, "id" by default
+	KeyColumn string
+	// Columns are the exported columns, all columns by default. They are also
+	// the header of CSV exports, which otherwise follows the first row. The key
+	// column is fetched for the pagination even if it is not one of them.
+	Columns []string
+}
+
+// ExportTable writes all rows of a table to w in the given format, one row per
+// line for NDJSON. The rows are fetched in batches with keyset pagination on
+// opts.KeyColumn, which unlike offsets stays fast on large tables and does not
+// skip rows inserted during the export. Only one batch is held in memory.
+//
+// In CSV exports, null values are written as empty fields and JSON objects and
+// arrays as their JSON text.
+func (c *Client) ExportTable(ctx context.Context, table string, w io.Writer, format ExportFormat, opts ExportOptions) (int64, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	keyColumn := opts.KeyColumn
+	if keyColumn == "" {
+		keyColumn = "id"
+	}
+	selectColumns := []string{"*"}
+	dropKey := false
+	if len(opts.Columns) > 0 {
+		selectColumns = opts.Columns
+		if !containsString(opts.Columns, keyColumn) {
+			// the key column is needed to fetch the next batch, but only
+			// exported when asked for
+			selectColumns = append(append([]string{}, opts.Columns...), keyColumn)
+			dropKey = true
+		}
+	}
+	header := opts.Columns
+
+	var csvWriter *csv.Writer
+	switch format {
+	case ExportFormatCSV:
+		csvWriter = csv.NewWriter(w)
+	case ExportFormatNDJSON:
+	default:
+		return 0, fmt.Errorf("unsupported export format: %q", format)
+	}
+
+	var (
+		exported int64
+		lastKey  string
+	)
+	for {
+		query := c.From(table).Select(selectColumns...)
+		query.OrderBy(keyColumn, "asc").Limit(batchSize)
+		if exported > 0 {
+			query.Gt(keyColumn, lastKey)
+		}
+
+		var rows []json.RawMessage
+		if err := query.ExecuteWithContext(ctx, &rows); err != nil {
+			return exported, err
+		}
+
+		for _, row := range rows {
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(row, &fields); err != nil {
+				return exported, err
+			}
+
+			key, ok := fields[keyColumn]
+			if !ok || string(key) == "null" {
+				return exported, fmt.Errorf("row without a value for the key column %q", keyColumn)
+			}
+			lastKey = rawString(key)
+
+			if csvWriter == nil {
+				if dropKey {
+					var err error
+					if row, err = withoutKey(row, keyColumn); err != nil {
+						return exported, err
+					}
+				}
+				if _, err := fmt.Fprintf(w, "%s\n", row); err != nil {
+					return exported, err
+				}
+				exported++
+				continue
+			}
+
+			if exported == 0 {
+				if len(header) == 0 {
+					var err error
+					if header, err = objectKeys(row); err != nil {
+						return exported, err
+					}
+				}
+				if err := csvWriter.Write(header); err != nil {
+					return exported, err
+				}
+			}
+			record := make([]string, len(header))
+			for i, column := range header {
+				if value, ok := fields[column]; ok && string(value) != "null" {
+					record[i] = rawString(value)
+				}
+			}
+			if err := csvWriter.Write(record); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return exported, err
+			}
+		}
+		if len(rows) < batchSize {
+			return exported, nil
+		}
+	}
+}
+
+// rawString returns JSON strings unquoted and other values as their JSON text.
+func rawString(value json.RawMessage) string {
+	var s string
+	if len(value) > 0 && value[0] == '"' && json.Unmarshal(value, &s) == nil {
+		return s
+	}
+	return string(value)
+}
+
+// objectKeys returns the keys of a JSON object in the order they appear.
+func objectKeys(data []byte) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key.(string))
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// withoutKey returns a JSON object without the given key, keeping the order of
+// the other keys.
+func withoutKey(data []byte, without string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		if key == without {
+			continue
+		}
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
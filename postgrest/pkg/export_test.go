@@ -0,0 +1,90 @@
+package postgrest_go
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClient_ExportTable(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		switch r.URL.Query().Get("id") {
+		case "":
+			w.Write([]byte(`[{"id":1,"name":"a, b","tags":["x"]},{"id":2,"name":null,"tags":[]}]`))
+		case "gt.2":
+			w.Write([]byte(`[{"id":3,"name":"c","tags":null}]`))
+		default:
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	var buf bytes.Buffer
+	n, err := client.ExportTable(context.Background(), "items", &buf, ExportFormatCSV, ExportOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected exported rows == 3, got %d", n)
+	}
+
+	expected := "id,name,tags\n1,\"a, b\",\"[\"\"x\"\"]\"\n2,,[]\n3,c,\n"
+	if buf.String() != expected {
+		t.Errorf("expected CSV == %q, got %q", expected, buf.String())
+	}
+	if len(queries) != 2 || queries[0] != "order=id.asc&select=*" {
+		t.Errorf("expected keyset paginated queries, got %v", queries)
+	}
+}
+
+func TestClient_ExportTableColumnsWithoutKey(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		switch r.URL.Query().Get("id") {
+		case "":
+			w.Write([]byte(`[{"name":"a","email":"a@example.com","id":1},{"name":"b","email":null,"id":2}]`))
+		case "gt.2":
+			w.Write([]byte(`[{"name":"c","email":"c@example.com","id":3}]`))
+		default:
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+	opts := ExportOptions{BatchSize: 2, Columns: []string{"name", "email"}}
+
+	var buf bytes.Buffer
+	n, err := client.ExportTable(context.Background(), "users", &buf, ExportFormatCSV, opts)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected exported rows == 3, got %d", n)
+	}
+	if expected := "name,email\na,a@example.com\nb,\nc,c@example.com\n"; buf.String() != expected {
+		t.Errorf("expected CSV == %q, got %q", expected, buf.String())
+	}
+	if len(queries) != 2 || queries[0] != "order=id.asc&select=name,email,id" {
+		t.Errorf("expected the key column to be selected, got %v", queries)
+	}
+
+	buf.Reset()
+	if _, err := client.ExportTable(context.Background(), "users", &buf, ExportFormatNDJSON, opts); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expected := "{\"name\":\"a\",\"email\":\"a@example.com\"}\n{\"name\":\"b\",\"email\":null}\n{\"name\":\"c\",\"email\":\"c@example.com\"}\n"
+	if buf.String() != expected {
+		t.Errorf("expected NDJSON without the key column == %q, got %q", expected, buf.String())
+	}
+}
@@ -3,6 +3,7 @@ package postgrest_go
 import (
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -81,6 +82,24 @@ func TestFilterRequestBuilder_Filter(t *testing.T) {
 	}
 }
 
+func TestFilterRequestBuilder_EmbeddedColumn(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"})
+
+	builder := client.From("posts").Select("*, author(name)")
+	builder.FilterRaw("author.name", "eq", "x")
+	builder.FilterRaw("id::text", "gt", "1")
+
+	if got := builder.params.Get("author.name"); got != "eq.x" {
+		t.Errorf("expected http param author.name == eq.x, got %s", got)
+	}
+	if got := builder.params.Get("id::text"); got != "gt.1" {
+		t.Errorf("expected http param id::text == gt.1, got %s", got)
+	}
+	if got := encodeQuery(builder.params); !strings.Contains(got, "author.name=eq.x") {
+		t.Errorf("expected query to contain author.name=eq.x, got %s", got)
+	}
+}
+
 func TestFilterRequestBuilder_MultivaluedParam(t *testing.T) {
 	client := NewClient(url.URL{Scheme: "https", Host: "example.com"})
 
@@ -107,3 +126,23 @@ func TestFilterRequestBuilder_MultivaluedParam(t *testing.T) {
 		t.Errorf("expected http params.Encode() == %s, got %s", want, got)
 	}
 }
+
+func TestFilterRequestBuilder_Clone(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"})
+
+	base := client.From("example_table").Select("*").Eq("tenant", "a")
+	first := base.Clone()
+	first.Eq("id", "1")
+	second := base.Clone()
+	second.Eq("id", "2")
+
+	if got := base.params.Encode(); got != "select=%2A&tenant=eq.a" {
+		t.Errorf("expected base params.Encode() == %s, got %s", "select=%2A&tenant=eq.a", got)
+	}
+	if got := first.params.Get("id"); got != "eq.1" {
+		t.Errorf("expected http param id == %s, got %s", "eq.1", got)
+	}
+	if got := second.params.Get("id"); got != "eq.2" {
+		t.Errorf("expected http param id == %s, got %s", "eq.2", got)
+	}
+}
@@ -0,0 +1,169 @@
+package postgrest_go
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ImportOptions controls ImportNDJSON. Zero values use the defaults.
+type ImportOptions struct {
+	// BatchSize is the number of rows sent per request, 500 by default
+	BatchSize int
+	// Upsert merges rows conflicting on OnConflict (the primary key if empty) instead of failing
+	Upsert     bool
+	OnConflict []string
+	// ContinueOnError keeps importing the next batches when a batch fails,
+	// the failures are reported in ImportResult.Errors
+	ContinueOnError bool
+	// Progress is called after each batch
+	Progress func(ImportProgress)
+	// MaxLineBytes is the maximum size of a row, 1 MiB by default
+	MaxLineBytes int
+}
+
+// ImportProgress reports the progress of ImportNDJSON.
+type ImportProgress struct {
+	Batches  int
+	Imported int64
+	Failed   int64
+}
+
+// ImportResult is the outcome of ImportNDJSON.
+type ImportResult struct {
+	ImportProgress
+	Errors []*ImportBatchError
+}
+
+// ImportBatchError is the error of a batch of ImportNDJSON.
+type ImportBatchError struct {
+	Batch int
+	// FirstLine and LastLine are the 1-based lines of the rows of the batch
+	FirstLine int
+	LastLine  int
+	Err       error
+}
+
+func (err *ImportBatchError) Error() string {
+	return fmt.Sprintf("batch %d (lines %d-%d): %v", err.Batch, err.FirstLine, err.LastLine, err.Err)
+}
+
+func (err *ImportBatchError) Unwrap() error {
+	return err.Err
+}
+
+// ImportNDJSON inserts the rows of r, one JSON object per line, into the table in
+// batches of opts.BatchSize. Only one batch is held in memory and the next one is
+// read once the previous is stored, so large files are streamed with backpressure.
+// Rows may have different keys, missing columns are set to their default. Blank
+// lines are skipped.
+func (c *Client) ImportNDJSON(ctx context.Context, table string, r io.Reader, opts ImportOptions) (ImportResult, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	maxLineBytes := opts.MaxLineBytes
+	if maxLineBytes <= 0 {
+		maxLineBytes = 1024 * 1024
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+
+	var (
+		result    ImportResult
+		batch     []json.RawMessage
+		line      int
+		firstLine int
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		result.Batches++
+		err := c.importBatch(ctx, table, batch, opts)
+		if err != nil {
+			batchErr := &ImportBatchError{Batch: result.Batches, FirstLine: firstLine, LastLine: line, Err: err}
+			result.Failed += int64(len(batch))
+			result.Errors = append(result.Errors, batchErr)
+			if !opts.ContinueOnError || ctx.Err() != nil {
+				return batchErr
+			}
+		} else {
+			result.Imported += int64(len(batch))
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(result.ImportProgress)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for scanner.Scan() {
+		line++
+		row := bytes.TrimSpace(scanner.Bytes())
+		if len(row) == 0 {
+			continue
+		}
+		if !json.Valid(row) || row[0] != '{' {
+			return result, fmt.Errorf("line %d: invalid JSON object", line)
+		}
+
+		if len(batch) == 0 {
+			firstLine = line
+		}
+		batch = append(batch, append(json.RawMessage(nil), row...))
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+	return result, flush()
+}
+
+// importBatch inserts a batch of rows, listing the union of their keys in the
+// columns parameter so rows with different keys can be inserted together.
+func (c *Client) importBatch(ctx context.Context, table string, batch []json.RawMessage, opts ImportOptions) error {
+	keys := map[string]bool{}
+	for _, row := range batch {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(row, &fields); err != nil {
+			return err
+		}
+		for key := range fields {
+			keys[key] = true
+		}
+	}
+	columns := make([]string, 0, len(keys))
+	for key := range keys {
+		columns = append(columns, key)
+	}
+	sort.Strings(columns)
+
+	var builder *QueryRequestBuilder
+	if opts.Upsert {
+		builder = c.From(table).Upsert(batch)
+		if len(opts.OnConflict) > 0 {
+			builder.OnConflict(opts.OnConflict...)
+		}
+	} else {
+		builder = c.From(table).Insert(batch)
+	}
+	builder.header.Set("Prefer", "return=minimal")
+	if opts.Upsert {
+		builder.addPreference("resolution=merge-duplicates")
+	}
+	builder.addPreference("missing=default")
+
+	return builder.Columns(columns...).ExecuteWithContext(ctx, nil)
+}
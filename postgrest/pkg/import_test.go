@@ -0,0 +1,72 @@
+package postgrest_go
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestClient_ImportNDJSON(t *testing.T) {
+	var batches [][]map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("columns"); got != "id,name" && got != "id,tag" {
+			t.Errorf("expected param columns to list the keys of the batch, got %s", got)
+		}
+		var rows []map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&rows); err != nil {
+			t.Errorf("expected a JSON array body, got %v", err)
+		}
+		batches = append(batches, rows)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	input := strings.NewReader("{\"id\":1,\"name\":\"a\"}\n{\"id\":2}\n\n{\"id\":3,\"tag\":\"x\"}\n")
+	var progress []ImportProgress
+	result, err := client.ImportNDJSON(context.Background(), "example_table", input, ImportOptions{
+		BatchSize: 2,
+		Progress:  func(p ImportProgress) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Imported != 3 || result.Batches != 2 {
+		t.Errorf("expected 3 rows imported in 2 batches, got %d rows in %d batches", result.Imported, result.Batches)
+	}
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Errorf("expected batches of 2 and 1 rows, got %v", batches)
+	}
+	if len(progress) != 2 || progress[1].Imported != 3 {
+		t.Errorf("expected progress after each batch, got %v", progress)
+	}
+}
+
+func TestClient_ImportNDJSONBatchError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"23505","message":"duplicate key value violates unique constraint"}`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	input := strings.NewReader("{\"id\":1}\n{\"id\":1}\n{\"id\":2}\n")
+	result, err := client.ImportNDJSON(context.Background(), "example_table", input, ImportOptions{BatchSize: 2, ContinueOnError: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.Failed != 3 || len(result.Errors) != 2 {
+		t.Fatalf("expected 3 failed rows in 2 batch errors, got %d rows in %d errors", result.Failed, len(result.Errors))
+	}
+	if got := result.Errors[1]; got.FirstLine != 3 || got.LastLine != 3 {
+		t.Errorf("expected the second batch to cover line 3, got lines %d-%d", got.FirstLine, got.LastLine)
+	}
+}
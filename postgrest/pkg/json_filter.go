@@ -0,0 +1,63 @@
+package postgrest_go
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// JSONPath builds a path to a key of a json or jsonb column, e.g. JSONPath("metadata", "address", "city")
+// returns metadata->address->>city. The last key is extracted as text so it can be compared to string values.
+func JSONPath(column string, keys ...string) string {
+	if len(keys) == 0 {
+		return column
+	}
+
+	var sb strings.Builder
+	sb.WriteString(column)
+	for i, key := range keys {
+		if i == len(keys)-1 {
+			sb.WriteString("->>")
+		} else {
+			sb.WriteString("->")
+		}
+		sb.WriteString(key)
+	}
+	return sb.String()
+}
+
+// FilterJSON adds a filter condition on a path of a json or jsonb column, such as metadata->>tier, to the request.
+// Unlike Filter, the path is used as is so the -> and ->> operators are never quoted.
+func (b *FilterRequestBuilder) FilterJSON(path, operator, criteria string) *FilterRequestBuilder {
+	return b.addFilter(path, operator, criteria)
+}
+
+// EqJSON adds an equality filter condition on a path of a json or jsonb column to the request.
+func (b *FilterRequestBuilder) EqJSON(path, value string) *FilterRequestBuilder {
+	return b.FilterJSON(path, "eq", SanitizeParam(value))
+}
+
+// NeqJSON adds a not-equal filter condition on a path of a json or jsonb column to the request.
+func (b *FilterRequestBuilder) NeqJSON(path, value string) *FilterRequestBuilder {
+	return b.FilterJSON(path, "neq", SanitizeParam(value))
+}
+
+// ContainsJSON adds a filter condition matching jsonb columns or paths containing the JSON encoding of value.
+func (b *FilterRequestBuilder) ContainsJSON(path string, value interface{}) *FilterRequestBuilder {
+	return b.jsonContainment(path, "cs", value)
+}
+
+// ContainedByJSON adds a filter condition matching jsonb columns or paths contained by the JSON encoding of value.
+func (b *FilterRequestBuilder) ContainedByJSON(path string, value interface{}) *FilterRequestBuilder {
+	return b.jsonContainment(path, "cd", value)
+}
+
+func (b *FilterRequestBuilder) jsonContainment(path, operator string, value interface{}) *FilterRequestBuilder {
+	data, err := json.Marshal(value)
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+		return b
+	}
+	return b.FilterJSON(path, operator, string(data))
+}
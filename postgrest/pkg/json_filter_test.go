@@ -0,0 +1,42 @@
+package postgrest_go
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	if got := JSONPath("metadata", "address", "city"); got != "metadata->address->>city" {
+		t.Errorf("expected path == %s, got %s", "metadata->address->>city", got)
+	}
+	if got := JSONPath("metadata"); got != "metadata" {
+		t.Errorf("expected path == %s, got %s", "metadata", got)
+	}
+}
+
+func TestFilterRequestBuilder_JSONFilters(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"})
+
+	builder := client.From("accounts").Select("*").
+		EqJSON("metadata->>tier", "pro").
+		ContainsJSON("attrs", map[string]interface{}{"beta": true})
+
+	if got := builder.params.Get("metadata->>tier"); got != "eq.pro" {
+		t.Errorf("expected http param metadata->>tier == %s, got %s", "eq.pro", got)
+	}
+	if got := builder.params.Get("attrs"); got != `cs.{"beta":true}` {
+		t.Errorf("expected http param attrs == %s, got %s", `cs.{"beta":true}`, got)
+	}
+}
+
+func TestFilterRequestBuilder_ContainsJSONError(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"})
+
+	err := client.From("accounts").Select("*").
+		ContainsJSON("attrs", map[string]interface{}{"invalid": func() {}}).
+		Execute(nil)
+
+	if err == nil {
+		t.Errorf("expected an error for a value that cannot be encoded")
+	}
+}
@@ -0,0 +1,252 @@
+package postgrest_go
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy maps the name of a struct field without a json tag to a column name.
+type NamingStrategy func(field string) string
+
+// SnakeCase maps CamelCase field names to snake_case column names, e.g. CreatedAt to created_at and UserID to user_id.
+func SnakeCase(field string) string {
+	runes := []rune(field)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// start a new word on a lower to upper transition and at the end of an acronym
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// WithNamingStrategy applies a naming strategy to the fields without a json tag
+// of the structs sent and received by the client, so that e.g. a CreatedAt field
+// maps to a created_at column without a tag. Only the fields of the row structs
+// are renamed; nested values such as jsonb columns are encoded as is.
+func WithNamingStrategy(naming NamingStrategy) ClientOption {
+	return func(c *Client) {
+		c.naming = naming
+	}
+}
+
+// marshal encodes a request body, applying the naming strategy if set.
+func (c *Client) marshal(v interface{}) ([]byte, error) {
+	if c.naming == nil {
+		return c.jsonEncode(v)
+	}
+	return c.jsonEncode(renameFields(reflect.ValueOf(v), c.naming))
+}
+
+// Marshal encodes v like the request bodies of the client, with its JSON
+// codec and naming strategy.
+func (c *Client) Marshal(v interface{}) ([]byte, error) {
+	return c.marshal(v)
+}
+
+// ColumnName returns the column a struct field is sent as, named by its json
+// tag or the naming strategy of the client. It is empty for the fields left
+// out of the rows and for embedded structs, whose fields are promoted.
+func (c *Client) ColumnName(field reflect.StructField) string {
+	naming := c.naming
+	if naming == nil {
+		naming = func(field string) string { return field }
+	}
+	name, _, _ := columnName(field, naming)
+	return name
+}
+
+// unmarshal decodes a response body into v, applying the naming strategy if
+// set. Numbers decoded into interface{} values are json.Number if useNumber.
+func (c *Client) unmarshal(data []byte, v interface{}, useNumber bool) error {
+	if c.naming == nil {
+		return c.decode(data, v, useNumber)
+	}
+
+	fields := fieldsByColumn(rowType(reflect.TypeOf(v)), c.naming)
+	if len(fields) == 0 {
+		return c.decode(data, v, useNumber)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+
+	switch value := raw.(type) {
+	case map[string]interface{}:
+		raw = renameKeys(value, fields)
+	case []interface{}:
+		for i, row := range value {
+			if row, ok := row.(map[string]interface{}); ok {
+				value[i] = renameKeys(row, fields)
+			}
+		}
+	}
+
+	renamed, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return c.decode(renamed, v, useNumber)
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// renameFields converts structs, and slices of structs, into maps keyed by column name.
+func renameFields(v reflect.Value, naming NamingStrategy) interface{} {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch {
+	case !v.IsValid():
+		return nil
+	case v.Type().Implements(jsonMarshalerType):
+		return v.Interface()
+	case v.Kind() == reflect.Struct:
+		row := map[string]interface{}{}
+		addFields(row, v, naming)
+		return row
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8, v.Kind() == reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		rows := make([]interface{}, v.Len())
+		for i := range rows {
+			rows[i] = renameFields(v.Index(i), naming)
+		}
+		return rows
+	}
+	return v.Interface()
+}
+
+// addFields adds the exported fields of a struct to row, flattening embedded structs.
+func addFields(row map[string]interface{}, v reflect.Value, naming NamingStrategy) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitEmpty, ok := columnName(field, naming)
+		if !ok {
+			continue
+		}
+
+		value := v.Field(i)
+		if field.Anonymous && name == "" {
+			for value.Kind() == reflect.Pointer {
+				if value.IsNil() {
+					break
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				addFields(row, value, naming)
+			}
+			continue
+		}
+
+		if omitEmpty && value.IsZero() {
+			continue
+		}
+		row[name] = value.Interface()
+	}
+}
+
+// columnName returns the column of a struct field. The name is empty for
+// embedded structs without a json tag, whose fields are promoted.
+func columnName(field reflect.StructField, naming NamingStrategy) (name string, omitEmpty bool, ok bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	omitEmpty = strings.Contains(","+opts+",", ",omitempty,")
+	if field.Anonymous && name == "" {
+		t := field.Type
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			return "", omitEmpty, true
+		}
+	}
+	if !field.IsExported() {
+		return "", false, false
+	}
+	if name == "" {
+		name = naming(field.Name)
+	}
+	return name, omitEmpty, true
+}
+
+// rowType returns the struct type of the rows decoded into a value of type t.
+func rowType(t reflect.Type) reflect.Type {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || reflect.PointerTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) {
+		return nil
+	}
+	return t
+}
+
+// fieldsByColumn maps the columns of untagged struct fields to the field names.
+func fieldsByColumn(t reflect.Type, naming NamingStrategy) map[string]string {
+	if t == nil {
+		return nil
+	}
+
+	fields := map[string]string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" {
+			continue
+		}
+
+		if field.Anonymous {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for column, name := range fieldsByColumn(embedded, naming) {
+					fields[column] = name
+				}
+				continue
+			}
+		}
+
+		if field.IsExported() {
+			fields[naming(field.Name)] = field.Name
+		}
+	}
+	return fields
+}
+
+// renameKeys renames the columns of a row to the names of their struct fields.
+func renameKeys(row map[string]interface{}, fields map[string]string) map[string]interface{} {
+	renamed := make(map[string]interface{}, len(row))
+	for key, value := range row {
+		if name, ok := fields[key]; ok {
+			key = name
+		}
+		renamed[key] = value
+	}
+	return renamed
+}
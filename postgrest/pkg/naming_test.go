@@ -0,0 +1,124 @@
+package postgrest_go
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"Name":       "name",
+		"CreatedAt":  "created_at",
+		"UserID":     "user_id",
+		"HTTPStatus": "http_status",
+		"Address2":   "address2",
+		"already_ok": "already_ok",
+	}
+
+	for field, want := range tests {
+		if got := SnakeCase(field); got != want {
+			t.Errorf("expected SnakeCase(%s) == %s, got %s", field, want, got)
+		}
+	}
+}
+
+type namingBase struct {
+	ID int64
+}
+
+type namingRow struct {
+	namingBase
+	FirstName string
+	CreatedAt time.Time
+	Nickname  string                 `json:"nick"`
+	Note      string                 `json:",omitempty"`
+	Attrs     map[string]interface{} `json:"attrs"`
+	Secret    string                 `json:"-"`
+}
+
+func TestNamingStrategy_Marshal(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"}, WithNamingStrategy(SnakeCase))
+
+	data, err := client.marshal([]namingRow{{
+		namingBase: namingBase{ID: 1},
+		FirstName:  "Ada",
+		Nickname:   "ada",
+		Attrs:      map[string]interface{}{"FavoriteColor": "red"},
+		Secret:     "s3cr3t",
+	}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var got []map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := []string{"id", "first_name", "created_at", "nick", "attrs"}
+	for _, column := range want {
+		if _, ok := got[0][column]; !ok {
+			t.Errorf("expected column %s in %s", column, data)
+		}
+	}
+	if len(got[0]) != len(want) {
+		t.Errorf("expected %d columns, got %s", len(want), data)
+	}
+	if _, ok := got[0]["attrs"].(map[string]interface{})["FavoriteColor"]; !ok {
+		t.Errorf("expected nested keys to be kept as is, got %s", data)
+	}
+}
+
+func TestNamingStrategy_Unmarshal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":1,"first_name":"Ada","nick":"ada","note":"n","created_at":"2024-01-02T03:04:05Z"}]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL, WithNamingStrategy(SnakeCase))
+
+	var rows []namingRow
+	if err := client.From("people").Select("*").Execute(&rows); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(rows) != 1 {
+		t.Fatalf("expected len(rows) == %d, got %d", 1, len(rows))
+	}
+	row := rows[0]
+	if row.ID != 1 || row.FirstName != "Ada" || row.Nickname != "ada" || row.Note != "n" || row.CreatedAt.Year() != 2024 {
+		t.Errorf("unexpected row %+v", row)
+	}
+}
+
+func TestNamingStrategy_ColumnName(t *testing.T) {
+	rowType := reflect.TypeOf(namingRow{})
+	tests := map[string]struct {
+		naming string
+		plain  string
+	}{
+		"FirstName":  {"first_name", "FirstName"},
+		"Nickname":   {"nick", "nick"},
+		"Note":       {"note", "Note"},
+		"Secret":     {"", ""},
+		"namingBase": {"", ""},
+	}
+
+	named := NewClient(url.URL{Scheme: "https", Host: "example.com"}, WithNamingStrategy(SnakeCase))
+	plain := NewClient(url.URL{Scheme: "https", Host: "example.com"})
+	for name, want := range tests {
+		field, _ := rowType.FieldByName(name)
+		if got := named.ColumnName(field); got != want.naming {
+			t.Errorf("expected the column of %s to be %q with the naming strategy, got %q", name, want.naming, got)
+		}
+		if got := plain.ColumnName(field); got != want.plain {
+			t.Errorf("expected the column of %s to be %q, got %q", name, want.plain, got)
+		}
+	}
+}
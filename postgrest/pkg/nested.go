@@ -0,0 +1,110 @@
+package postgrest_go
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Returning sets the columns of the rows returned by an INSERT, UPSERT,
+// UPDATE or DELETE request. Related tables can be embedded like in a SELECT
+// request, e.g. Returning("*", "items(*)") returns the inserted orders with
+// their items.
+func (b *QueryRequestBuilder) Returning(columns ...string) *QueryRequestBuilder {
+	b.params.Set("select", strings.Join(columns, ","))
+	return b
+}
+
+// NestedInsert is a row and the rows of related tables referencing it,
+// inserted with InsertNested.
+type NestedInsert struct {
+	Table string
+	Row   interface{}
+	// Key is the column of the row referenced by the related rows, id by default
+	Key      string
+	Children []NestedRows
+}
+
+// NestedRows are the rows of a related table referencing the row of a NestedInsert.
+type NestedRows struct {
+	Table string
+	// ForeignKey is the column of the rows set to the key of the parent row
+	ForeignKey string
+	// Rows is a slice of rows, encoded like the payload of Insert
+	Rows interface{}
+}
+
+// InsertNested inserts a row and the rows of related tables referencing it,
+// then decodes the row with the related rows embedded into out, e.g.
+//
+//	err := client.InsertNested(ctx, postgrest.NestedInsert{
+//		Table: "orders",
+//		Row:   Order{Customer: "alice"},
+//		Children: []postgrest.NestedRows{
+//			{Table: "items", ForeignKey: "order_id", Rows: []Item{{Sku: "a"}, {Sku: "b"}}},
+//		},
+//	}, &order)
+//
+// PostgREST writes to a single table per request, so the parent and each
+// related table are inserted by separate requests and the inserts are not
+// atomic: if a related insert fails, the rows inserted before are kept. Use a
+// database function called with Rpc when the rows must be inserted in one
+// transaction.
+func (c *Client) InsertNested(ctx context.Context, insert NestedInsert, out interface{}) error {
+	key := insert.Key
+	if key == "" {
+		key = "id"
+	}
+
+	var parents []map[string]json.RawMessage
+	if err := c.From(insert.Table).Insert(insert.Row).Returning(key).ExecuteWithContext(ctx, &parents); err != nil {
+		return err
+	}
+	if len(parents) != 1 {
+		return fmt.Errorf("expected 1 inserted row in %s, got %d", insert.Table, len(parents))
+	}
+	keyValue, ok := parents[0][key]
+	if !ok {
+		return fmt.Errorf("inserted row in %s has no column %s", insert.Table, key)
+	}
+
+	embedded := []string{"*"}
+	for _, children := range insert.Children {
+		rows, err := c.nestedRows(children, keyValue)
+		if err != nil {
+			return err
+		}
+		if len(rows) > 0 {
+			builder := c.From(children.Table).Insert(rows)
+			builder.header.Set("Prefer", "return=minimal")
+			if err := builder.ExecuteWithContext(ctx, nil); err != nil {
+				return fmt.Errorf("insert into %s: %w", children.Table, err)
+			}
+		}
+		embedded = append(embedded, children.Table+"(*)")
+	}
+
+	if out == nil {
+		return nil
+	}
+	return c.From(insert.Table).Select(embedded...).Single().
+		Eq(key, rawString(keyValue)).ExecuteWithContext(ctx, out)
+}
+
+// nestedRows encodes related rows, setting their foreign key to the key of the parent row.
+func (c *Client) nestedRows(children NestedRows, key json.RawMessage) ([]map[string]json.RawMessage, error) {
+	data, err := c.marshal(children.Rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("rows of %s must be a slice of objects: %w", children.Table, err)
+	}
+	for _, row := range rows {
+		row[children.ForeignKey] = key
+	}
+	return rows, nil
+}
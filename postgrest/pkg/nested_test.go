@@ -0,0 +1,81 @@
+package postgrest_go
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestQueryRequestBuilder_Returning(t *testing.T) {
+	baseURL, _ := url.Parse("http://localhost/")
+	client := NewClient(*baseURL)
+
+	req, err := client.From("orders").Insert(map[string]interface{}{"customer": "alice"}).Returning("*", "items(*)").Build()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := req.URL.Query().Get("select"); got != "*,items(*)" {
+		t.Errorf("expected param select to be *,items(*), got %s", got)
+	}
+}
+
+func TestClient_InsertNested(t *testing.T) {
+	var itemRows []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/orders":
+			if got := r.URL.Query().Get("select"); got != "id" {
+				t.Errorf("expected the parent insert to return the key, got select %s", got)
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`[{"id":7}]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/items":
+			json.NewDecoder(r.Body).Decode(&itemRows)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && r.URL.Path == "/orders":
+			if got := r.URL.Query().Get("select"); got != "*,items(*)" {
+				t.Errorf("expected the order to be selected with its items, got select %s", got)
+			}
+			if got := r.URL.Query().Get("id"); got != "eq.7" {
+				t.Errorf("expected the inserted order to be selected, got id %s", got)
+			}
+			w.Write([]byte(`{"id":7,"customer":"alice","items":[{"sku":"a","order_id":7},{"sku":"b","order_id":7}]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	type item struct {
+		Sku     string `json:"sku"`
+		OrderID int    `json:"order_id,omitempty"`
+	}
+	var order struct {
+		ID       int    `json:"id"`
+		Customer string `json:"customer"`
+		Items    []item `json:"items"`
+	}
+	err := client.InsertNested(context.Background(), NestedInsert{
+		Table: "orders",
+		Row:   map[string]interface{}{"customer": "alice"},
+		Children: []NestedRows{
+			{Table: "items", ForeignKey: "order_id", Rows: []item{{Sku: "a"}, {Sku: "b"}}},
+		},
+	}, &order)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(itemRows) != 2 || itemRows[0]["order_id"] != float64(7) || itemRows[1]["sku"] != "b" {
+		t.Errorf("expected the items to reference the order, got %v", itemRows)
+	}
+	if order.ID != 7 || len(order.Items) != 2 {
+		t.Errorf("expected the order with its items, got %+v", order)
+	}
+}
@@ -0,0 +1,81 @@
+package postgrest_go
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DefaultParallelism is the number of queries Parallel runs at the same time.
+const DefaultParallelism = 4
+
+// Executor is a request builder that can be executed with a context, e.g. a
+// *SelectRequestBuilder, *QueryRequestBuilder or *RpcRequestBuilder.
+type Executor interface {
+	ExecuteWithContext(ctx context.Context, r interface{}) error
+}
+
+// Query is a request and the value its result is decoded into, run with Parallel.
+type Query struct {
+	builder Executor
+	out     interface{}
+}
+
+// NewQuery returns a query executing builder and decoding its result into out.
+func NewQuery[T any](builder Executor, out *T) Query {
+	return Query{builder: builder, out: out}
+}
+
+// Parallel runs independent queries concurrently, at most DefaultParallelism
+// at a time, and decodes each result into the value of its query. The first
+// error cancels the context of the other queries and is returned once all of
+// them stopped.
+func Parallel(ctx context.Context, queries ...Query) error {
+	return ParallelWithLimit(ctx, DefaultParallelism, queries...)
+}
+
+// ParallelWithLimit is like Parallel, running at most limit queries at a time.
+// A limit of zero or less runs all queries at once.
+func ParallelWithLimit(ctx context.Context, limit int, queries ...Query) error {
+	if limit <= 0 || limit > len(queries) {
+		limit = len(queries)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		slots    = make(chan struct{}, limit)
+	)
+	for i, query := range queries {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, query Query) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if err := query.builder.ExecuteWithContext(ctx, query.out); err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("query %d: %w", i, err)
+					cancel()
+				})
+			}
+		}(i, query)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,96 @@
+package postgrest_go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallel(t *testing.T) {
+	var running, maxRunning int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		switch r.URL.Path {
+		case "/users":
+			w.Write([]byte(`[{"id":1},{"id":2}]`))
+		case "/rpc/total":
+			w.Write([]byte(`42`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	var (
+		users  []struct{ ID int }
+		total  int
+		orders []map[string]interface{}
+		posts  []map[string]interface{}
+	)
+	err := ParallelWithLimit(context.Background(), 2,
+		NewQuery(client.From("users").Select("id"), &users),
+		NewQuery(client.Rpc("total", nil), &total),
+		NewQuery(client.From("orders").Select("*"), &orders),
+		NewQuery(client.From("posts").Select("*"), &posts),
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(users) != 2 || total != 42 || orders == nil || posts == nil {
+		t.Errorf("expected the results of all queries, got %v %v %v %v", users, total, orders, posts)
+	}
+	if maxRunning > 2 {
+		t.Errorf("expected at most 2 queries at a time, got %d", maxRunning)
+	}
+}
+
+func TestParallelError(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"42P01","message":"relation does not exist"}`))
+			return
+		}
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	var slow, missing []map[string]interface{}
+	start := time.Now()
+	err := Parallel(context.Background(),
+		NewQuery(client.From("slow").Select("*"), &slow),
+		NewQuery(client.From("missing").Select("*"), &missing),
+	)
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.Code != "42P01" {
+		t.Fatalf("expected the request error of the failed query, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("expected the other queries to be cancelled")
+	}
+}
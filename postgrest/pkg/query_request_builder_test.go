@@ -1,8 +1,14 @@
 package postgrest_go
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestQueryRequestBuilder_Constructor(t *testing.T) {
@@ -28,3 +34,285 @@ func TestQueryRequestBuilder_Constructor(t *testing.T) {
 		t.Errorf("expected json == %v, got %v", nil, builder.json)
 	}
 }
+
+func TestQueryRequestBuilder_WithTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	err := client.From("example_table").Select("*").WithTimeout(10 * time.Millisecond).Execute(nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error == %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestQueryRequestBuilder_DefaultTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL, WithDefaultTimeout(10*time.Millisecond))
+
+	err := client.From("example_table").Select("*").Execute(nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error == %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestQueryRequestBuilder_ExecuteWithAffected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Prefer"); got != "return=representation,count=exact" {
+			t.Errorf("expected header Prefer == %s, got %s", "return=representation,count=exact", got)
+		}
+		w.Header().Set("Content-Range", "0-2/3")
+		w.Write([]byte(`[{"id":1},{"id":2},{"id":3}]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	var rows []map[string]interface{}
+	affected, err := client.From("example_table").Update(map[string]string{"name": "x"}).Eq("tenant", "a").ExecuteWithAffected(&rows)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if affected != 3 {
+		t.Errorf("expected affected == %d, got %d", 3, affected)
+	}
+	if len(rows) != 3 {
+		t.Errorf("expected len(rows) == %d, got %d", 3, len(rows))
+	}
+}
+
+func TestQueryRequestBuilder_DryRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Prefer"); got != "return=representation,tx=rollback" {
+			t.Errorf("expected header Prefer == %s, got %s", "return=representation,tx=rollback", got)
+		}
+		w.Write([]byte(`[{"id":1}]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	var rows []map[string]interface{}
+	if err := client.From("example_table").Insert(map[string]string{"name": "x"}).DryRun().Execute(&rows); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := client.From("example_table").Update(map[string]string{"name": "x"}).DryRun().Eq("id", "1").Execute(&rows); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestQueryRequestBuilder_UpsertColumns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("columns"); got != "id,name" {
+			t.Errorf("expected param columns == %s, got %s", "id,name", got)
+		}
+		if got := r.URL.Query().Get("on_conflict"); got != "email" {
+			t.Errorf("expected param on_conflict == %s, got %s", "email", got)
+		}
+		if got := r.Header.Get("Prefer"); got != "return=representation,resolution=ignore-duplicates,missing=default" {
+			t.Errorf("expected header Prefer == %s, got %s", "return=representation,resolution=ignore-duplicates,missing=default", got)
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	rows := []map[string]interface{}{{"id": 1, "name": "a"}, {"id": 2, "extra": true}}
+	err := client.From("example_table").Upsert(rows).Columns("id", "name").OnConflict("email").IgnoreDuplicates().MissingDefault().Execute(nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestQueryRequestBuilder_Build(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com", Path: "/rest/v1/"}, WithTokenAuth("s3cr3t"))
+
+	builder := client.From("example_table").Update(map[string]string{"name": "x"}).Eq("id", "1")
+	req, err := builder.Build()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if req.Method != http.MethodPatch {
+		t.Errorf("expected method == %s, got %s", http.MethodPatch, req.Method)
+	}
+	if got := req.URL.String(); got != "https://example.com/rest/v1/example_table?id=eq.1" {
+		t.Errorf("expected URL == %s, got %s", "https://example.com/rest/v1/example_table?id=eq.1", got)
+	}
+	if got := req.Header.Get("Prefer"); got != "return=representation" {
+		t.Errorf("expected header Prefer == %s, got %s", "return=representation", got)
+	}
+
+	s := builder.String()
+	if !strings.HasPrefix(s, "PATCH https://example.com/rest/v1/example_table?id=eq.1\n") {
+		t.Errorf("expected String to start with the method and URL, got %s", s)
+	}
+	if !strings.Contains(s, "Authorization: [redacted]\n") || strings.Contains(s, "s3cr3t") {
+		t.Errorf("expected String to redact the Authorization header, got %s", s)
+	}
+	if !strings.HasSuffix(s, "\n"+`{"name":"x"}`) {
+		t.Errorf("expected String to end with the body, got %s", s)
+	}
+}
+
+func TestQueryRequestBuilder_DeleteLimitAffected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if got := query.Get("limit"); got != "100" {
+			t.Errorf("expected param limit == %s, got %s", "100", got)
+		}
+		if got := query.Get("order"); got != "id" {
+			t.Errorf("expected param order == %s, got %s", "id", got)
+		}
+		if got := r.Header.Get("Prefer"); got != "count=exact" {
+			t.Errorf("expected header Prefer == %s, got %s", "count=exact", got)
+		}
+		w.Header().Set("Content-Range", "*/100")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	deleted, err := client.From("example_table").Delete().Eq("tenant", "a").LimitAffected(100, "id").ExecuteWithAffected(nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if deleted != 100 {
+		t.Errorf("expected deleted == %d, got %d", 100, deleted)
+	}
+}
+
+func TestQueryRequestBuilder_MaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":1},{"id":2},{"id":3}]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL, WithMaxResponseBytes(16))
+
+	var rows []map[string]interface{}
+	err := client.From("example_table").Select("*").Execute(&rows)
+
+	var tooLarge *ResponseTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("expected *ResponseTooLargeError, got %v", err)
+	}
+	if tooLarge.Limit != 16 {
+		t.Errorf("expected limit == %d, got %d", 16, tooLarge.Limit)
+	}
+}
+
+func TestQueryRequestBuilder_QueryEncoding(t *testing.T) {
+	var got url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL)
+	client := NewClient(*baseURL)
+
+	values := []string{`50% off`, `a&b=c`, `1+1`, `"quoted"`, `a,b`, `f(x).y`, `%2C`, `#tag`}
+	for _, value := range values {
+		got = nil
+		err := client.From("items").Select("id").Eq("name", value).Execute(nil)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if expected := "eq." + SanitizeParam(value); got.Get("name") != expected {
+			t.Errorf("expected name == %s, got %s", expected, got.Get("name"))
+		}
+	}
+
+	got = nil
+	err := client.From("items").Select("id").In("name", []string{"a,b", "50%", "c"}).Execute(nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if expected := `in.("a,b",50%,c)`; got.Get("name") != expected {
+		t.Errorf("expected name == %s, got %s", expected, got.Get("name"))
+	}
+}
+
+func TestQueryRequestBuilder_QuerySyntaxReadable(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"})
+
+	req, err := client.From("items").Select("id", "author(name)").In("id", []string{"1", "2"}).Eq("note", "a b&c").Build()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if expected := "id=in.(1,2)&note=eq.a%20b%26c&select=id,author(name)"; req.URL.RawQuery != expected {
+		t.Errorf("expected query == %s, got %s", expected, req.URL.RawQuery)
+	}
+}
+
+func BenchmarkQueryRequestBuilder_Build(b *testing.B) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"})
+	builder := client.From("items").Select("id", "name", "author(name)").
+		Eq("status", "active").In("id", []string{"1", "2", "3"}).Ilike("name", "%a,b%")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := builder.Build(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeQuery(b *testing.B) {
+	values := url.Values{
+		"select": {"id,name,author(name)"},
+		"status": {"eq.active"},
+		"id":     {"in.(1,2,3)"},
+		"name":   {`ilike."%a,b%"`},
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		encodeQuery(values)
+	}
+}
+
+func BenchmarkQueryRequestBuilder_Execute(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":1,"name":"a"},{"id":2,"name":"b"}]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL)
+	client := NewClient(*baseURL, WithTokenAuth("token"))
+	client.AddHeader("apikey", "key")
+
+	type item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var items []item
+		if err := client.From("items").Select("id", "name").Eq("status", "active").Execute(&items); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package postgrest_go
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// IdempotencyKeyHeader is the header carrying the idempotency key of a mutation
+// sent with ExecuteOrQueue, on the first attempt and when it is replayed.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// QueuedMutation is an INSERT, UPDATE or DELETE request stored by a
+// MutationQueue until it can be replayed.
+type QueuedMutation struct {
+	// ID is the idempotency key of the mutation
+	ID       string          `json:"id"`
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	Params   url.Values      `json:"params,omitempty"`
+	Header   http.Header     `json:"header,omitempty"`
+	Body     json.RawMessage `json:"body,omitempty"`
+	QueuedAt time.Time       `json:"queued_at"`
+}
+
+// MutationQueue stores the mutations that could not be sent because the
+// network was unavailable. Implementations must be safe for concurrent use.
+type MutationQueue interface {
+	// Enqueue appends a mutation to the queue
+	Enqueue(ctx context.Context, mutation QueuedMutation) error
+	// List returns the queued mutations, oldest first
+	List(ctx context.Context) ([]QueuedMutation, error)
+	// Remove deletes the mutation with the given id from the queue
+	Remove(ctx context.Context, id string) error
+}
+
+// MemoryQueue is a MutationQueue held in memory, lost when the process exits.
+type MemoryQueue struct {
+	mu        sync.Mutex
+	mutations []QueuedMutation
+}
+
+// NewMemoryQueue returns an empty in-memory queue.
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{}
+}
+
+func (q *MemoryQueue) Enqueue(ctx context.Context, mutation QueuedMutation) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.mutations = append(q.mutations, mutation)
+	return nil
+}
+
+func (q *MemoryQueue) List(ctx context.Context) ([]QueuedMutation, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]QueuedMutation(nil), q.mutations...), nil
+}
+
+func (q *MemoryQueue) Remove(ctx context.Context, id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, mutation := range q.mutations {
+		if mutation.ID == id {
+			q.mutations = append(q.mutations[:i], q.mutations[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// WithOfflineQueue stores the mutations sent with ExecuteOrQueue in queue when
+// the network is unavailable, to be sent later with ReplayQueue.
+func WithOfflineQueue(queue MutationQueue) ClientOption {
+	return func(c *Client) {
+		c.queue = queue
+	}
+}
+
+// QueuedMutationError is returned by ReplayQueue when the server rejects a
+// queued mutation. The mutation is removed from the queue.
+type QueuedMutationError struct {
+	Mutation QueuedMutation
+	Err      error
+}
+
+func (err *QueuedMutationError) Error() string {
+	return fmt.Sprintf("queued mutation %s %s: %v", err.Mutation.Method, err.Mutation.Path, err.Err)
+}
+
+func (err *QueuedMutationError) Unwrap() error {
+	return err.Err
+}
+
+// ExecuteOrQueue sends the mutation like ExecuteWithContext. If the request
+// fails before reaching the server because the network is unavailable, e.g.
+// the host cannot be resolved or the connection is refused, and the client has
+// an offline queue, the mutation is queued and queued is true. Other failures,
+// such as timeouts, are returned without queuing, as the mutation may have
+// been applied. The mutation carries an
+// idempotency key in the Idempotency-Key header, PostgREST itself ignores it,
+// so replays are only deduplicated by a proxy honoring the header or by rows
+// with client generated primary keys and upserts.
+func (b *QueryRequestBuilder) ExecuteOrQueue(ctx context.Context, r interface{}) (queued bool, err error) {
+	if b.httpMethod == http.MethodGet || b.httpMethod == http.MethodHead {
+		return false, errors.New("only INSERT, UPDATE and DELETE requests can be queued")
+	}
+	if b.client.queue == nil {
+		return false, b.ExecuteWithContext(ctx, r)
+	}
+	if b.err != nil {
+		return false, b.err
+	}
+
+	if b.header.Get(IdempotencyKeyHeader) == "" {
+		key, err := newIdempotencyKey()
+		if err != nil {
+			return false, err
+		}
+		b.header.Set(IdempotencyKeyHeader, key)
+	}
+
+	err = b.ExecuteWithContext(ctx, r)
+	if err == nil || !isUnsentError(err) {
+		return false, err
+	}
+
+	body, err := b.client.marshal(b.json)
+	if err != nil {
+		return false, err
+	}
+	mutation := QueuedMutation{
+		ID:       b.header.Get(IdempotencyKeyHeader),
+		Method:   b.httpMethod,
+		Path:     b.path,
+		Params:   cloneValues(b.params),
+		Header:   b.header.Clone(),
+		Body:     body,
+		QueuedAt: time.Now(),
+	}
+	if err := b.client.queue.Enqueue(ctx, mutation); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReplayQueue sends the queued mutations in order and removes the ones that
+// succeeded, returning their number. It stops at the first failure: a request
+// that did not reach the server leaves the mutation queued, while a mutation
+// rejected by the server, or whose request failed after it may have been
+// applied, is removed and returned as a *QueuedMutationError.
+func (c *Client) ReplayQueue(ctx context.Context) (int, error) {
+	if c.queue == nil {
+		return 0, nil
+	}
+
+	mutations, err := c.queue.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, mutation := range mutations {
+		b := &QueryRequestBuilder{
+			client:     c,
+			params:     cloneValues(mutation.Params),
+			header:     mutation.Header.Clone(),
+			path:       mutation.Path,
+			httpMethod: mutation.Method,
+			json:       mutation.Body,
+		}
+		if b.params == nil {
+			b.params = url.Values{}
+		}
+		if b.header == nil {
+			b.header = http.Header{}
+		}
+		b.header.Set(IdempotencyKeyHeader, mutation.ID)
+
+		err := b.ExecuteWithContext(ctx, nil)
+		if err != nil && isUnsentError(err) {
+			return replayed, err
+		}
+		if removeErr := c.queue.Remove(ctx, mutation.ID); removeErr != nil {
+			return replayed, removeErr
+		}
+		if err != nil {
+			return replayed, &QueuedMutationError{Mutation: mutation, Err: err}
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// isUnsentError tells whether the request failed before it reached the
+// server, so it is safe to send again: the connection could not be
+// established or the host could not be resolved.
+func isUnsentError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+func newIdempotencyKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
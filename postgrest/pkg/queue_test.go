@@ -0,0 +1,127 @@
+package postgrest_go
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestClient_OfflineQueue(t *testing.T) {
+	var (
+		bodies []string
+		keys   []string
+	)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if r.URL.Path == "/rejected" {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"code":"23505","message":"duplicate key value violates unique constraint"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	// reserve the address of the server, requests fail until it is started
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	baseURL, _ := url.Parse("http://" + addr + "/")
+	queue := NewMemoryQueue()
+	client := NewClient(*baseURL, WithOfflineQueue(queue))
+
+	ctx := context.Background()
+	queued, err := client.From("events").Insert(map[string]interface{}{"id": 1}).ExecuteOrQueue(ctx, nil)
+	if err != nil || !queued {
+		t.Fatalf("expected the insert to be queued, got %v %v", queued, err)
+	}
+	queued, err = client.From("rejected").Delete().Eq("id", "1").ExecuteOrQueue(ctx, nil)
+	if err != nil || !queued {
+		t.Fatalf("expected the delete to be queued, got %v %v", queued, err)
+	}
+
+	if _, err := client.ReplayQueue(ctx); err == nil {
+		t.Errorf("expected an error replaying while offline")
+	}
+	if mutations, _ := queue.List(ctx); len(mutations) != 2 {
+		t.Fatalf("expected the mutations to stay queued, got %d", len(mutations))
+	}
+
+	server.Listener, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("could not listen on %s: %v", addr, err)
+	}
+	server.Start()
+	defer server.Close()
+
+	replayed, err := client.ReplayQueue(ctx)
+	var queuedErr *QueuedMutationError
+	if !errors.As(err, &queuedErr) || queuedErr.Mutation.Path != "/rejected" {
+		t.Errorf("expected the rejected mutation error, got %v", err)
+	}
+	if replayed != 1 {
+		t.Errorf("expected 1 replayed mutation, got %d", replayed)
+	}
+	if mutations, _ := queue.List(ctx); len(mutations) != 0 {
+		t.Errorf("expected an empty queue, got %d mutations", len(mutations))
+	}
+	if len(bodies) != 2 || bodies[0] != `{"id":1}` {
+		t.Errorf("expected the queued insert body to be replayed, got %v", bodies)
+	}
+	if keys[0] == "" || keys[0] == keys[1] {
+		t.Errorf("expected distinct idempotency keys, got %v", keys)
+	}
+}
+
+func TestClient_ExecuteOrQueueSentRequests(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		if r.URL.Path == "/reset" {
+			// the body was received, the connection is closed without a response
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	queue := NewMemoryQueue()
+	client := NewClient(*baseURL, WithOfflineQueue(queue))
+
+	ctx := context.Background()
+	queued, err := client.From("events").Insert(map[string]interface{}{"id": 1}).WithTimeout(50*time.Millisecond).ExecuteOrQueue(ctx, nil)
+	if err == nil || queued {
+		t.Errorf("expected a timed out insert to fail without being queued, got %v %v", queued, err)
+	}
+	queued, err = client.From("reset").Insert(map[string]interface{}{"id": 1}).ExecuteOrQueue(ctx, nil)
+	if err == nil || queued {
+		t.Errorf("expected an insert reset after being sent to fail without being queued, got %v %v", queued, err)
+	}
+	if mutations, _ := queue.List(ctx); len(mutations) != 0 {
+		t.Errorf("expected an empty queue, got %d mutations", len(mutations))
+	}
+}
+
+func TestClient_ExecuteOrQueueRejectsReads(t *testing.T) {
+	baseURL, _ := url.Parse("http://localhost/")
+	client := NewClient(*baseURL, WithOfflineQueue(NewMemoryQueue()))
+	if _, err := client.From("events").Select("*").ExecuteOrQueue(context.Background(), nil); err == nil {
+		t.Errorf("expected an error queuing a SELECT request")
+	}
+}
@@ -6,10 +6,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // RequestError represents an error response from the PostgREST server.
@@ -33,6 +34,16 @@ type RequestBuilder struct {
 	header http.Header
 }
 
+// Clone returns a copy of the builder that shares no params or headers with it.
+func (b *RequestBuilder) Clone() *RequestBuilder {
+	return &RequestBuilder{
+		client: b.client,
+		path:   b.path,
+		params: cloneValues(b.params),
+		header: b.header.Clone(),
+	}
+}
+
 // Select starts building a SELECT request with the specified columns.
 func (b *RequestBuilder) Select(columns ...string) *SelectRequestBuilder {
 	b.params.Set("select", strings.Join(columns, ","))
@@ -116,81 +127,251 @@ type QueryRequestBuilder struct {
 	httpMethod string
 	json       interface{}
 	isCount    bool
+	timeout    time.Duration
+	useReplica bool
+	// useNumber decodes numbers into json.Number, set by SelectAggregate
+	useNumber bool
+	// err is the first error that occurred while building the request, returned on execution
+	err error
+}
+
+// Clone returns a copy of the builder that shares no params or headers with it,
+// so a partially built query can be reused as a template.
+func (b *QueryRequestBuilder) Clone() *QueryRequestBuilder {
+	c := *b
+	c.params = cloneValues(b.params)
+	c.header = b.header.Clone()
+	return &c
+}
+
+// WithTimeout sets the timeout of the request, applied on top of the context passed to ExecuteWithContext.
+func (b *QueryRequestBuilder) WithTimeout(d time.Duration) *QueryRequestBuilder {
+	b.timeout = d
+	return b
+}
+
+// DryRun makes PostgREST roll back the transaction of the request with Prefer: tx=rollback,
+// so writes are executed and their results returned without being committed. It requires
+// PostgREST to be configured with db-tx-end = commit-allow-override or rollback-allow-override.
+func (b *QueryRequestBuilder) DryRun() *QueryRequestBuilder {
+	b.addPreference("tx=rollback")
+	return b
+}
+
+// Columns restricts the columns inserted or upserted from the payload with the columns
+// parameter. Keys of the payload outside the columns are ignored and missing keys are
+// set to null (or their default, see MissingDefault), so rows of a bulk insert may have
+// different keys.
+func (b *QueryRequestBuilder) Columns(columns ...string) *QueryRequestBuilder {
+	sanitized := make([]string, len(columns))
+	for i, column := range columns {
+		sanitized[i] = SanitizeParam(column)
+	}
+	b.params.Set("columns", strings.Join(sanitized, ","))
+	return b
+}
+
+// MissingDefault makes PostgREST use the column defaults instead of null for the
+// columns missing from a row, with Prefer: missing=default.
+func (b *QueryRequestBuilder) MissingDefault() *QueryRequestBuilder {
+	b.addPreference("missing=default")
+	return b
+}
+
+// OnConflict sets the unique columns used as the conflict target of an upsert, by
+// default the primary key is used.
+func (b *QueryRequestBuilder) OnConflict(columns ...string) *QueryRequestBuilder {
+	sanitized := make([]string, len(columns))
+	for i, column := range columns {
+		sanitized[i] = SanitizeParam(column)
+	}
+	b.params.Set("on_conflict", strings.Join(sanitized, ","))
+	return b
+}
+
+// IgnoreDuplicates makes an upsert keep the existing rows on conflict instead of
+// merging the new values into them.
+func (b *QueryRequestBuilder) IgnoreDuplicates() *QueryRequestBuilder {
+	prefer := b.header.Get("Prefer")
+	if strings.Contains(prefer, "resolution=merge-duplicates") {
+		b.header.Set("Prefer", strings.Replace(prefer, "resolution=merge-duplicates", "resolution=ignore-duplicates", 1))
+		return b
+	}
+	b.addPreference("resolution=ignore-duplicates")
+	return b
 }
 
 // Execute sends the query request and unmarshals the response JSON into the provided object.
+// The request is bounded by the client default timeout, see WithDefaultTimeout.
 func (b *QueryRequestBuilder) Execute(r interface{}) error {
-	return b.ExecuteWithContext(context.Background(), r)
+	ctx, cancel := b.client.defaultContext()
+	defer cancel()
+	return b.ExecuteWithContext(ctx, r)
 }
 
 // ExecuteWithContext sends the query request with the provided context and unmarshals the response JSON into the provided object.
 func (b *QueryRequestBuilder) ExecuteWithContext(ctx context.Context, r interface{}) error {
-	data, err := json.Marshal(b.json)
+	_, err := b.execute(ctx, r)
+	return err
+}
+
+// ExecuteWithAffected sends the mutation request and returns the number of affected rows.
+// The request is bounded by the client default timeout, see WithDefaultTimeout.
+func (b *QueryRequestBuilder) ExecuteWithAffected(r interface{}) (int64, error) {
+	ctx, cancel := b.client.defaultContext()
+	defer cancel()
+	return b.ExecuteWithAffectedContext(ctx, r)
+}
+
+// ExecuteWithAffectedContext sends the mutation request with the provided context and
+// returns the number of affected rows, as counted by PostgREST with Prefer: count=exact.
+func (b *QueryRequestBuilder) ExecuteWithAffectedContext(ctx context.Context, r interface{}) (int64, error) {
+	b.addPreference("count=exact")
+	header, err := b.execute(ctx, r)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	req, err := http.NewRequestWithContext(ctx, b.httpMethod, b.path, bytes.NewBuffer(data))
-	if err != nil {
-		return err
+
+	return parseContentRangeCount(header.Get("Content-Range"))
+}
+
+// addPreference appends a preference to the Prefer header of the request.
+func (b *QueryRequestBuilder) addPreference(preference string) {
+	if b.header == nil {
+		b.header = http.Header{}
 	}
-	query, err := url.QueryUnescape(b.params.Encode())
 
-	if err != nil {
-		return err
+	prefer := b.header.Get("Prefer")
+	for _, p := range strings.Split(prefer, ",") {
+		if strings.TrimSpace(p) == preference {
+			return
+		}
+	}
+
+	if prefer != "" {
+		preference = prefer + "," + preference
 	}
+	b.header.Set("Prefer", preference)
+}
 
-	req.URL.RawQuery = query
+// Build returns the fully composed request without sending it, e.g. for
+// logging or testing the construction of a query.
+func (b *QueryRequestBuilder) Build() (*http.Request, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.newRequest(context.Background())
+}
 
-	req.Header = b.client.Headers()
+// String returns the method, URL, headers and body of the request, or the
+// error that occurred while building it.
+func (b *QueryRequestBuilder) String() string {
+	req, err := b.Build()
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return formatRequest(req)
+}
 
-	// Inject/override custom headers
-	for key, vals := range b.header {
-		for _, val := range vals {
-			req.Header.Set(key, val)
+// newRequest composes the request with the default headers of the client.
+func (b *QueryRequestBuilder) newRequest(ctx context.Context) (*http.Request, error) {
+	data := nullJSON
+	if b.json != nil {
+		var err error
+		if data, err = b.client.marshal(b.json); err != nil {
+			return nil, err
 		}
 	}
+	data, compressed, err := b.client.compressBody(b.httpMethod, data)
+	if err != nil {
+		return nil, err
+	}
+	reqURL := b.client.resolveURL(b.path)
+	if b.useReplica && (b.httpMethod == http.MethodGet || b.httpMethod == http.MethodHead) && !b.client.pinnedToPrimary(ctx, b.authorization()) {
+		reqURL = b.client.resolveReplicaURL(b.path)
+	}
+	req, err := http.NewRequestWithContext(ctx, b.httpMethod, reqURL.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.URL.RawQuery = encodeQuery(b.params)
+	req.Header = b.client.requestHeaders(b.header)
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	return req, nil
+}
+
+// execute sends the request and returns the response headers.
+func (b *QueryRequestBuilder) execute(ctx context.Context, r interface{}) (http.Header, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
 
-	req.URL.Path = req.URL.Path[1:]
-	req.URL = b.client.Transport.baseURL.ResolveReference(req.URL)
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	req, err := b.newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	resp, err := b.client.session.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := responseBody(resp)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer respBody.Close()
+	buf, err := readBody(respBody, b.client.maxResponse)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseBuffer(buf)
+	body := buf.Bytes()
 
 	statusOK := resp.StatusCode >= 200 && resp.StatusCode < 300
 	if !statusOK {
-		reqError := RequestError{HTTPStatusCode: resp.StatusCode}
-
-		if err = json.Unmarshal(body, &reqError); err != nil {
-			return err
-		}
-
-		return &reqError
+		return nil, b.client.decodeRequestError(resp.StatusCode, body)
 	}
+	b.client.recordWrite(ctx, b.httpMethod, req.Header.Get("Authorization"))
 
 	if resp.StatusCode != http.StatusNoContent && r != nil {
 		if b.isCount {
 			contentRange := resp.Header.Get("Content-Range")
 			contentRangeParts := strings.Split(contentRange, "/")
 			if len(contentRangeParts) != 2 {
-				return errors.New("invalid content range returned from count request")
+				return nil, errors.New("invalid content range returned from count request")
 			}
-			return json.Unmarshal([]byte(contentRangeParts[1]), r)
+			return resp.Header, json.Unmarshal([]byte(contentRangeParts[1]), r)
 		}
 
-		if err = json.Unmarshal(body, r); err != nil {
-			return err
+		if err = b.client.unmarshal(body, r, b.client.useNumber || b.useNumber); err != nil {
+			return nil, &DecodeError{Err: err}
 		}
 	}
 
-	return nil
+	return resp.Header, nil
+}
+
+// parseContentRangeCount returns the total count of a "start-end/count" Content-Range header.
+func parseContentRangeCount(contentRange string) (int64, error) {
+	contentRangeParts := strings.Split(contentRange, "/")
+	if len(contentRangeParts) != 2 {
+		return 0, errors.New("invalid content range returned from count request")
+	}
+
+	count, err := strconv.ParseInt(contentRangeParts[1], 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid content range returned from count request")
+	}
+	return count, nil
 }
 
 // FilterRequestBuilder represents a builder for filter requests.
@@ -199,14 +380,62 @@ type FilterRequestBuilder struct {
 	negateNext bool
 }
 
+// Clone returns a copy of the builder that shares no params or headers with it,
+// so a partially built query can be reused as a template.
+func (b *FilterRequestBuilder) Clone() *FilterRequestBuilder {
+	return &FilterRequestBuilder{
+		QueryRequestBuilder: *b.QueryRequestBuilder.Clone(),
+		negateNext:          b.negateNext,
+	}
+}
+
+// WithTimeout sets the timeout of the request, applied on top of the context passed to ExecuteWithContext.
+func (b *FilterRequestBuilder) WithTimeout(d time.Duration) *FilterRequestBuilder {
+	b.timeout = d
+	return b
+}
+
+// DryRun makes PostgREST roll back the transaction of the request, see QueryRequestBuilder.DryRun.
+func (b *FilterRequestBuilder) DryRun() *FilterRequestBuilder {
+	b.QueryRequestBuilder.DryRun()
+	return b
+}
+
+// LimitAffected restricts an UPDATE or DELETE request to the first size rows ordered by
+// orderColumn, which should be unique, e.g. to purge a large table in batches:
+//
+//	for {
+//		deleted, err := client.From("events").Delete().Eq("user_id", id).LimitAffected(1000, "id").ExecuteWithAffected(nil)
+//		if err != nil || deleted < 1000 {
+//			break
+//		}
+//	}
+func (b *FilterRequestBuilder) LimitAffected(size int, orderColumn string) *FilterRequestBuilder {
+	b.params.Set("limit", strconv.Itoa(size))
+	b.params.Set("order", SanitizeParam(orderColumn))
+	return b
+}
+
 // Not negates the next filter condition.
 func (b *FilterRequestBuilder) Not() *FilterRequestBuilder {
 	b.negateNext = true
 	return b
 }
 
-// Filter adds a filter condition to the request.
+// Filter adds a filter condition to the request. Column names containing reserved characters are quoted,
+// use FilterRaw for columns of embedded resources and casts.
 func (b *FilterRequestBuilder) Filter(column, operator, criteria string) *FilterRequestBuilder {
+	return b.addFilter(SanitizeParam(column), operator, criteria)
+}
+
+// FilterRaw adds a filter condition to the request like Filter, using the column as is, so it may be a
+// column of an embedded resource such as author.name or a cast such as id::text.
+func (b *FilterRequestBuilder) FilterRaw(column, operator, criteria string) *FilterRequestBuilder {
+	return b.addFilter(column, operator, criteria)
+}
+
+// addFilter adds a filter condition on a column or path used as is.
+func (b *FilterRequestBuilder) addFilter(column, operator, criteria string) *FilterRequestBuilder {
 	if b.negateNext {
 		b.negateNext = false
 		operator = "not." + operator
@@ -355,6 +584,25 @@ type SelectRequestBuilder struct {
 	FilterRequestBuilder
 }
 
+// Clone returns a copy of the builder that shares no params or headers with it,
+// so a partially built query can be reused as a template.
+func (b *SelectRequestBuilder) Clone() *SelectRequestBuilder {
+	return &SelectRequestBuilder{*b.FilterRequestBuilder.Clone()}
+}
+
+// WithTimeout sets the timeout of the request, applied on top of the context passed to ExecuteWithContext.
+func (b *SelectRequestBuilder) WithTimeout(d time.Duration) *SelectRequestBuilder {
+	b.timeout = d
+	return b
+}
+
+// UseReplica sends the SELECT request to a read replica configured with WithReadReplica.
+// Replicas lag behind the primary, so rows written just before may be missing.
+func (b *SelectRequestBuilder) UseReplica() *SelectRequestBuilder {
+	b.useReplica = true
+	return b
+}
+
 // OrderBy sets the ordering column and direction for the SELECT request.
 func (b *SelectRequestBuilder) OrderBy(column, direction string) *SelectRequestBuilder {
 	b.params.Set("order", column+"."+direction)
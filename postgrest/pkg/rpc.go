@@ -0,0 +1,144 @@
+package postgrest_go
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// RpcWithParams starts building a call of the function f with the given params, a
+// map or a struct whose fields are named after the function arguments by their json
+// tags (or the naming strategy of the client). Slices are sent as Postgres arrays
+// and time.Time values as timestamptz.
+func (c *Client) RpcWithParams(f string, params interface{}) *RpcRequestBuilder {
+	return &RpcRequestBuilder{
+		client:     c,
+		path:       "/rpc/" + f,
+		header:     http.Header{},
+		httpMethod: http.MethodPost,
+		params:     params,
+	}
+}
+
+// Get calls the function with GET, passing the params in the query string. Only
+// functions declared STABLE or IMMUTABLE can be called with GET.
+func (r *RpcRequestBuilder) Get() *RpcRequestBuilder {
+	r.httpMethod = http.MethodGet
+	return r
+}
+
+// encodeParams encodes the params as a JSON object. Nil params are sent as an
+// empty object, as required by functions without arguments.
+func (r *RpcRequestBuilder) encodeParams() ([]byte, error) {
+	params := r.params
+	if params == nil || isNilValue(reflect.ValueOf(params)) {
+		return []byte("{}"), nil
+	}
+	if m, ok := params.(map[string]interface{}); ok {
+		params = normalizeRPCParams(m)
+	}
+
+	data, err := r.client.marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, fmt.Errorf("rpc params must encode to a JSON object, got %T", r.params)
+	}
+	return data, nil
+}
+
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Pointer, reflect.Interface, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// normalizeRPCParams converts time values to timestamptz literals with microsecond precision.
+func normalizeRPCParams(params map[string]interface{}) map[string]interface{} {
+	normalized := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		switch v := value.(type) {
+		case time.Time:
+			value = FormatTimestamptz(v)
+		case *time.Time:
+			if v != nil {
+				value = FormatTimestamptz(*v)
+			}
+		case []time.Time:
+			formatted := make([]string, len(v))
+			for i, t := range v {
+				formatted[i] = FormatTimestamptz(t)
+			}
+			value = formatted
+		}
+		normalized[key] = value
+	}
+	return normalized
+}
+
+// rpcQuery converts JSON encoded params to query parameters, formatting arrays as
+// Postgres array literals.
+func rpcQuery(data []byte) (url.Values, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var params map[string]interface{}
+	if err := decoder.Decode(&params); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	for key, value := range params {
+		switch v := value.(type) {
+		case []interface{}:
+			query.Set(key, formatArrayLiteral(v))
+		case map[string]interface{}:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			query.Set(key, string(encoded))
+		case nil:
+			query.Set(key, "")
+		default:
+			query.Set(key, fmt.Sprint(v))
+		}
+	}
+	return query, nil
+}
+
+// formatArrayLiteral formats values as a Postgres array literal such as {1,2,"a b"}.
+func formatArrayLiteral(values []interface{}) string {
+	elements := make([]string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case nil:
+			elements[i] = "NULL"
+		case string:
+			elements[i] = quoteArrayElement(v)
+		case []interface{}:
+			elements[i] = formatArrayLiteral(v)
+		case map[string]interface{}:
+			encoded, _ := json.Marshal(v)
+			elements[i] = quoteArrayElement(string(encoded))
+		default:
+			elements[i] = fmt.Sprint(v)
+		}
+	}
+	return "{" + strings.Join(elements, ",") + "}"
+}
+
+// quoteArrayElement quotes a string array element if needed.
+func quoteArrayElement(s string) string {
+	if s != "" && !strings.EqualFold(s, "null") && !strings.ContainsAny(s, "{},\"\\ \t\n") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
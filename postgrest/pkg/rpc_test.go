@@ -0,0 +1,74 @@
+package postgrest_go
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRpcRequestBuilder_Params(t *testing.T) {
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.Write([]byte(`null`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	if err := client.Rpc("example_function", nil).Execute(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if gotBody != "{}" {
+		t.Errorf("expected body == %s, got %s", "{}", gotBody)
+	}
+
+	since := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.FixedZone("", 3600))
+	if err := client.Rpc("example_function", map[string]interface{}{"since": since}).Execute(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if expected := `{"since":"2024-01-02T02:04:05.000006Z"}`; gotBody != expected {
+		t.Errorf("expected body == %s, got %s", expected, gotBody)
+	}
+
+	type params struct {
+		IDs  []int  `json:"ids"`
+		Name string `json:"p_name"`
+	}
+	if err := client.RpcWithParams("example_function", params{IDs: []int{1, 2}, Name: "a"}).Execute(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if expected := `{"ids":[1,2],"p_name":"a"}`; gotBody != expected {
+		t.Errorf("expected body == %s, got %s", expected, gotBody)
+	}
+
+	if err := client.RpcWithParams("example_function", []int{1}).Execute(nil); err == nil {
+		t.Errorf("expected an error for params that are not an object")
+	}
+}
+
+func TestRpcRequestBuilder_Get(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"})
+
+	req, err := client.RpcWithParams("example_function", map[string]interface{}{
+		"tags": []string{"a", "b c"},
+		"n":    1,
+	}).Get().Build()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if req.Method != http.MethodGet {
+		t.Errorf("expected method == %s, got %s", http.MethodGet, req.Method)
+	}
+	if got := req.URL.Query().Get("tags"); got != `{a,"b c"}` {
+		t.Errorf("expected param tags == %s, got %s", `{a,"b c"}`, got)
+	}
+	if got := req.URL.Query().Get("n"); got != "1" {
+		t.Errorf("expected param n == %s, got %s", "1", got)
+	}
+}
@@ -0,0 +1,210 @@
+package postgrest_go
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithUseNumber decodes the numbers of responses into json.Number instead of
+// float64 when the target is an interface{}, e.g. the values of rows decoded
+// into []map[string]interface{}, so bigint and numeric columns keep their
+// precision.
+func WithUseNumber() ClientOption {
+	return func(c *Client) {
+		c.useNumber = true
+	}
+}
+
+var (
+	scannerType       = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	jsonUnmarshalType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	// scannerFieldsCache maps struct types to their []scannerField
+	scannerFieldsCache sync.Map
+)
+
+// scannerField is a struct field implementing sql.Scanner but not
+// json.Unmarshaler, such as sql.NullString or sql.NullTime.
+type scannerField struct {
+	name  string
+	key   string
+	index []int
+}
+
+// scannerFields returns the sql.Scanner fields of a struct type, including
+// the ones of embedded structs.
+func scannerFields(t reflect.Type) []scannerField {
+	if t == nil {
+		return nil
+	}
+	if fields, ok := scannerFieldsCache.Load(t); ok {
+		return fields.([]scannerField)
+	}
+
+	var fields []scannerField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct && !isScanner(field.Type) {
+			for _, nested := range scannerFields(field.Type) {
+				nested.index = append([]int{i}, nested.index...)
+				fields = append(fields, nested)
+			}
+			continue
+		}
+
+		if field.IsExported() && isScanner(field.Type) {
+			key := tag
+			if key == "" {
+				key = field.Name
+			}
+			fields = append(fields, scannerField{name: field.Name, key: key, index: []int{i}})
+		}
+	}
+
+	scannerFieldsCache.Store(t, fields)
+	return fields
+}
+
+func isScanner(t reflect.Type) bool {
+	p := reflect.PointerTo(t)
+	return p.Implements(scannerType) && !p.Implements(jsonUnmarshalType)
+}
+
+// decode decodes JSON into v, scanning the values of sql.Scanner fields of
+// the rows and decoding numbers into json.Number if useNumber.
+func (c *Client) decode(data []byte, v interface{}, useNumber bool) error {
+	fields := scannerFields(rowType(reflect.TypeOf(v)))
+	target := reflect.ValueOf(v)
+	if len(fields) == 0 || target.Kind() != reflect.Pointer || target.IsNil() {
+		return c.decodeValue(data, v, useNumber)
+	}
+
+	data = bytes.TrimSpace(data)
+	target = target.Elem()
+	switch {
+	case len(data) > 0 && data[0] == '[' && target.Kind() == reflect.Slice:
+		var rows []json.RawMessage
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return err
+		}
+
+		slice := reflect.MakeSlice(target.Type(), len(rows), len(rows))
+		for i, row := range rows {
+			if err := c.decodeRow(row, slice.Index(i), fields, useNumber); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+		return nil
+	case len(data) > 0 && data[0] == '{' && (target.Kind() == reflect.Struct || target.Kind() == reflect.Pointer):
+		return c.decodeRow(data, target, fields, useNumber)
+	}
+	return c.decodeValue(data, v, useNumber)
+}
+
+// decodeValue decodes JSON into v, decoding numbers into json.Number if useNumber.
+func (c *Client) decodeValue(data []byte, v interface{}, useNumber bool) error {
+	if !useNumber {
+		return c.jsonDecode(data, v)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// decodeRow decodes a JSON object into a struct, or a pointer to a struct,
+// scanning the values of its sql.Scanner fields.
+func (c *Client) decodeRow(data []byte, target reflect.Value, fields []scannerField, useNumber bool) error {
+	if target.Kind() == reflect.Pointer {
+		if string(data) == "null" {
+			target.SetZero()
+			return nil
+		}
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+
+	var row map[string]json.RawMessage
+	if err := json.Unmarshal(data, &row); err != nil {
+		return err
+	}
+
+	values := make([]json.RawMessage, len(fields))
+	for i, field := range fields {
+		for key, value := range row {
+			if key == field.key || key == field.name || strings.EqualFold(key, field.key) {
+				values[i] = value
+				delete(row, key)
+				break
+			}
+		}
+	}
+
+	rest, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if err := c.decodeValue(rest, target.Addr().Interface(), useNumber); err != nil {
+		return err
+	}
+
+	for i, field := range fields {
+		if values[i] == nil {
+			continue
+		}
+		scanner := target.FieldByIndex(field.index).Addr().Interface().(sql.Scanner)
+		if err := scanJSON(scanner, values[i]); err != nil {
+			return &json.UnmarshalTypeError{Value: string(values[i]), Type: target.FieldByIndex(field.index).Type(), Field: field.key}
+		}
+	}
+	return nil
+}
+
+// scanJSON scans a JSON value. Numbers are scanned as strings so they keep
+// their precision, strings that are not accepted are retried as timestamps,
+// and arrays and objects are scanned as their JSON bytes.
+func scanJSON(scanner sql.Scanner, data json.RawMessage) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		value = v.String()
+	case map[string]interface{}, []interface{}:
+		value = []byte(data)
+	}
+
+	err := scanner.Scan(value)
+	if s, ok := value.(string); ok && err != nil {
+		if t, ok := parseTimeValue(s); ok {
+			return scanner.Scan(t)
+		}
+	}
+	return err
+}
+
+// parseTimeValue parses a timestamptz, timestamp or date literal.
+func parseTimeValue(s string) (time.Time, bool) {
+	s = strings.Replace(s, " ", "T", 1)
+	for _, layout := range []string{timestamptzLayout, "2006-01-02T15:04:05.999999Z07", timestampLayout, dateLayout} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
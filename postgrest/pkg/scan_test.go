@@ -0,0 +1,95 @@
+package postgrest_go
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestClient_UseNumber(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":9007199254740993,"total":"12.50"}]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL, WithUseNumber())
+
+	var rows []map[string]interface{}
+	if err := client.From("orders").Select("*").ExecuteWithContext(context.Background(), &rows); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if id, ok := rows[0]["id"].(json.Number); !ok || id.String() != "9007199254740993" {
+		t.Errorf("expected the id to be decoded as a json.Number, got %#v", rows[0]["id"])
+	}
+}
+
+func TestClient_ScanNullTypes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"id":1,"name":"a","deleted_at":"2024-03-01T10:20:30.123456+00:00","score":1.5,"active":true,"tags":["x"]},
+			{"id":2,"name":null,"deleted_at":null,"score":null,"active":null,"tags":null}
+		]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	type base struct {
+		ID int64 `json:"id"`
+	}
+	var rows []struct {
+		base
+		Name      sql.NullString  `json:"name"`
+		DeletedAt sql.NullTime    `json:"deleted_at"`
+		Score     sql.NullFloat64 `json:"score"`
+		Active    sql.NullBool    `json:"active"`
+		Tags      []string        `json:"tags"`
+	}
+	if err := client.From("users").Select("*").ExecuteWithContext(context.Background(), &rows); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+
+	first := rows[0]
+	if first.ID != 1 || first.Name != (sql.NullString{String: "a", Valid: true}) || first.Score.Float64 != 1.5 || !first.Active.Bool || len(first.Tags) != 1 {
+		t.Errorf("expected the values of the first row, got %+v", first)
+	}
+	expected := time.Date(2024, 3, 1, 10, 20, 30, 123456000, time.UTC)
+	if !first.DeletedAt.Valid || !first.DeletedAt.Time.Equal(expected) {
+		t.Errorf("expected deleted_at to be %v, got %+v", expected, first.DeletedAt)
+	}
+
+	second := rows[1]
+	if second.ID != 2 || second.Name.Valid || second.DeletedAt.Valid || second.Score.Valid || second.Active.Valid {
+		t.Errorf("expected the null values of the second row to be invalid, got %+v", second)
+	}
+}
+
+func TestClient_ScanNullTypesSingle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":9007199254740993}`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	var row struct {
+		ID sql.NullInt64 `json:"id"`
+	}
+	if err := client.From("users").Select("*").Single().ExecuteWithContext(context.Background(), &row); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if row.ID.Int64 != 9007199254740993 {
+		t.Errorf("expected the id to keep its precision, got %d", row.ID.Int64)
+	}
+}
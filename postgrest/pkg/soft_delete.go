@@ -0,0 +1,51 @@
+package postgrest_go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// defaultSoftDeleteColumn is the column set by SoftDelete unless changed with WithSoftDeleteColumn.
+const defaultSoftDeleteColumn = "deleted_at"
+
+// WithSoftDeleteColumn sets the timestamp column used by SoftDelete and
+// WithoutDeleted, "deleted_at" by default.
+func WithSoftDeleteColumn(column string) ClientOption {
+	return func(c *Client) {
+		c.softDeleteColumn = column
+	}
+}
+
+func (c *Client) softDeleteColumnName() string {
+	if c.softDeleteColumn == "" {
+		return defaultSoftDeleteColumn
+	}
+	return c.softDeleteColumn
+}
+
+// SoftDelete sends a DELETE request as an UPDATE setting the soft delete
+// column of the matched rows to the current time, and returns the number of
+// soft deleted rows. Rows already soft deleted keep their timestamp.
+//
+//	deleted, err := client.From("posts").Delete().Eq("id", "1").SoftDelete(ctx)
+func (b *FilterRequestBuilder) SoftDelete(ctx context.Context) (int64, error) {
+	if b.httpMethod != http.MethodDelete {
+		return 0, errors.New("SoftDelete can only be used on a Delete request")
+	}
+
+	column := b.client.softDeleteColumnName()
+	update := b.Clone()
+	update.httpMethod = http.MethodPatch
+	update.json = map[string]string{column: FormatTimestamptz(time.Now())}
+	update.addPreference("return=minimal")
+	update.IsNull(column)
+	return update.ExecuteWithAffectedContext(ctx, nil)
+}
+
+// WithoutDeleted excludes the rows soft deleted with SoftDelete.
+func (b *SelectRequestBuilder) WithoutDeleted() *SelectRequestBuilder {
+	b.IsNull(b.client.softDeleteColumnName())
+	return b
+}
@@ -0,0 +1,55 @@
+package postgrest_go
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestFilterRequestBuilder_SoftDelete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			t.Errorf("expected method == %s, got %s", http.MethodPatch, r.Method)
+		}
+		if expected := "archived_at=is.null&id=eq.1"; r.URL.RawQuery != expected {
+			t.Errorf("expected query == %s, got %s", expected, r.URL.RawQuery)
+		}
+
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["archived_at"] == "" {
+			t.Errorf("expected body with archived_at, got %v (%v)", body, err)
+		}
+		w.Header().Set("Content-Range", "*/1")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL, WithSoftDeleteColumn("archived_at"))
+
+	deleted, err := client.From("posts").Delete().Eq("id", "1").SoftDelete(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected deleted == 1, got %d", deleted)
+	}
+
+	if _, err := client.From("posts").Update(nil).SoftDelete(context.Background()); err == nil {
+		t.Error("expected an error for a non-DELETE request")
+	}
+}
+
+func TestSelectRequestBuilder_WithoutDeleted(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "http", Host: "localhost"})
+	req, err := client.From("posts").Select("*").WithoutDeleted().Build()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if expected := "deleted_at=is.null&select=*"; req.URL.RawQuery != expected {
+		t.Errorf("expected query == %s, got %s", expected, req.URL.RawQuery)
+	}
+}
@@ -0,0 +1,120 @@
+package postgrest_go
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Placeholder returns the placeholder of the named parameter of a QueryTemplate,
+// used in place of a filter value, e.g.
+//
+//	tmpl := client.From("orders").Select("*").Eq("user_id", postgrest.Placeholder("user")).Template()
+//	err := tmpl.ExecuteWithContext(ctx, map[string]string{"user": id}, &orders)
+func Placeholder(name string) string {
+	return "${" + name + "}"
+}
+
+// QueryTemplate is a request built once with named placeholders in its filter
+// values, executed with different parameter values.
+type QueryTemplate struct {
+	builder *QueryRequestBuilder
+	params  []templateParam
+	names   []string
+}
+
+// templateParam is a query parameter value containing placeholders, split into
+// the literal parts around them: parts[0] names[0] parts[1] ... parts[len(names)].
+type templateParam struct {
+	key   string
+	index int
+	parts []string
+	names []string
+}
+
+// Template returns a template of the request. Placeholders are only substituted
+// in query parameters, such as filter values, not in the body or headers.
+func (b *QueryRequestBuilder) Template() *QueryTemplate {
+	t := &QueryTemplate{builder: b.Clone()}
+	seen := map[string]bool{}
+	for key, values := range t.builder.params {
+		for i, value := range values {
+			parts, names := splitPlaceholders(value)
+			if len(names) == 0 {
+				continue
+			}
+			t.params = append(t.params, templateParam{key: key, index: i, parts: parts, names: names})
+			for _, name := range names {
+				if !seen[name] {
+					seen[name] = true
+					t.names = append(t.names, name)
+				}
+			}
+		}
+	}
+	sort.Strings(t.names)
+	return t
+}
+
+// Names returns the sorted names of the parameters of the template.
+func (t *QueryTemplate) Names() []string {
+	return append([]string(nil), t.names...)
+}
+
+// Execute runs the template with the given parameter values using the client default timeout.
+func (t *QueryTemplate) Execute(params map[string]string, r interface{}) error {
+	ctx, cancel := t.builder.client.defaultContext()
+	defer cancel()
+	return t.ExecuteWithContext(ctx, params, r)
+}
+
+// ExecuteWithContext runs the template with the given parameter values. Values
+// are quoted like the values of filter methods such as Eq. Every parameter of
+// the template must be given.
+func (t *QueryTemplate) ExecuteWithContext(ctx context.Context, params map[string]string, r interface{}) error {
+	b, err := t.bind(params)
+	if err != nil {
+		return err
+	}
+	return b.ExecuteWithContext(ctx, r)
+}
+
+// bind returns a copy of the request with the placeholders replaced by the parameter values.
+func (t *QueryTemplate) bind(params map[string]string) (*QueryRequestBuilder, error) {
+	for _, name := range t.names {
+		if _, ok := params[name]; !ok {
+			return nil, fmt.Errorf("missing template parameter %q", name)
+		}
+	}
+
+	b := t.builder.Clone()
+	for _, param := range t.params {
+		var sb strings.Builder
+		for i, name := range param.names {
+			sb.WriteString(param.parts[i])
+			sb.WriteString(SanitizeParam(params[name]))
+		}
+		sb.WriteString(param.parts[len(param.names)])
+		b.params[param.key][param.index] = sb.String()
+	}
+	return b, nil
+}
+
+// splitPlaceholders splits a value into the literal parts around its placeholders and their names.
+func splitPlaceholders(value string) (parts []string, names []string) {
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			break
+		}
+		parts = append(parts, value[:start])
+		names = append(names, value[start+2:start+end])
+		value = value[start+end+1:]
+	}
+	return append(parts, value), names
+}
@@ -0,0 +1,57 @@
+package postgrest_go
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestQueryTemplate(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	tmpl := client.From("orders").Select("*").
+		Eq("user_id", Placeholder("user")).
+		In("status", []string{Placeholder("status"), "paid"}).
+		Template()
+	if names := tmpl.Names(); !reflect.DeepEqual(names, []string{"status", "user"}) {
+		t.Errorf("expected the template parameters status and user, got %v", names)
+	}
+
+	var orders []map[string]interface{}
+	for _, user := range []string{"1", "a,b"} {
+		if err := tmpl.ExecuteWithContext(context.Background(), map[string]string{"user": user, "status": "open"}, &orders); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if len(queries) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(queries))
+	}
+	if got := queries[0].Get("user_id"); got != "eq.1" {
+		t.Errorf("expected param user_id to be eq.1, got %s", got)
+	}
+	if got := queries[1].Get("user_id"); got != `eq."a,b"` {
+		t.Errorf("expected param user_id to be quoted, got %s", got)
+	}
+	if got := queries[1].Get("status"); got != "in.(open,paid)" {
+		t.Errorf("expected param status to be in.(open,paid), got %s", got)
+	}
+
+	if err := tmpl.ExecuteWithContext(context.Background(), map[string]string{"user": "1"}, &orders); err == nil {
+		t.Errorf("expected an error for a missing parameter")
+	}
+	if len(queries) != 2 {
+		t.Errorf("expected no request with a missing parameter")
+	}
+}
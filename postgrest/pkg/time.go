@@ -0,0 +1,209 @@
+package postgrest_go
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	timestamptzLayout = "2006-01-02T15:04:05.999999Z07:00"
+	timestampLayout   = "2006-01-02T15:04:05.999999"
+	dateLayout        = "2006-01-02"
+	timeOfDayLayout   = "15:04:05.999999"
+)
+
+// InfinityModifier tells whether a timestamp or date is finite or one of the Postgres infinity values.
+type InfinityModifier int8
+
+const (
+	Finite           InfinityModifier = 0
+	Infinity         InfinityModifier = 1
+	NegativeInfinity InfinityModifier = -1
+)
+
+func (m InfinityModifier) String() string {
+	switch m {
+	case Infinity:
+		return "infinity"
+	case NegativeInfinity:
+		return "-infinity"
+	}
+	return "finite"
+}
+
+// Timestamptz is a Postgres timestamptz value with microsecond precision.
+type Timestamptz struct {
+	Time             time.Time
+	InfinityModifier InfinityModifier
+}
+
+func (t Timestamptz) MarshalJSON() ([]byte, error) {
+	return marshalTime(t.Time, t.InfinityModifier, FormatTimestamptz)
+}
+
+func (t *Timestamptz) UnmarshalJSON(data []byte) error {
+	return unmarshalTime(data, &t.Time, &t.InfinityModifier, func(s string) (time.Time, error) {
+		s = strings.Replace(s, " ", "T", 1)
+		parsed, err := time.Parse(timestamptzLayout, s)
+		if err != nil {
+			// Postgres omits the minutes of whole hour offsets, e.g. +02
+			parsed, err = time.Parse("2006-01-02T15:04:05.999999Z07", s)
+		}
+		return parsed, err
+	})
+}
+
+// Timestamp is a Postgres timestamp (without time zone) value with microsecond precision. The time is in UTC.
+type Timestamp struct {
+	Time             time.Time
+	InfinityModifier InfinityModifier
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return marshalTime(t.Time, t.InfinityModifier, FormatTimestamp)
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	return unmarshalTime(data, &t.Time, &t.InfinityModifier, func(s string) (time.Time, error) {
+		return time.Parse(timestampLayout, strings.Replace(s, " ", "T", 1))
+	})
+}
+
+// Date is a Postgres date value. The time is midnight UTC.
+type Date struct {
+	Time             time.Time
+	InfinityModifier InfinityModifier
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return marshalTime(d.Time, d.InfinityModifier, FormatDate)
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	return unmarshalTime(data, &d.Time, &d.InfinityModifier, func(s string) (time.Time, error) {
+		return time.Parse(dateLayout, s)
+	})
+}
+
+// TimeOfDay is a Postgres time (without time zone) value, stored as the time elapsed since midnight.
+type TimeOfDay struct {
+	Duration time.Duration
+}
+
+func (t TimeOfDay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func (t *TimeOfDay) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	parsed, err := time.Parse(timeOfDayLayout, s)
+	if err != nil {
+		// 24:00:00 is a valid Postgres time
+		if s == "24:00:00" {
+			t.Duration = 24 * time.Hour
+			return nil
+		}
+		return err
+	}
+
+	t.Duration = parsed.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC))
+	return nil
+}
+
+func (t TimeOfDay) String() string {
+	d := t.Duration.Truncate(time.Microsecond)
+	if d == 24*time.Hour {
+		return "24:00:00"
+	}
+	return time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC).Add(d).Format(timeOfDayLayout)
+}
+
+// FormatTimestamptz formats t as a timestamptz literal in UTC with microsecond precision.
+func FormatTimestamptz(t time.Time) string {
+	return t.UTC().Truncate(time.Microsecond).Format(timestamptzLayout)
+}
+
+// FormatTimestamp formats the wall clock of t as a timestamp literal with microsecond precision.
+func FormatTimestamp(t time.Time) string {
+	return t.Truncate(time.Microsecond).Format(timestampLayout)
+}
+
+// FormatDate formats t as a date literal.
+func FormatDate(t time.Time) string {
+	return t.Format(dateLayout)
+}
+
+func marshalTime(t time.Time, modifier InfinityModifier, format func(time.Time) string) ([]byte, error) {
+	if modifier != Finite {
+		return json.Marshal(modifier.String())
+	}
+	return json.Marshal(format(t))
+}
+
+func unmarshalTime(data []byte, t *time.Time, modifier *InfinityModifier, parse func(string) (time.Time, error)) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	switch s {
+	case "infinity":
+		*t, *modifier = time.Time{}, Infinity
+		return nil
+	case "-infinity":
+		*t, *modifier = time.Time{}, NegativeInfinity
+		return nil
+	}
+
+	parsed, err := parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid time value %q: %w", s, err)
+	}
+	*t, *modifier = parsed, Finite
+	return nil
+}
+
+// EqTime adds an equality filter condition on a timestamptz column to the request.
+func (b *FilterRequestBuilder) EqTime(column string, value time.Time) *FilterRequestBuilder {
+	return b.Filter(column, "eq", FormatTimestamptz(value))
+}
+
+// NeqTime adds a not-equal filter condition on a timestamptz column to the request.
+func (b *FilterRequestBuilder) NeqTime(column string, value time.Time) *FilterRequestBuilder {
+	return b.Filter(column, "neq", FormatTimestamptz(value))
+}
+
+// GtTime adds a greater-than filter condition on a timestamptz column to the request.
+func (b *FilterRequestBuilder) GtTime(column string, value time.Time) *FilterRequestBuilder {
+	return b.Filter(column, "gt", FormatTimestamptz(value))
+}
+
+// GteTime adds a greater-than-or-equal filter condition on a timestamptz column to the request.
+func (b *FilterRequestBuilder) GteTime(column string, value time.Time) *FilterRequestBuilder {
+	return b.Filter(column, "gte", FormatTimestamptz(value))
+}
+
+// LtTime adds a less-than filter condition on a timestamptz column to the request.
+func (b *FilterRequestBuilder) LtTime(column string, value time.Time) *FilterRequestBuilder {
+	return b.Filter(column, "lt", FormatTimestamptz(value))
+}
+
+// LteTime adds a less-than-or-equal filter condition on a timestamptz column to the request.
+func (b *FilterRequestBuilder) LteTime(column string, value time.Time) *FilterRequestBuilder {
+	return b.Filter(column, "lte", FormatTimestamptz(value))
+}
+
+// EqDate adds an equality filter condition on a date column to the request.
+func (b *FilterRequestBuilder) EqDate(column string, value time.Time) *FilterRequestBuilder {
+	return b.Filter(column, "eq", FormatDate(value))
+}
@@ -0,0 +1,84 @@
+package postgrest_go
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTimestamptz_JSON(t *testing.T) {
+	tests := map[string]time.Time{
+		`"2024-01-02T03:04:05.123456+00:00"`: time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC),
+		`"2024-01-02 05:04:05+02"`:           time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	for data, want := range tests {
+		var got Timestamptz
+		if err := json.Unmarshal([]byte(data), &got); err != nil {
+			t.Fatalf("expected no error for %s, got %v", data, err)
+		}
+		if !got.Time.Equal(want) || got.InfinityModifier != Finite {
+			t.Errorf("expected %s to decode to %v, got %v", data, want, got.Time)
+		}
+	}
+
+	value := Timestamptz{Time: time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)}
+	if got, _ := json.Marshal(value); string(got) != `"2024-01-02T03:04:05.123456Z"` {
+		t.Errorf("expected %s, got %s", `"2024-01-02T03:04:05.123456Z"`, got)
+	}
+}
+
+func TestTimestamptz_Infinity(t *testing.T) {
+	var got Timestamptz
+	if err := json.Unmarshal([]byte(`"-infinity"`), &got); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.InfinityModifier != NegativeInfinity {
+		t.Errorf("expected InfinityModifier == %v, got %v", NegativeInfinity, got.InfinityModifier)
+	}
+
+	if data, _ := json.Marshal(Timestamptz{InfinityModifier: Infinity}); string(data) != `"infinity"` {
+		t.Errorf("expected %s, got %s", `"infinity"`, data)
+	}
+}
+
+func TestDate_JSON(t *testing.T) {
+	var got Date
+	if err := json.Unmarshal([]byte(`"2024-02-29"`), &got); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if want := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC); !got.Time.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got.Time)
+	}
+	if data, _ := json.Marshal(got); string(data) != `"2024-02-29"` {
+		t.Errorf("expected %s, got %s", `"2024-02-29"`, data)
+	}
+}
+
+func TestTimeOfDay_JSON(t *testing.T) {
+	var got TimeOfDay
+	if err := json.Unmarshal([]byte(`"13:45:30.000001"`), &got); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if want := 13*time.Hour + 45*time.Minute + 30*time.Second + time.Microsecond; got.Duration != want {
+		t.Errorf("expected %v, got %v", want, got.Duration)
+	}
+	if data, _ := json.Marshal(got); string(data) != `"13:45:30.000001"` {
+		t.Errorf("expected %s, got %s", `"13:45:30.000001"`, data)
+	}
+}
+
+func TestFilterRequestBuilder_TimeFilters(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"})
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+
+	builder := client.From("events").Select("*").GteTime("created_at", at).EqDate("day", at)
+
+	if got := builder.params.Get("created_at"); got != "gte.2024-01-02T02:04:05Z" {
+		t.Errorf("expected http param created_at == %s, got %s", "gte.2024-01-02T02:04:05Z", got)
+	}
+	if got := builder.params.Get("day"); got != "eq.2024-01-02" {
+		t.Errorf("expected http param day == %s, got %s", "eq.2024-01-02", got)
+	}
+}
@@ -0,0 +1,72 @@
+package postgrest_go
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// EqUUID adds an equality filter condition on a uuid column to the request. The value may be
+// any value formatting as a UUID with %v, such as a string or a uuid.UUID, and is validated and
+// normalized to lowercase; an invalid UUID is returned as an error on execution.
+func (b *FilterRequestBuilder) EqUUID(column string, value interface{}) *FilterRequestBuilder {
+	id, err := formatUUID(value)
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+		return b
+	}
+	return b.Filter(column, "eq", id)
+}
+
+// EqBool adds an equality filter condition on a boolean column to the request. It uses the is
+// operator, as recommended by PostgREST for booleans.
+func (b *FilterRequestBuilder) EqBool(column string, value bool) *FilterRequestBuilder {
+	return b.Filter(column, "is", strconv.FormatBool(value))
+}
+
+// EqInt adds an equality filter condition on an integer column to the request.
+func (b *FilterRequestBuilder) EqInt(column string, value int64) *FilterRequestBuilder {
+	return b.Filter(column, "eq", strconv.FormatInt(value, 10))
+}
+
+// EqFloat adds an equality filter condition on a floating point or numeric column to the request.
+func (b *FilterRequestBuilder) EqFloat(column string, value float64) *FilterRequestBuilder {
+	return b.Filter(column, "eq", formatFloat(value))
+}
+
+// formatUUID validates a UUID in its canonical 8-4-4-4-12 form and returns it in lowercase.
+func formatUUID(value interface{}) (string, error) {
+	s := strings.ToLower(fmt.Sprint(value))
+	if len(s) != 36 {
+		return "", fmt.Errorf("invalid UUID: %q", s)
+	}
+	for i, r := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return "", fmt.Errorf("invalid UUID: %q", s)
+			}
+		default:
+			if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f') {
+				return "", fmt.Errorf("invalid UUID: %q", s)
+			}
+		}
+	}
+	return s, nil
+}
+
+// formatFloat formats a float as a Postgres numeric literal without an exponent.
+func formatFloat(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "Infinity"
+	case math.IsInf(value, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
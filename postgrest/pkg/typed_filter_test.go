@@ -0,0 +1,42 @@
+package postgrest_go
+
+import (
+	"math"
+	"net/url"
+	"testing"
+)
+
+func TestFilterRequestBuilder_TypedFilters(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"})
+
+	builder := client.From("accounts").Select("*").
+		EqUUID("id", "6F9619FF-8B86-D011-B42D-00CF4FC964FF").
+		EqBool("active", true).
+		EqInt("age", 42).
+		EqFloat("score", 1e21)
+
+	expected := map[string]string{
+		"id":     "eq.6f9619ff-8b86-d011-b42d-00cf4fc964ff",
+		"active": "is.true",
+		"age":    "eq.42",
+		"score":  "eq.1000000000000000000000",
+	}
+	for param, value := range expected {
+		if got := builder.params.Get(param); got != value {
+			t.Errorf("expected http param %s == %s, got %s", param, value, got)
+		}
+	}
+
+	if got := formatFloat(math.Inf(-1)); got != "-Infinity" {
+		t.Errorf("expected formatted float == %s, got %s", "-Infinity", got)
+	}
+}
+
+func TestFilterRequestBuilder_EqUUIDError(t *testing.T) {
+	client := NewClient(url.URL{Scheme: "https", Host: "example.com"})
+
+	err := client.From("accounts").Select("*").EqUUID("id", "not-a-uuid").Execute(nil)
+	if err == nil {
+		t.Errorf("expected an error for an invalid UUID")
+	}
+}
@@ -2,6 +2,8 @@ package postgrest_go
 
 import (
 	"fmt"
+	"net/url"
+	"sort"
 	"strings"
 )
 
@@ -17,3 +19,80 @@ func SanitizeParam(param string) string {
 func SanitizePatternParam(pattern string) string {
 	return SanitizeParam(strings.ReplaceAll(pattern, "%", "*"))
 }
+
+func cloneValues(values url.Values) url.Values {
+	if values == nil {
+		return nil
+	}
+
+	cloned := make(url.Values, len(values))
+	for key, vals := range values {
+		cloned[key] = append([]string(nil), vals...)
+	}
+	return cloned
+}
+
+// encodeQuery encodes the values as a query string sorted by key, like
+// url.Values.Encode, but leaves the characters of the PostgREST syntax
+// readable. Characters with a meaning in query strings are escaped, so values
+// containing %, &, = or + reach PostgREST unchanged.
+func encodeQuery(values url.Values) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	size := 0
+	for key, vals := range values {
+		for _, value := range vals {
+			size += len(key) + len(value) + 2
+		}
+	}
+	var sb strings.Builder
+	sb.Grow(size)
+	for _, key := range keys {
+		for _, value := range values[key] {
+			if sb.Len() > 0 {
+				sb.WriteByte('&')
+			}
+			writeQueryEscaped(&sb, key)
+			sb.WriteByte('=')
+			writeQueryEscaped(&sb, value)
+		}
+	}
+	return sb.String()
+}
+
+const upperHex = "0123456789ABCDEF"
+
+// writeQueryEscaped percent-encodes s into sb, keeping unreserved characters
+// and the PostgREST syntax characters *,():
+func writeQueryEscaped(sb *strings.Builder, s string) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if shouldKeepInQuery(c) {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteByte('%')
+		sb.WriteByte(upperHex[c>>4])
+		sb.WriteByte(upperHex[c&15])
+	}
+}
+
+func shouldKeepInQuery(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	switch c {
+	case '-', '_', '.', '~', '*', ',', '(', ')', ':':
+		return true
+	}
+	return false
+}
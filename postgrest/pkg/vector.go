@@ -0,0 +1,80 @@
+package postgrest_go
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Vector is a pgvector embedding. It is encoded as a vector literal such as
+// "[0.1,0.2]", the format in which PostgREST returns vector columns.
+type Vector []float32
+
+func (v Vector) String() string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+func (v Vector) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON decodes a vector literal or a JSON array of numbers.
+func (v *Vector) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*v = nil
+		return nil
+	}
+
+	var literal string
+	if err := json.Unmarshal(data, &literal); err == nil {
+		data = []byte(literal)
+	}
+
+	var values []float32
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("invalid vector: %w", err)
+	}
+	*v = values
+	return nil
+}
+
+// MatchParams are the parameters of MatchDocuments.
+type MatchParams struct {
+	Embedding Vector
+	// Threshold is the minimum similarity of the returned rows
+	Threshold float64
+	// Count is the maximum number of returned rows
+	Count int
+	// Extra holds additional arguments of the function, e.g. a metadata filter
+	Extra map[string]interface{}
+}
+
+// MatchDocuments calls a similarity search function such as the match_documents
+// function of the Supabase vector guides, which takes query_embedding,
+// match_threshold and match_count arguments and returns rows ordered by
+// similarity, and decodes the rows into out.
+func (c *Client) MatchDocuments(ctx context.Context, function string, params MatchParams, out interface{}) error {
+	args := make(map[string]interface{}, len(params.Extra)+3)
+	for key, value := range params.Extra {
+		args[key] = value
+	}
+	args["query_embedding"] = params.Embedding
+	args["match_threshold"] = params.Threshold
+	args["match_count"] = params.Count
+
+	return c.Rpc(function, args).ExecuteWithContext(ctx, out)
+}
@@ -0,0 +1,60 @@
+package postgrest_go
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestVector_JSON(t *testing.T) {
+	data, err := json.Marshal(Vector{0.5, -1, 2e-3})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if string(data) != `"[0.5,-1,0.002]"` {
+		t.Errorf("expected JSON == %s, got %s", `"[0.5,-1,0.002]"`, data)
+	}
+
+	for _, input := range []string{`"[0.5,-1,0.002]"`, `[0.5,-1,0.002]`} {
+		var v Vector
+		if err := json.Unmarshal([]byte(input), &v); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(v) != 3 || v[0] != 0.5 || v[1] != -1 || v[2] != 0.002 {
+			t.Errorf("expected vector == [0.5 -1 0.002], got %v", v)
+		}
+	}
+}
+
+func TestClient_MatchDocuments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rpc/match_documents" {
+			t.Errorf("expected path == %s, got %s", "/rpc/match_documents", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if expected := `{"match_count":5,"match_threshold":0.8,"query_embedding":"[0.1,0.2]"}`; string(body) != expected {
+			t.Errorf("expected body == %s, got %s", expected, body)
+		}
+		w.Write([]byte(`[{"id":1,"similarity":0.9}]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	var rows []struct {
+		ID         int     `json:"id"`
+		Similarity float64 `json:"similarity"`
+	}
+	err := client.MatchDocuments(context.Background(), "match_documents", MatchParams{Embedding: Vector{0.1, 0.2}, Threshold: 0.8, Count: 5}, &rows)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(rows) != 1 || rows[0].Similarity != 0.9 {
+		t.Errorf("expected one row with similarity 0.9, got %v", rows)
+	}
+}
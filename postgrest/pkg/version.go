@@ -0,0 +1,37 @@
+package postgrest_go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// ErrStaleVersion is returned by UpdateIfVersion when no row has the expected version,
+// because the row was changed or deleted since it was read.
+var ErrStaleVersion = errors.New("stale version")
+
+// UpdateIfVersion sends an UPDATE request only applied to the rows whose
+// versionColumn equals expectedVersion, implementing optimistic locking. The
+// payload should set the next version:
+//
+//	doc.Version++
+//	err := client.From("docs").Update(doc).Eq("id", doc.ID).UpdateIfVersion(ctx, "version", doc.Version-1, &rows)
+//	if errors.Is(err, postgrest.ErrStaleVersion) {
+//		// reload the row and retry
+//	}
+func (b *FilterRequestBuilder) UpdateIfVersion(ctx context.Context, versionColumn string, expectedVersion int64, r interface{}) error {
+	if b.httpMethod != http.MethodPatch {
+		return errors.New("UpdateIfVersion can only be used on an Update request")
+	}
+
+	b.Eq(versionColumn, strconv.FormatInt(expectedVersion, 10))
+	affected, err := b.ExecuteWithAffectedContext(ctx, r)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrStaleVersion
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package postgrest_go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestFilterRequestBuilder_UpdateIfVersion(t *testing.T) {
+	affected := "0-0/1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if expected := "id=eq.1&version=eq.3"; r.URL.RawQuery != expected {
+			t.Errorf("expected query == %s, got %s", expected, r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Range", affected)
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	payload := map[string]interface{}{"title": "new", "version": 4}
+	if err := client.From("docs").Update(payload).Eq("id", "1").UpdateIfVersion(context.Background(), "version", 3, nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	affected = "*/0"
+	err := client.From("docs").Update(payload).Eq("id", "1").UpdateIfVersion(context.Background(), "version", 3, nil)
+	if !errors.Is(err, ErrStaleVersion) {
+		t.Errorf("expected ErrStaleVersion, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package postgrest_go
+
+// ViewRequestBuilder represents a read-only builder for requests to views and
+// materialized views. It has no Insert, Upsert, Update or Delete methods, so
+// writes to views that are not updatable are rejected at compile time.
+type ViewRequestBuilder struct {
+	builder *RequestBuilder
+}
+
+// FromView starts building a read-only request to the view with the given name.
+func (c *Client) FromView(view string) *ViewRequestBuilder {
+	return &ViewRequestBuilder{builder: c.From(view)}
+}
+
+// Clone returns a copy of the builder that shares no params or headers with it.
+func (b *ViewRequestBuilder) Clone() *ViewRequestBuilder {
+	return &ViewRequestBuilder{builder: b.builder.Clone()}
+}
+
+// Select starts building a SELECT request with the specified columns.
+func (b *ViewRequestBuilder) Select(columns ...string) *SelectRequestBuilder {
+	return b.builder.Select(columns...)
+}
+
+// Count starts building a HEAD request counting the rows of the view. The
+// result of the request is the count instead of rows.
+func (b *ViewRequestBuilder) Count() *SelectRequestBuilder {
+	return b.builder.Select("*").Count()
+}
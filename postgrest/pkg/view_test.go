@@ -0,0 +1,32 @@
+package postgrest_go
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestViewRequestBuilder_Count(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("expected method == %s, got %s", http.MethodHead, r.Method)
+		}
+		if r.URL.Path != "/example_view" {
+			t.Errorf("expected path == %s, got %s", "/example_view", r.URL.Path)
+		}
+		w.Header().Set("Content-Range", "*/42")
+	}))
+	defer server.Close()
+
+	baseURL, _ := url.Parse(server.URL + "/")
+	client := NewClient(*baseURL)
+
+	var count int
+	if err := client.FromView("example_view").Count().Eq("active", "true").Execute(&count); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if count != 42 {
+		t.Errorf("expected count == %d, got %d", 42, count)
+	}
+}
@@ -0,0 +1,118 @@
+package supabase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProviderTokenURLs are the OAuth token endpoints of common providers, for
+// refreshing provider tokens with RefreshProviderToken.
+var ProviderTokenURLs = map[string]string{
+	"google":    "https://oauth2.googleapis.com/token",
+	"github":    "https://github.com/login/oauth/access_token",
+	"gitlab":    "https://gitlab.com/oauth/token",
+	"azure":     "https://login.microsoftonline.com/common/oauth2/v2.0/token",
+	"discord":   "https://discord.com/api/oauth2/token",
+	"spotify":   "https://accounts.spotify.com/api/token",
+	"twitch":    "https://id.twitch.tv/oauth2/token",
+	"bitbucket": "https://bitbucket.org/site/oauth2/access_token",
+}
+
+// ProviderToken is an OAuth token issued by a provider such as Google or
+// GitHub, used to call the provider API on behalf of the user.
+type ProviderToken struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	Scope        string `json:"scope"`
+}
+
+// ProviderTokens returns the provider tokens of a session established with an
+// OAuth sign in, or nil if the session has none. GoTrue only returns them
+// right after the sign in, so they must be stored by the application.
+func (d *AuthenticatedDetails) ProviderTokens() *ProviderToken {
+	if d.ProviderToken == "" {
+		return nil
+	}
+	return &ProviderToken{AccessToken: d.ProviderToken, RefreshToken: d.ProviderRefreshToken}
+}
+
+// RefreshProviderTokenParams contains the parameters of a provider token refresh.
+type RefreshProviderTokenParams struct {
+	// Provider selects the token endpoint from ProviderTokenURLs when TokenURL is empty
+	Provider string
+	TokenURL string
+	// ClientID and ClientSecret are the credentials of the OAuth app configured for the provider
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+}
+
+// RefreshProviderToken exchanges a provider refresh token for a new provider
+// token at the token endpoint of the provider. GoTrue does not refresh
+// provider tokens, so the request is sent to the provider directly and never
+// carries the Supabase API key.
+func (a *Auth) RefreshProviderToken(ctx context.Context, params RefreshProviderTokenParams) (*ProviderToken, error) {
+	tokenURL := params.TokenURL
+	if tokenURL == "" {
+		tokenURL = ProviderTokenURLs[params.Provider]
+	}
+	if tokenURL == "" {
+		return nil, fmt.Errorf("no token URL known for provider %q", params.Provider)
+	}
+	if params.RefreshToken == "" {
+		return nil, fmt.Errorf("provider refresh token is required")
+	}
+
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {params.RefreshToken},
+		"client_id":     {params.ClientID},
+		"client_secret": {params.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	// the provider is not a Supabase service, so the request bypasses the
+	// signer and circuit breakers wrapping the transport of the client
+	client := &http.Client{Transport: a.client.transport, Timeout: time.Minute}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+	body, err := a.client.readBody(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// GitHub reports errors with a 200 status code
+	var errRes AuthError
+	if a.client.unmarshalJSON(body, &errRes) == nil && errRes.Code != "" {
+		errRes.StatusCode = res.StatusCode
+		return nil, &errRes
+	}
+	if res.StatusCode < http.StatusOK || res.StatusCode >= 300 {
+		return nil, newHTTPError(res, body)
+	}
+
+	token := ProviderToken{}
+	if err := a.client.unmarshalJSON(body, &token); err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		// providers without refresh token rotation keep the previous one valid
+		token.RefreshToken = params.RefreshToken
+	}
+	return &token, nil
+}
@@ -0,0 +1,154 @@
+package supabase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	postgrest "github.com/nedpals/supabase-go/postgrest/pkg"
+)
+
+// Service is a Supabase service addressed by RequestSpec.
+type Service string
+
+const (
+	ServiceAuth      Service = "auth"
+	ServiceRest      Service = "rest"
+	ServiceStorage   Service = "storage"
+	ServiceFunctions Service = "functions"
+	ServiceGraphQL   Service = "graphql"
+)
+
+// RequestSpec describes a raw request sent with Client.Do.
+type RequestSpec struct {
+	Service Service
+	Method  string
+	// Path is relative to the URL of the service, e.g. "/object/info/avatars/a.png"
+	Path  string
+	Query url.Values
+	// Body is sent as is if it is an io.Reader and encoded as JSON otherwise,
+	// no body is sent if nil
+	Body interface{}
+	// Headers are added to the request, overriding the default headers
+	Headers map[string]string
+}
+
+// Do sends a request to an endpoint of a Supabase service without a dedicated
+// method. The apikey and Authorization headers are set like for the other
+// requests of the service and the JSON response is decoded into out if not
+// nil. Error responses are returned as the error type of the service:
+// *AuthError, *FileErrorResponse or *postgrest.RequestError, and *HTTPError
+// for other services or responses that are not JSON.
+func (c *Client) Do(ctx context.Context, spec RequestSpec, out interface{}) error {
+	serviceURL, err := c.serviceURLOf(spec.Service)
+	if err != nil {
+		return err
+	}
+
+	var reqBody io.Reader
+	isJSON := false
+	switch body := spec.Body.(type) {
+	case nil:
+	case io.Reader:
+		reqBody = body
+	default:
+		data, err := c.marshalJSON(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+		isJSON = true
+	}
+
+	reqURL := serviceURL + "/" + strings.TrimPrefix(spec.Path, "/")
+	if len(spec.Query) > 0 {
+		reqURL += "?" + spec.Query.Encode()
+	}
+	method := spec.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return err
+	}
+
+	if isJSON {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.injectAuthHeaders(req)
+	for key, value := range spec.Headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	body, err := c.readBody(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= 300 {
+		return decodeServiceError(spec.Service, res, body)
+	}
+	if out == nil || res.StatusCode == http.StatusNoContent || len(body) == 0 {
+		return nil
+	}
+	if err := c.unmarshalJSON(body, out); err != nil {
+		if !isJSONResponse(res) {
+			return newHTTPError(res, body)
+		}
+		return &DecodeError{Err: err}
+	}
+	return nil
+}
+
+// serviceURLOf returns the URL of a service, without a trailing slash.
+func (c *Client) serviceURLOf(service Service) (string, error) {
+	switch service {
+	case ServiceAuth:
+		return c.authURL(), nil
+	case ServiceRest:
+		return c.restURL(), nil
+	case ServiceStorage:
+		return c.storageURL(), nil
+	case ServiceFunctions:
+		return c.functionsURL(), nil
+	case ServiceGraphQL:
+		return c.graphqlURL(), nil
+	}
+	return "", fmt.Errorf("unknown service %q", service)
+}
+
+// decodeServiceError decodes an error response in the error format of the service.
+func decodeServiceError(service Service, res *http.Response, body []byte) error {
+	if !isJSONResponse(res) {
+		return newHTTPError(res, body)
+	}
+
+	var err error
+	switch service {
+	case ServiceAuth:
+		err = &AuthError{}
+	case ServiceStorage:
+		err = &FileErrorResponse{}
+	case ServiceRest:
+		err = &postgrest.RequestError{HTTPStatusCode: res.StatusCode}
+	default:
+		return newHTTPError(res, body)
+	}
+	if json.Unmarshal(body, err) != nil {
+		return newHTTPError(res, body)
+	}
+	setErrorStatus(err, res.StatusCode)
+	return err
+}
@@ -0,0 +1,109 @@
+package supabase
+
+import (
+	"context"
+	"time"
+)
+
+// sessionRefreshMargin is how long before its expiry a session is refreshed.
+const sessionRefreshMargin = 30 * time.Second
+
+// refreshCall is a session refresh in flight, shared by all goroutines
+// needing a fresh session at the same time.
+type refreshCall struct {
+	done    chan struct{}
+	session *AuthenticatedDetails
+	err     error
+}
+
+// setSession wires a newly established session when WithAutoSessionToken is enabled.
+func (c *Client) setSession(details *AuthenticatedDetails) {
+	if !c.autoSessionToken {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken = details.AccessToken
+	c.session = details
+	c.sessionExpiresAt = time.Time{}
+	if details.ExpiresIn > 0 {
+		c.sessionExpiresAt = time.Now().Add(time.Duration(details.ExpiresIn) * time.Second)
+	}
+}
+
+// clearSession reverts to the API key if the given token belongs to the wired session.
+func (c *Client) clearSession(token string) {
+	if !c.autoSessionToken {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.accessToken == token {
+		c.accessToken = ""
+		c.session = nil
+	}
+}
+
+// Session returns the session established through Auth when
+// WithAutoSessionToken is enabled, refreshing it first if it is about to
+// expire. It returns nil if there is no session. Concurrent calls share a
+// single refresh request.
+func (a *Auth) Session(ctx context.Context) (*AuthenticatedDetails, error) {
+	c := a.client
+	c.mu.RLock()
+	session, expiresAt := c.session, c.sessionExpiresAt
+	c.mu.RUnlock()
+
+	if session == nil || expiresAt.IsZero() || time.Until(expiresAt) > sessionRefreshMargin {
+		return session, nil
+	}
+	return c.refreshSession(ctx, session)
+}
+
+// refreshSession refreshes the session unless another goroutine already did.
+// Only one refresh request is in flight, the other callers wait for its result.
+func (c *Client) refreshSession(ctx context.Context, stale *AuthenticatedDetails) (*AuthenticatedDetails, error) {
+	c.mu.Lock()
+	if c.session != stale {
+		session := c.session
+		c.mu.Unlock()
+		return session, nil
+	}
+
+	call := c.refreshing
+	if call == nil {
+		call = &refreshCall{done: make(chan struct{})}
+		c.refreshing = call
+		c.mu.Unlock()
+
+		// the refresh is shared, so it must not be canceled with the context
+		// of the goroutine that happened to start it
+		call.session, call.err = c.Auth.RefreshUser(context.WithoutCancel(ctx), stale.AccessToken, stale.RefreshToken)
+
+		c.mu.Lock()
+		c.refreshing = nil
+		c.mu.Unlock()
+		close(call.done)
+	} else {
+		c.mu.Unlock()
+	}
+
+	select {
+	case <-call.done:
+		return call.session, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// sessionToken returns the bearer token for DB, Storage and Functions
+// requests, refreshing the session if needed. When the refresh fails the
+// current token is used and the request fails with the error of the server.
+func (c *Client) sessionToken(ctx context.Context) string {
+	if session, err := c.Auth.Session(ctx); err == nil && session != nil {
+		return session.AccessToken
+	}
+	return c.authToken()
+}
@@ -0,0 +1,118 @@
+package supabase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// newRefreshServer returns a client with an expiring session, wired to a fake
+// GoTrue server which holds the refresh requests until release is closed.
+func newRefreshServer(t *testing.T) (client *Client, refreshes *atomic.Int32, received chan struct{}, release chan struct{}) {
+	refreshes = new(atomic.Int32)
+	received = make(chan struct{}, 10)
+	release = make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+AuthEndpoint+"/token" || r.URL.Query().Get("grant_type") != "refresh_token" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"refresh_token":"stale-refresh"`) {
+			t.Errorf("expected the refresh token of the session, got %s", body)
+		}
+		refreshes.Add(1)
+		received <- struct{}{}
+		<-release
+		w.Write([]byte(`{"access_token":"fresh-token","expires_in":3600,"refresh_token":"fresh-refresh"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client = NewClient(server.URL, "key", WithAutoSessionToken())
+	// the session expires within the refresh margin
+	client.setSession(&AuthenticatedDetails{AccessToken: "stale-token", RefreshToken: "stale-refresh", ExpiresIn: 10})
+	return client, refreshes, received, release
+}
+
+func TestAuth_SessionSingleRefresh(t *testing.T) {
+	client, refreshes, received, release := newRefreshServer(t)
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	tokens := make(chan string, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session, err := client.Auth.Session(context.Background())
+			if err != nil {
+				errs <- err
+				return
+			}
+			tokens <- session.AccessToken
+		}()
+	}
+
+	<-received
+	close(release)
+	wg.Wait()
+	close(errs)
+	close(tokens)
+
+	for err := range errs {
+		t.Errorf("Session error = %v", err)
+	}
+	for token := range tokens {
+		if token != "fresh-token" {
+			t.Errorf("expected the refreshed session, got token %q", token)
+		}
+	}
+	if got := refreshes.Load(); got != 1 {
+		t.Errorf("expected a single refresh request, got %d", got)
+	}
+
+	// the refreshed session no longer needs a refresh
+	if session, err := client.Auth.Session(context.Background()); err != nil || session.AccessToken != "fresh-token" {
+		t.Errorf("Session after the refresh = %v, %v, want the refreshed session", session, err)
+	}
+	if got := refreshes.Load(); got != 1 {
+		t.Errorf("expected no refresh of a fresh session, got %d requests", got)
+	}
+}
+
+func TestAuth_SessionRefreshCanceledWaiter(t *testing.T) {
+	client, refreshes, received, release := newRefreshServer(t)
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.Auth.Session(context.Background())
+		first <- err
+	}()
+	<-received
+
+	// a waiter gives up with its context while the refresh goes on
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.Auth.Session(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled waiter error = %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatalf("refresh error = %v", err)
+	}
+	if session, _ := client.Auth.Session(context.Background()); session == nil || session.AccessToken != "fresh-token" {
+		t.Errorf("expected the refreshed session, got %v", session)
+	}
+	if got := refreshes.Load(); got != 1 {
+		t.Errorf("expected a single refresh request, got %d", got)
+	}
+}
@@ -0,0 +1,37 @@
+package supabase
+
+import "net/http"
+
+// RequestSigner adds authentication to a request before it is sent, e.g. a
+// header with an HMAC of the path and body.
+type RequestSigner func(req *http.Request) error
+
+// WithRequestSigner signs every request sent by the client, including DB,
+// storage and functions requests, for deployments fronted by a custom API
+// gateway requiring additional authentication. The signer is called with a
+// copy of the request after all other headers are set and may modify its
+// headers. The body must be read from req.GetBody so it is not consumed;
+// GetBody is nil for streamed bodies such as uploads. A signer error fails
+// the request.
+func WithRequestSigner(signer RequestSigner) ClientOption {
+	return func(c *Client) {
+		c.signer = signer
+	}
+}
+
+// signerTransport signs requests with the signer of WithRequestSigner.
+type signerTransport struct {
+	signer RequestSigner
+	parent http.RoundTripper
+}
+
+func (t *signerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if err := t.signer(req); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.parent.RoundTrip(req)
+}
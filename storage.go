@@ -3,14 +3,20 @@ package supabase
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
-	"encoding/json"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Storage struct {
@@ -39,30 +45,40 @@ type BucketOption struct {
 	Public bool   `json:"public"`
 }
 
-type storageError struct {
-	Err     string `json:"error"`
-	Message string `json:"message"`
-}
+var (
+	ErrNotFound            = errors.New("file not found")
+	ErrObjectAlreadyExists = errors.New("object already exists")
+)
 
-var ErrNotFound = errors.New("file not found")
+// sendStorageRequest sends a request to the storage API, returning error
+// responses as a *FileErrorResponse.
+func (c *Client) sendStorageRequest(req *http.Request, v interface{}) error {
+	var errRes FileErrorResponse
+	hasCustomError, err := c.sendCustomRequest(req, v, &errRes)
+	if err != nil {
+		return err
+	} else if hasCustomError {
+		return &errRes
+	}
+	return nil
+}
 
 // CreateBucket creates a new storage bucket
 // @param: option:  a bucketOption with the name and id of the bucket you want to create
 // @returns: bucket: a response with the details of the bucket of the bucket created
 func (s *Storage) CreateBucket(ctx context.Context, option BucketOption) (*bucket, error) {
-	reqBody, _ := json.Marshal(option)
-	reqURL := fmt.Sprintf("%s/%s/bucket", s.client.BaseURL, StorageEndpoint)
+	reqBody, _ := s.client.marshalJSON(option)
+	reqURL := fmt.Sprintf("%s/bucket", s.client.storageURL())
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	injectAuthorizationHeader(req, s.client.apiKey)
+	s.client.injectAuthHeaders(req)
 	res := bucket{}
-	errRes := storageError{}
-	if err := s.client.sendRequest(req, &res); err != nil {
-		return nil, fmt.Errorf("%s\n%s", errRes.Err, errRes.Message)
+	if err := s.client.sendStorageRequest(req, &res); err != nil {
+		return nil, err
 	}
 
 	return &res, nil
@@ -73,18 +89,17 @@ func (s *Storage) CreateBucket(ctx context.Context, option BucketOption) (*bucke
 // @returns: bucketResponse: a response with the details of the bucket
 func (s *Storage) GetBucket(ctx context.Context, id string) (*bucketResponse, error) {
 	// reqBody, _ := json.Marshal()
-	reqURL := fmt.Sprintf("%s/%s/bucket/%s", s.client.BaseURL, StorageEndpoint, id)
+	reqURL := fmt.Sprintf("%s/bucket/%s", s.client.storageURL(), id)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	injectAuthorizationHeader(req, s.client.apiKey)
+	s.client.injectAuthHeaders(req)
 	res := bucketResponse{}
-	errRes := storageError{}
-	if err := s.client.sendRequest(req, &res); err != nil {
-		return nil, fmt.Errorf("%s \n %s", errRes.Err, errRes.Message)
+	if err := s.client.sendStorageRequest(req, &res); err != nil {
+		return nil, err
 	}
 
 	return &res, nil
@@ -92,23 +107,22 @@ func (s *Storage) GetBucket(ctx context.Context, id string) (*bucketResponse, er
 
 // ListBucket retrieves all buckets ina supabase storage
 // @returns: []bucketResponse: a response with the details of all the bucket
-func (s *Storage) ListBuckets(ctx context.Context) (*[]bucketResponse, error) {
+func (s *Storage) ListBuckets(ctx context.Context) ([]bucketResponse, error) {
 	// reqBody, _ := json.Marshal()
-	reqURL := fmt.Sprintf("%s/%s/bucket/", s.client.BaseURL, StorageEndpoint)
+	reqURL := fmt.Sprintf("%s/bucket/", s.client.storageURL())
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	injectAuthorizationHeader(req, s.client.apiKey)
+	s.client.injectAuthHeaders(req)
 	res := []bucketResponse{}
-	errRes := storageError{}
-	if err := s.client.sendRequest(req, &res); err != nil {
-		return nil, fmt.Errorf("%s \n %s", errRes.Err, errRes.Message)
+	if err := s.client.sendStorageRequest(req, &res); err != nil {
+		return nil, err
 	}
 
-	return &res, nil
+	return res, nil
 }
 
 // EmptyBucket  empties the object of a bucket by id
@@ -116,41 +130,89 @@ func (s *Storage) ListBuckets(ctx context.Context) (*[]bucketResponse, error) {
 // @returns bucketMessage: a successful response message or failed
 func (s *Storage) EmptyBucket(ctx context.Context, id string) (*bucketMessage, error) {
 	// reqBody, _ := json.Marshal()
-	reqURL := fmt.Sprintf("%s/%s/bucket/%s/empty", s.client.BaseURL, StorageEndpoint, id)
+	reqURL := fmt.Sprintf("%s/bucket/%s/empty", s.client.storageURL(), id)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	injectAuthorizationHeader(req, s.client.apiKey)
+	s.client.injectAuthHeaders(req)
 	res := bucketMessage{}
-	errRes := storageError{}
-	if err := s.client.sendRequest(req, &res); err != nil {
-		return nil, fmt.Errorf("%s \n %s", errRes.Err, errRes.Message)
+	if err := s.client.sendStorageRequest(req, &res); err != nil {
+		return nil, err
 	}
 
 	return &res, nil
 }
 
+// EmptyBucketOptions controls EmptyBucketWithOptions.
+type EmptyBucketOptions struct {
+	// DryRun lists the objects that would be deleted without deleting them
+	DryRun bool
+	// Progress is called after each batch of deleted objects with the number of
+	// objects deleted so far and the number of objects in the bucket
+	Progress func(deleted int, total int)
+}
+
+// EmptyBucketWithProgress empties a bucket by id like EmptyBucket, deleting its
+// objects in batches and calling progress after each batch. It returns the
+// number of deleted objects.
+func (s *Storage) EmptyBucketWithProgress(ctx context.Context, id string, progress func(deleted int, total int)) (int, error) {
+	paths, err := s.EmptyBucketWithOptions(ctx, id, EmptyBucketOptions{Progress: progress})
+	return len(paths), err
+}
+
+// EmptyBucketWithOptions lists the objects of a bucket by id and deletes them in
+// batches. It returns the paths of the deleted objects, or of the objects that
+// would be deleted with DryRun. When a batch fails, the paths deleted before it
+// are returned with the error.
+func (s *Storage) EmptyBucketWithOptions(ctx context.Context, id string, opts EmptyBucketOptions) ([]string, error) {
+	f := s.From(id)
+	paths, err := f.listRecursive(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		return paths, nil
+	}
+
+	deleted := 0
+	for deleted < len(paths) {
+		end := deleted + removeBatchSize
+		if end > len(paths) {
+			end = len(paths)
+		}
+
+		if err := f.removeBatch(ctx, paths[deleted:end]); err != nil {
+			return paths[:deleted], err
+		}
+		deleted = end
+		if opts.Progress != nil {
+			opts.Progress(deleted, len(paths))
+		}
+	}
+
+	return paths, nil
+}
+
 // UpdateBucket updates a bucket by its id
 // @param: id:  the id of the bucket
 // @param: option:  the options to be updated
 // @returns bucketMessage: a successful response message or failed
 func (s *Storage) UpdateBucket(ctx context.Context, id string, option BucketOption) (*bucketMessage, error) {
-	reqBody, _ := json.Marshal(option)
-	reqURL := fmt.Sprintf("%s/%s/bucket/%s", s.client.BaseURL, StorageEndpoint, id)
+	reqBody, _ := s.client.marshalJSON(option)
+	reqURL := fmt.Sprintf("%s/bucket/%s", s.client.storageURL(), id)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPut, reqURL, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	injectAuthorizationHeader(req, s.client.apiKey)
+	s.client.injectAuthHeaders(req)
 	res := bucketMessage{}
-	errRes := storageError{}
-	if err := s.client.sendRequest(req, &res); err != nil {
-		return nil, fmt.Errorf("%s \n %s", errRes.Err, errRes.Message)
+	if err := s.client.sendStorageRequest(req, &res); err != nil {
+		return nil, err
 	}
 
 	return &res, nil
@@ -161,23 +223,33 @@ func (s *Storage) UpdateBucket(ctx context.Context, id string, option BucketOpti
 // @returns bucketMessage: a successful response message or failed
 func (s *Storage) DeleteBucket(ctx context.Context, id string) (*bucketResponse, error) {
 	// reqBody, _ := json.Marshal()
-	reqURL := fmt.Sprintf("%s/%s/bucket/%s", s.client.BaseURL, StorageEndpoint, id)
+	reqURL := fmt.Sprintf("%s/bucket/%s", s.client.storageURL(), id)
 	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, reqURL, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	injectAuthorizationHeader(req, s.client.apiKey)
+	s.client.injectAuthHeaders(req)
 	res := bucketResponse{}
-	errRes := storageError{}
-	if err := s.client.sendRequest(req, &res); err != nil {
-		return nil, fmt.Errorf("%s\n%s", errRes.Err, errRes.Message)
+	if err := s.client.sendStorageRequest(req, &res); err != nil {
+		return nil, err
 	}
 
 	return &res, nil
 }
 
+// DeleteBucketForce empties a bucket by its id and then deletes it
+// @param: id:  the id of the bucket
+// @returns bucketMessage: a successful response message or failed
+func (s *Storage) DeleteBucketForce(ctx context.Context, id string) (*bucketResponse, error) {
+	if _, err := s.EmptyBucket(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.DeleteBucket(ctx, id)
+}
+
 func (s *Storage) From(bucketId string) *file {
 	return &file{BucketId: bucketId, storage: s}
 }
@@ -187,6 +259,22 @@ func (s *Storage) From(bucketId string) *file {
 type file struct {
 	BucketId string
 	storage  *Storage
+	timeout  time.Duration
+}
+
+// WithTimeout returns a copy of the file API whose requests time out after d
+func (f *file) WithTimeout(d time.Duration) *file {
+	c := *f
+	c.timeout = d
+	return &c
+}
+
+// withTimeout bounds ctx by the timeout set with WithTimeout
+func (f *file) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if f.timeout > 0 {
+		return context.WithTimeout(ctx, f.timeout)
+	}
+	return context.WithCancel(ctx)
 }
 
 type SortBy struct {
@@ -196,9 +284,17 @@ type SortBy struct {
 
 type FileResponse struct {
 	Key     string `json:"key"`
+	Id      string `json:"id"`
 	Message string `json:"message"`
 }
 
+type FileMoveOptions struct {
+	// DestinationBucket is the bucket of the destination object, defaults to the current bucket
+	DestinationBucket string
+	// Overwrite replaces the destination object if it already exists
+	Overwrite bool
+}
+
 type FileErrorResponse struct {
 	Status     string `json:"statusCode"`
 	ShortError string `json:"error"`
@@ -209,10 +305,36 @@ func (err *FileErrorResponse) Error() string {
 	return err.ShortError + ": " + err.Message
 }
 
-type FileSearchOptions struct {
-	Limit  int    `json:"limit"`
-	Offset int    `json:"offset"`
-	SortBy SortBy `json:"sortBy"`
+// HTTPStatus returns the HTTP status code of the response, 0 if unknown.
+func (err *FileErrorResponse) HTTPStatus() int {
+	status, _ := strconv.Atoi(err.Status)
+	return status
+}
+
+// fileError decodes the body of an error response of the storage API. Bodies
+// that are not a JSON error are returned as an *HTTPError. Conflicts wrap
+// ErrObjectAlreadyExists and missing objects or buckets wrap ErrNotFound.
+func (f *file) fileError(res *http.Response, body []byte) error {
+	var resErr FileErrorResponse
+	if err := f.storage.client.unmarshalJSON(body, &resErr); err != nil || (resErr.ShortError == "" && resErr.Message == "") {
+		return newHTTPError(res, body)
+	}
+	setErrorStatus(&resErr, res.StatusCode)
+
+	switch {
+	case resErr.Status == "409" || resErr.ShortError == "Duplicate":
+		return fmt.Errorf("%w: %w", ErrObjectAlreadyExists, &resErr)
+	case resErr.Status == "404" || res.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrNotFound, &resErr)
+	}
+	return &resErr
+}
+
+// ListOptions controls the listing of file objects. Nil fields use the defaults.
+type ListOptions struct {
+	Limit  *int
+	Offset *int
+	SortBy *SortBy
 }
 
 type FileObject struct {
@@ -224,6 +346,7 @@ type FileObject struct {
 	CreatedAt      string      `json:"created_at"`
 	LastAccessedAt string      `json:"last_accessed_at"`
 	Metadata       interface{} `json:"metadata"`
+	UserMetadata   JSONMap     `json:"user_metadata"`
 	Buckets        bucket      `json:"buckets"`
 }
 
@@ -236,6 +359,10 @@ type ListFileRequest struct {
 
 type SignedUrlResponse struct {
 	SignedUrl string `json:"signedURL"`
+	// Token is the token part of the signed url
+	Token string `json:"-"`
+	// ExpiresAt is the time after which the signed url is no longer valid
+	ExpiresAt time.Time `json:"-"`
 }
 
 const (
@@ -254,9 +381,49 @@ type FileUploadOptions struct {
 	ContentType  string
 	MimeType     string
 	Upsert       bool
+	// Metadata is stored as the user metadata of the file object
+	Metadata JSONMap
 }
 
+// UploadOrUpdate uploads a file object, or replaces it if update is true.
+// Errors are only reported in the Message of the response, use
+// UploadWithContext or UpdateWithContext to get them.
 func (f *file) UploadOrUpdate(path string, data io.Reader, update bool, opts *FileUploadOptions) FileResponse {
+	ctx, cancel := f.withTimeout(context.Background())
+	defer cancel()
+
+	response, err := f.upload(ctx, path, data, update, opts)
+	return responseWithError(response, err)
+}
+
+// UploadWithContext uploads a file object to a storage bucket.
+func (f *file) UploadWithContext(ctx context.Context, path string, data io.Reader, opts *FileUploadOptions) (FileResponse, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	return f.upload(ctx, path, data, false, opts)
+}
+
+// UpdateWithContext replaces a file object in a storage bucket.
+func (f *file) UpdateWithContext(ctx context.Context, path string, data io.Reader, opts *FileUploadOptions) (FileResponse, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	return f.upload(ctx, path, data, true, opts)
+}
+
+// responseWithError reports err in the Message of the response of the file
+// methods without an error result, unless the error response already has one.
+func responseWithError(response FileResponse, err error) FileResponse {
+	if err != nil && response.Message == "" {
+		response.Message = err.Error()
+	}
+	return response
+}
+
+// upload sends a file object. Error responses are returned as an error along
+// with the response decoded from their body.
+func (f *file) upload(ctx context.Context, path string, data io.Reader, update bool, opts *FileUploadOptions) (FileResponse, error) {
 	// use default options, then override with whatever is passed in opts
 	mergedOpts := FileUploadOptions{
 		CacheControl: defaultFileCacheControl,
@@ -277,53 +444,58 @@ func (f *file) UploadOrUpdate(path string, data io.Reader, update bool, opts *Fi
 		}
 
 		mergedOpts.Upsert = opts.Upsert
+		mergedOpts.Metadata = opts.Metadata
 	}
 
 	body := bufio.NewReader(data)
 	_path := removeEmptyFolder(f.BucketId + "/" + path)
-	client := &http.Client{}
-
-	var (
-		method string
-		req    *http.Request
-		res    *http.Response
-		err    error
-	)
+	client := &http.Client{Transport: f.storage.client.roundTripper}
 
+	method := http.MethodPost
 	if update {
 		method = http.MethodPut
-	} else {
-		method = http.MethodPost
 	}
 
-	reqURL := fmt.Sprintf("%s/%s/object/%s", f.storage.client.BaseURL, StorageEndpoint, _path)
-	req, err = http.NewRequest(method, reqURL, body)
+	reqURL := fmt.Sprintf("%s/object/%s", f.storage.client.storageURL(), _path)
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
 	if err != nil {
-		panic(err)
+		return FileResponse{}, err
 	}
 
-	injectAuthorizationHeader(req, f.storage.client.apiKey)
+	f.storage.client.injectAuthHeaders(req)
 	req.Header.Set("cache-control", mergedOpts.CacheControl)
 	req.Header.Set("content-type", mergedOpts.ContentType)
 	req.Header.Set("mime-type", mergedOpts.MimeType)
 	req.Header.Set("x-upsert", strconv.FormatBool(mergedOpts.Upsert))
+	if mergedOpts.Metadata != nil {
+		metadata, err := f.storage.client.marshalJSON(mergedOpts.Metadata)
+		if err != nil {
+			return FileResponse{}, err
+		}
+		req.Header.Set("x-metadata", base64.StdEncoding.EncodeToString(metadata))
+	}
 
-	res, err = client.Do(req)
+	res, err := client.Do(req)
 	if err != nil {
-		panic(err)
+		return FileResponse{}, err
 	}
 
+	defer res.Body.Close()
 	resBody, err := io.ReadAll(res.Body)
 	if err != nil {
-		panic(err)
+		return FileResponse{}, err
 	}
 
 	var response FileResponse
-	if err = json.Unmarshal(resBody, &response); err != nil {
-		panic(err)
+	decodeErr := f.storage.client.unmarshalJSON(resBody, &response)
+	if res.StatusCode < http.StatusOK || res.StatusCode >= 300 {
+		return response, f.fileError(res, resBody)
+	}
+	if decodeErr != nil {
+		return FileResponse{}, &DecodeError{Err: decodeErr}
 	}
 
-	return response
+	return response, nil
 }
 
 // Update updates a file object in a storage bucket
@@ -337,247 +509,722 @@ func (f *file) Upload(path string, data io.Reader, opts *FileUploadOptions) File
 }
 
 // Move moves a file object
-func (f *file) Move(fromPath string, toPath string) FileResponse {
-	_json, _ := json.Marshal(map[string]interface{}{
-		"bucketId":      f.BucketId,
-		"sourceKey":     fromPath,
-		"destintionKey": toPath,
-	})
-
-	reqURL := fmt.Sprintf("%s/%s/object/move", f.storage.client.BaseURL, StorageEndpoint)
-	req, err := http.NewRequest(http.MethodPost, reqURL, bytes.NewBuffer(_json))
-	if err != nil {
-		panic(err)
-	}
-
-	injectAuthorizationHeader(req, f.storage.client.apiKey)
-
-	client := &http.Client{}
-	res, err := client.Do(req)
-	if err != nil {
-		panic(err)
-	}
-
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		panic(err)
-	}
+func (f *file) Move(fromPath string, toPath string, opts *FileMoveOptions) (FileResponse, error) {
+	return f.moveOrCopy("move", fromPath, toPath, opts, nil)
+}
 
-	var response FileResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		panic(err)
+// CreateSignedUrl create a signed url for a file object that is valid for expiresIn seconds
+func (f *file) CreateSignedUrl(filePath string, expiresIn int) (SignedUrlResponse, error) {
+	if expiresIn <= 0 {
+		return SignedUrlResponse{}, fmt.Errorf("expiresIn must be greater than 0, got %d", expiresIn)
 	}
 
-	return response
-}
-
-// CreateSignedUrl create a signed url for a file object
-func (f *file) CreateSignedUrl(filePath string, expiresIn int) SignedUrlResponse {
-	_json, _ := json.Marshal(map[string]interface{}{
+	_json, _ := f.storage.client.marshalJSON(map[string]interface{}{
 		"expiresIn": expiresIn,
 	})
 
-	reqURL := fmt.Sprintf("%s/%s/object/sign/%s/%s", f.storage.client.BaseURL, StorageEndpoint, f.BucketId, filePath)
-	req, err := http.NewRequest(http.MethodPost, reqURL, bytes.NewBuffer(_json))
+	reqURL := fmt.Sprintf("%s/object/sign/%s/%s", f.storage.client.storageURL(), f.BucketId, filePath)
+	ctx, cancel := f.withTimeout(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(_json))
 	if err != nil {
-		panic(err)
+		return SignedUrlResponse{}, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	injectAuthorizationHeader(req, f.storage.client.apiKey)
+	f.storage.client.injectAuthHeaders(req)
 
-	client := &http.Client{}
+	// taken before sending the request so the url is never cached past its actual expiry
+	expiresAt := time.Now().Add(time.Duration(expiresIn) * time.Second)
+
+	client := &http.Client{Transport: f.storage.client.roundTripper}
 	res, err := client.Do(req)
 	if err != nil {
-		panic(err)
+		return SignedUrlResponse{}, err
 	}
 
+	defer res.Body.Close()
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		panic(err)
+		return SignedUrlResponse{}, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return SignedUrlResponse{}, f.fileError(res, body)
 	}
 
 	var response SignedUrlResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		panic(err)
+	if err := f.storage.client.unmarshalJSON(body, &response); err != nil {
+		return SignedUrlResponse{}, &DecodeError{Err: err}
 	}
-	response.SignedUrl = f.storage.client.BaseURL + "/" + StorageEndpoint + response.SignedUrl
 
-	return response
+	signedURL, err := url.Parse(response.SignedUrl)
+	if err != nil {
+		return SignedUrlResponse{}, err
+	}
+
+	response.Token = signedURL.Query().Get("token")
+	response.ExpiresAt = expiresAt
+	response.SignedUrl = f.storage.client.storageURL() + response.SignedUrl
+
+	return response, nil
 }
 
 // GetPublicUrl get a public signed url of a file object
 func (f *file) GetPublicUrl(filePath string) SignedUrlResponse {
 	var response SignedUrlResponse
-	response.SignedUrl = fmt.Sprintf("%s/%s/object/public/%s/%s", f.storage.client.BaseURL, StorageEndpoint, f.BucketId, filePath)
+	response.SignedUrl = fmt.Sprintf("%s/object/public/%s/%s", f.storage.client.storageURL(), f.BucketId, filePath)
 	return response
 }
 
-// Remove deletes a file object
+// Remove deletes file objects. Errors are only reported in the Message of
+// the response, use RemoveWithContext to get them.
 func (f *file) Remove(filePaths []string) FileResponse {
-	_json, _ := json.Marshal(map[string]interface{}{
+	response, err := f.RemoveWithContext(context.Background(), filePaths)
+	return responseWithError(response, err)
+}
+
+// RemoveWithContext deletes file objects. Error responses are returned as an
+// error along with the response decoded from their body.
+func (f *file) RemoveWithContext(ctx context.Context, filePaths []string) (FileResponse, error) {
+	_json, err := f.storage.client.marshalJSON(map[string]interface{}{
 		"prefixes": filePaths,
 	})
+	if err != nil {
+		return FileResponse{}, err
+	}
+
+	reqURL := fmt.Sprintf("%s/object/%s", f.storage.client.storageURL(), f.BucketId)
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
 
-	reqURL := fmt.Sprintf("%s/%s/object/%s", f.storage.client.BaseURL, StorageEndpoint, f.BucketId)
-	req, err := http.NewRequest(http.MethodDelete, reqURL, bytes.NewBuffer(_json))
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, reqURL, bytes.NewBuffer(_json))
 	if err != nil {
-		panic(err)
+		return FileResponse{}, err
 	}
 
-	injectAuthorizationHeader(req, f.storage.client.apiKey)
+	f.storage.client.injectAuthHeaders(req)
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
+	client := &http.Client{Transport: f.storage.client.roundTripper}
 	res, err := client.Do(req)
 	if err != nil {
-		panic(err)
+		return FileResponse{}, err
 	}
+	defer res.Body.Close()
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		panic(err)
+		return FileResponse{}, err
 	}
 
-	if res.StatusCode != 200 {
+	if res.StatusCode != http.StatusOK {
 		var response FileResponse
-		if err := json.Unmarshal(body, &response); err != nil {
-			panic(err)
+		f.storage.client.unmarshalJSON(body, &response)
+		return response, f.fileError(res, body)
+	}
+
+	return FileResponse{}, nil
+}
+
+// removeBatchSize is the maximum number of objects deleted by a single request
+const removeBatchSize = 1000
+
+// RemovePrefix deletes all file objects under a prefix, including the ones in
+// nested folders, and returns the number of deleted objects
+func (f *file) RemovePrefix(ctx context.Context, prefix string) (int, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	paths, err := f.listRecursive(ctx, strings.Trim(prefix, "/"))
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for start := 0; start < len(paths); start += removeBatchSize {
+		end := start + removeBatchSize
+		if end > len(paths) {
+			end = len(paths)
+		}
+
+		if err := f.removeBatch(ctx, paths[start:end]); err != nil {
+			return removed, err
 		}
+		removed += end - start
+	}
 
-		return response
+	return removed, nil
+}
+
+// listRecursive returns the paths of all file objects under a prefix
+func (f *file) listRecursive(ctx context.Context, prefix string) ([]string, error) {
+	objects, err := f.listObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
 	}
 
-	return FileResponse{}
+	paths := make([]string, len(objects))
+	for i, object := range objects {
+		paths[i] = object.Name
+	}
+	return paths, nil
 }
 
-// List list all file object
-func (f *file) List(queryPath string, options FileSearchOptions) []FileObject {
-	if options.Limit == 0 {
-		options.Limit = defaultLimit
+// listObjects returns all file objects under a prefix, named by their full path
+func (f *file) listObjects(ctx context.Context, prefix string) ([]FileObject, error) {
+	var objects []FileObject
+	err := f.walkObjects(ctx, prefix, func(object FileObject) {
+		objects = append(objects, object)
+	})
+	return objects, err
+}
+
+// walkObjects calls fn with each file object under a prefix, named by its full
+// path, listing a page at a time so the objects are not held in memory
+func (f *file) walkObjects(ctx context.Context, prefix string, fn func(FileObject)) error {
+	limit := removeBatchSize
+	for offset := 0; ; offset += limit {
+		page, err := f.List(ctx, prefix, &ListOptions{Limit: &limit, Offset: &offset})
+		if err != nil {
+			return err
+		}
+
+		for _, object := range page {
+			if prefix != "" {
+				object.Name = prefix + "/" + object.Name
+			}
+
+			// folders are listed without an id
+			if object.Id == "" {
+				if err := f.walkObjects(ctx, object.Name, fn); err != nil {
+					return err
+				}
+				continue
+			}
+
+			fn(object)
+		}
+
+		if len(page) < limit {
+			return nil
+		}
 	}
-	if options.Offset == 0 {
-		options.Offset = defaultOffset
+}
+
+// Count returns the number of file objects under a prefix, including the ones
+// in nested folders. The storage API has no count endpoint, so the objects are
+// listed a page at a time without being kept.
+func (f *file) Count(ctx context.Context, prefix string) (int, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	count := 0
+	err := f.walkObjects(ctx, strings.Trim(prefix, "/"), func(FileObject) {
+		count++
+	})
+	if err != nil {
+		return 0, err
 	}
-	if options.SortBy.Order == "" {
-		options.SortBy.Order = defaultSortOrder
+	return count, nil
+}
+
+// removeBatch deletes the given file objects in a single request
+func (f *file) removeBatch(ctx context.Context, filePaths []string) error {
+	_json, _ := f.storage.client.marshalJSON(map[string]interface{}{
+		"prefixes": filePaths,
+	})
+
+	reqURL := fmt.Sprintf("%s/object/%s", f.storage.client.storageURL(), f.BucketId)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, reqURL, bytes.NewBuffer(_json))
+	if err != nil {
+		return err
 	}
-	if options.SortBy.Column == "" {
-		options.SortBy.Column = defaultSortColumn
+
+	f.storage.client.injectAuthHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Transport: f.storage.client.roundTripper}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
 	}
 
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return err
+		}
+
+		return f.fileError(res, body)
+	}
+
+	return nil
+}
+
+// List list all file object
+func (f *file) List(ctx context.Context, queryPath string, opts *ListOptions) ([]FileObject, error) {
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
 	_body := ListFileRequest{
-		Limit:  options.Limit,
-		Offset: options.Offset,
+		Limit:  defaultLimit,
+		Offset: defaultOffset,
 		SortBy: SortBy{
-			Column: options.SortBy.Column,
-			Order:  options.SortBy.Order,
+			Column: defaultSortColumn,
+			Order:  defaultSortOrder,
 		},
 		Prefix: queryPath,
 	}
 
-	_json, _ := json.Marshal(_body)
+	if opts != nil {
+		if opts.Limit != nil {
+			_body.Limit = *opts.Limit
+		}
+		if opts.Offset != nil {
+			_body.Offset = *opts.Offset
+		}
+		if opts.SortBy != nil {
+			if opts.SortBy.Column != "" {
+				_body.SortBy.Column = opts.SortBy.Column
+			}
+			if opts.SortBy.Order != "" {
+				_body.SortBy.Order = opts.SortBy.Order
+			}
+		}
+	}
+
+	_json, _ := f.storage.client.marshalJSON(_body)
 
-	reqURL := fmt.Sprintf("%s/%s/object/list/%s", f.storage.client.BaseURL, StorageEndpoint, f.BucketId)
-	req, err := http.NewRequest(http.MethodPost, reqURL, bytes.NewBuffer(_json))
-	req.Header.Set("Content-Type", "application/json")
+	reqURL := fmt.Sprintf("%s/object/list/%s", f.storage.client.storageURL(), f.BucketId)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(_json))
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	injectAuthorizationHeader(req, f.storage.client.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	f.storage.client.injectAuthHeaders(req)
 
-	client := &http.Client{}
+	client := &http.Client{Transport: f.storage.client.roundTripper}
 	res, err := client.Do(req)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
+	defer res.Body.Close()
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, f.fileError(res, body)
 	}
 
 	var response []FileObject
-	if err := json.Unmarshal(body, &response); err != nil {
-		panic(err)
+	if err := f.storage.client.unmarshalJSON(body, &response); err != nil {
+		return nil, &DecodeError{Err: err}
 	}
 
-	return response
+	return response, nil
 }
 
 // Copy copies a file object
-func (f *file) Copy(fromPath, toPath string) FileResponse {
-	_json, _ := json.Marshal(map[string]interface{}{
-		"bucketId":      f.BucketId,
-		"sourceKey":     fromPath,
-		"destintionKey": toPath,
-	})
+func (f *file) Copy(fromPath, toPath string, opts *FileMoveOptions) (FileResponse, error) {
+	return f.moveOrCopy("copy", fromPath, toPath, opts, nil)
+}
+
+// UpdateObjectMetadata replaces the user metadata of a file object without
+// re-uploading it, by copying the object onto itself with the new metadata
+func (f *file) UpdateObjectMetadata(ctx context.Context, path string, metadata JSONMap) (FileResponse, error) {
+	if metadata == nil {
+		metadata = JSONMap{}
+	}
+
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	return f.moveOrCopyWithContext(ctx, "copy", path, path, &FileMoveOptions{Overwrite: true}, metadata)
+}
+
+// moveOrCopy sends a move or copy request and returns the key of the destination object
+func (f *file) moveOrCopy(action, fromPath, toPath string, opts *FileMoveOptions, metadata JSONMap) (FileResponse, error) {
+	ctx, cancel := f.withTimeout(context.Background())
+	defer cancel()
+
+	return f.moveOrCopyWithContext(ctx, action, fromPath, toPath, opts, metadata)
+}
+
+// moveOrCopyWithContext sends a move or copy request, replacing the user metadata of the destination object if metadata is set
+func (f *file) moveOrCopyWithContext(ctx context.Context, action, fromPath, toPath string, opts *FileMoveOptions, metadata JSONMap) (FileResponse, error) {
+	destinationBucket := f.BucketId
+	overwrite := false
+	if opts != nil {
+		if opts.DestinationBucket != "" {
+			destinationBucket = opts.DestinationBucket
+		}
+		overwrite = opts.Overwrite
+	}
 
-	reqURL := fmt.Sprintf("%s/%s/object/copy/%s", f.storage.client.BaseURL, StorageEndpoint, f.BucketId)
-	req, err := http.NewRequest(http.MethodPost, reqURL, bytes.NewBuffer(_json))
+	reqBody := map[string]interface{}{
+		"bucketId":          f.BucketId,
+		"sourceKey":         fromPath,
+		"destinationKey":    toPath,
+		"destinationBucket": destinationBucket,
+	}
+	if metadata != nil {
+		reqBody["copyMetadata"] = false
+		reqBody["metadata"] = metadata
+	}
+	_json, _ := f.storage.client.marshalJSON(reqBody)
+
+	reqURL := fmt.Sprintf("%s/object/%s", f.storage.client.storageURL(), action)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewBuffer(_json))
 	if err != nil {
-		panic(err)
+		return FileResponse{}, err
 	}
 
-	injectAuthorizationHeader(req, f.storage.client.apiKey)
+	f.storage.client.injectAuthHeaders(req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-upsert", strconv.FormatBool(overwrite))
 
-	client := &http.Client{}
+	client := &http.Client{Transport: f.storage.client.roundTripper}
 	res, err := client.Do(req)
 	if err != nil {
-		panic(err)
+		return FileResponse{}, err
 	}
 
+	defer res.Body.Close()
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		panic(err)
+		return FileResponse{}, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return FileResponse{}, f.fileError(res, body)
 	}
 
 	var response FileResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		panic(err)
+	if err := f.storage.client.unmarshalJSON(body, &response); err != nil {
+		return FileResponse{}, &DecodeError{Err: err}
 	}
 
-	return response
+	// move only returns a message, so the destination key is filled in here
+	if response.Key == "" {
+		response.Key = destinationBucket + "/" + toPath
+	}
+
+	return response, nil
 }
 
-// Download  retrieves a file object, if it exists, otherwise return file response
+// Download retrieves a file object. Missing objects return an error wrapping ErrNotFound.
 func (f *file) Download(filePath string) ([]byte, error) {
-	reqURL := fmt.Sprintf("%s/%s/object/authenticated/%s/%s", f.storage.client.BaseURL, StorageEndpoint, f.BucketId, filePath)
-	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	reqURL := fmt.Sprintf("%s/object/authenticated/%s/%s", f.storage.client.storageURL(), f.BucketId, filePath)
+	ctx, cancel := f.withTimeout(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	injectAuthorizationHeader(req, f.storage.client.apiKey)
+	f.storage.client.injectAuthHeaders(req)
 
-	client := &http.Client{}
+	client := &http.Client{Transport: f.storage.client.roundTripper}
 	res, err := client.Do(req)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	body, err := io.ReadAll(res.Body)
+	defer res.Body.Close()
+	body, err := f.storage.client.readBody(res.Body)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	// when not success, supabase will return json insted of file
 	if res.StatusCode != 200 {
-		var resErr *FileErrorResponse
-		if err := json.Unmarshal(body, &resErr); err != nil {
-			panic(err)
+		return nil, f.fileError(res, body)
+	}
+
+	return body, nil
+}
+
+// DownloadInfo contains the HTTP metadata of a downloaded file object
+type DownloadInfo struct {
+	ContentType string
+	// ContentLength is the size of the decoded body, -1 if unknown
+	ContentLength int64
+	CacheControl  string
+	ETag          string
+	LastModified  string
+}
+
+// DownloadResponse is a streamed download, Body must be closed by the caller
+type DownloadResponse struct {
+	DownloadInfo
+	Body io.ReadCloser
+}
+
+// DownloadStream retrieves a file object as a stream along with its metadata.
+// Bodies compressed with gzip or deflate content encoding are decompressed.
+func (f *file) DownloadStream(ctx context.Context, filePath string) (*DownloadResponse, error) {
+	reqURL := fmt.Sprintf("%s/object/authenticated/%s/%s", f.storage.client.storageURL(), f.BucketId, filePath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	f.storage.client.injectAuthHeaders(req)
+	// setting the header disables the transparent gzip decoding of the
+	// transport, the body is decoded below for all supported encodings
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+
+	client := &http.Client{Transport: f.storage.client.roundTripper}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
 		}
 
-		if resErr.Status == "404" {
-			return nil, ErrNotFound
+		return nil, f.fileError(res, body)
+	}
+
+	body, decoded, err := decodeContent(res)
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+
+	info := DownloadInfo{
+		ContentType:   res.Header.Get("Content-Type"),
+		ContentLength: res.ContentLength,
+		CacheControl:  res.Header.Get("Cache-Control"),
+		ETag:          res.Header.Get("ETag"),
+		LastModified:  res.Header.Get("Last-Modified"),
+	}
+	if decoded {
+		info.ContentLength = -1
+	}
+	return &DownloadResponse{DownloadInfo: info, Body: body}, nil
+}
+
+// DownloadWithInfo retrieves a file object along with its metadata
+func (f *file) DownloadWithInfo(ctx context.Context, filePath string) ([]byte, DownloadInfo, error) {
+	res, err := f.DownloadStream(ctx, filePath)
+	if err != nil {
+		return nil, DownloadInfo{}, err
+	}
+	defer res.Body.Close()
+
+	body, err := f.storage.client.readBody(res.Body)
+	if err != nil {
+		return nil, DownloadInfo{}, err
+	}
+
+	info := res.DownloadInfo
+	info.ContentLength = int64(len(body))
+	return body, info, nil
+}
+
+// decodedBody closes both the decoder and the underlying response body
+type decodedBody struct {
+	io.Reader
+	decoder io.Closer
+	body    io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	err := b.decoder.Close()
+	if bodyErr := b.body.Close(); err == nil {
+		err = bodyErr
+	}
+	return err
+}
+
+// decodeContent returns the response body decoded according to its
+// Content-Encoding and whether it was decoded
+func decodeContent(res *http.Response) (io.ReadCloser, bool, error) {
+	var (
+		decoder io.ReadCloser
+		err     error
+	)
+	switch encoding := strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		return res.Body, false, nil
+	case "gzip", "x-gzip":
+		decoder, err = gzip.NewReader(res.Body)
+	case "deflate":
+		decoder, err = zlib.NewReader(res.Body)
+	default:
+		return nil, false, fmt.Errorf("unsupported content encoding: %q", encoding)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &decodedBody{Reader: decoder, decoder: decoder, body: res.Body}, true, nil
+}
+
+// DownloadParallel retrieves a file object by issuing up to concurrency range requests
+// of partSize bytes at once and writing each part at its offset in w. It returns the
+// size of the file object.
+func (f *file) DownloadParallel(ctx context.Context, filePath string, w io.WriterAt, partSize int64, concurrency int) (int64, error) {
+	if partSize <= 0 {
+		return 0, fmt.Errorf("partSize must be greater than 0, got %d", partSize)
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	ctx, cancel := f.withTimeout(ctx)
+	defer cancel()
+
+	reqURL := fmt.Sprintf("%s/object/authenticated/%s/%s", f.storage.client.storageURL(), f.BucketId, filePath)
+
+	// the first part also tells the total size of the file object
+	res, err := f.downloadRange(ctx, reqURL, 0, partSize-1)
+	if err != nil {
+		return 0, err
+	}
+
+	if res.StatusCode == http.StatusOK {
+		// range requests are not supported, the whole file object is returned
+		defer res.Body.Close()
+		return io.Copy(io.NewOffsetWriter(w, 0), res.Body)
+	}
+
+	size, err := parseContentRangeSize(res.Header.Get("Content-Range"))
+	if err != nil {
+		res.Body.Close()
+		return 0, err
+	}
+
+	_, err = io.Copy(io.NewOffsetWriter(w, 0), res.Body)
+	res.Body.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		sem      = make(chan struct{}, concurrency)
+	)
+
+	for start := partSize; start < size; start += partSize {
+		end := start + partSize - 1
+		if end >= size {
+			end = size - 1
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
 		}
 
-		return nil, resErr
+		wg.Add(1)
+		go func(start, end int64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := f.downloadPart(ctx, reqURL, w, start, end); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(start, end)
 	}
 
-	return body, nil
+	wg.Wait()
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// downloadPart retrieves the bytes between start and end and writes them at start in w
+func (f *file) downloadPart(ctx context.Context, reqURL string, w io.WriterAt, start, end int64) error {
+	res, err := f.downloadRange(ctx, reqURL, start, end)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("unexpected status code for range request: %d", res.StatusCode)
+	}
+
+	n, err := io.Copy(io.NewOffsetWriter(w, start), res.Body)
+	if err != nil {
+		return err
+	}
+	if n != end-start+1 {
+		return fmt.Errorf("short read for range %d-%d: got %d bytes", start, end, n)
+	}
+
+	return nil
+}
+
+// downloadRange sends a range request, the caller must close the body of successful responses
+func (f *file) downloadRange(ctx context.Context, reqURL string, start, end int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	f.storage.client.injectAuthHeaders(req)
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	client := &http.Client{Transport: f.storage.client.roundTripper}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, f.fileError(res, body)
+	}
+
+	return res, nil
+}
+
+// parseContentRangeSize returns the total size from a "bytes start-end/size" header
+func parseContentRangeSize(contentRange string) (int64, error) {
+	i := strings.LastIndex(contentRange, "/")
+	if i < 0 {
+		return 0, fmt.Errorf("invalid content range: %q", contentRange)
+	}
+
+	size, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid content range: %q", contentRange)
+	}
+
+	return size, nil
 }
 
 func removeEmptyFolder(filePath string) string {
@@ -0,0 +1,129 @@
+package supabase
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultSyncConcurrency is the number of file objects copied at once by SyncBucket
+const defaultSyncConcurrency = 4
+
+// SyncOptions controls the copy of file objects by SyncBucket.
+type SyncOptions struct {
+	// DestinationBucket is the bucket the objects are copied to, defaults to the source bucket
+	DestinationBucket string
+	// Concurrency is the number of objects copied at once, defaults to 4
+	Concurrency int
+	// SkipExisting keeps the objects which already exist in the destination
+	// instead of overwriting them
+	SkipExisting bool
+	// Progress is called after each object, with the error if it failed.
+	// It may be called from several goroutines at once.
+	Progress func(path string, err error)
+}
+
+// SyncResult summarizes a SyncBucket run.
+type SyncResult struct {
+	Copied  int
+	Skipped int
+	// Failed contains the error of each object which could not be copied
+	Failed map[string]error
+}
+
+// SyncBucket copies the file objects under prefix of a bucket of the src
+// project to the dst project, e.g. to promote assets between environments or
+// to back up a bucket. The content type, cache control and user metadata of
+// the objects are preserved. Objects which fail to copy are reported in the
+// result, the returned error is only set if the objects could not be listed or
+// ctx is done.
+func SyncBucket(ctx context.Context, src *Client, dst *Client, bucket string, prefix string, opts *SyncOptions) (*SyncResult, error) {
+	config := SyncOptions{}
+	if opts != nil {
+		config = *opts
+	}
+	if config.DestinationBucket == "" {
+		config.DestinationBucket = bucket
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaultSyncConcurrency
+	}
+
+	from := src.Storage.From(bucket)
+	to := dst.Storage.From(config.DestinationBucket)
+	objects, err := from.listObjects(ctx, strings.Trim(prefix, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		sem    = make(chan struct{}, config.Concurrency)
+		result = &SyncResult{Failed: map[string]error{}}
+	)
+
+	for _, object := range objects {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(object FileObject) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := syncObject(ctx, from, to, object, !config.SkipExisting)
+
+			mu.Lock()
+			switch {
+			case err == nil:
+				result.Copied++
+			case errors.Is(err, ErrObjectAlreadyExists):
+				result.Skipped++
+				err = nil
+			default:
+				result.Failed[object.Name] = err
+			}
+			mu.Unlock()
+
+			if config.Progress != nil {
+				config.Progress(object.Name, err)
+			}
+		}(object)
+	}
+
+	wg.Wait()
+	return result, ctx.Err()
+}
+
+// syncObject streams a file object from one bucket to another
+func syncObject(ctx context.Context, from *file, to *file, object FileObject, overwrite bool) error {
+	download, err := from.DownloadStream(ctx, object.Name)
+	if err != nil {
+		return err
+	}
+	defer download.Body.Close()
+
+	opts := &FileUploadOptions{
+		ContentType: download.ContentType,
+		MimeType:    download.ContentType,
+		Upsert:      overwrite,
+		Metadata:    object.UserMetadata,
+	}
+	// uploads take the cache duration in seconds
+	if maxAge, ok := strings.CutPrefix(download.CacheControl, "max-age="); ok {
+		if _, err := strconv.Atoi(maxAge); err == nil {
+			opts.CacheControl = maxAge
+		}
+	}
+
+	_, err = to.upload(ctx, object.Name, download.Body, false, opts)
+	return err
+}
@@ -0,0 +1,180 @@
+package supabase_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	supabase "github.com/nedpals/supabase-go"
+	"github.com/nedpals/supabase-go/supabasetest"
+)
+
+// newSyncProjects starts the storage of a source and a destination project,
+// both with an assets bucket, and uploads objects to the source.
+func newSyncProjects(t *testing.T, ctx context.Context) (src *supabase.Client, dst *supabase.Client, dstServer *supabasetest.StorageServer) {
+	srcServer := supabasetest.NewStorageServer(t)
+	dstServer = supabasetest.NewStorageServer(t)
+	src, dst = srcServer.Client(), dstServer.Client()
+	for _, client := range []*supabase.Client{src, dst} {
+		if _, err := client.Storage.CreateBucket(ctx, supabase.BucketOption{Id: "assets", Name: "assets"}); err != nil {
+			t.Fatalf("create bucket: %v", err)
+		}
+	}
+
+	files := src.Storage.From("assets")
+	uploads := []struct {
+		path string
+		data string
+		opts *supabase.FileUploadOptions
+	}{
+		{"logo.png", "logo", &supabase.FileUploadOptions{ContentType: "image/png", CacheControl: "60", Metadata: supabase.JSONMap{"owner": "alice"}}},
+		{"docs/readme.txt", "readme", nil},
+		{"docs/guide/intro.txt", "intro", nil},
+	}
+	for _, upload := range uploads {
+		if _, err := files.UploadWithContext(ctx, upload.path, strings.NewReader(upload.data), upload.opts); err != nil {
+			t.Fatalf("upload %s: %v", upload.path, err)
+		}
+	}
+	return src, dst, dstServer
+}
+
+func TestSyncBucket(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	src, dst, dstServer := newSyncProjects(t, ctx)
+
+	result, err := supabase.SyncBucket(ctx, src, dst, "assets", "", nil)
+	if err != nil {
+		t.Fatalf("SyncBucket error = %v", err)
+	}
+	if result.Copied != 3 || result.Skipped != 0 || len(result.Failed) != 0 {
+		t.Errorf("expected 3 copied objects, got %+v", result)
+	}
+	for path, want := range map[string]string{"logo.png": "logo", "docs/readme.txt": "readme", "docs/guide/intro.txt": "intro"} {
+		if data, ok := dstServer.Object("assets", path); !ok || string(data) != want {
+			t.Errorf("expected %s to be copied, got %q", path, data)
+		}
+	}
+
+	// the content type, cache control and user metadata are preserved
+	files := dst.Storage.From("assets")
+	download, err := files.DownloadStream(ctx, "logo.png")
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	io.Copy(io.Discard, download.Body)
+	download.Body.Close()
+	if download.ContentType != "image/png" || download.CacheControl != "max-age=60" {
+		t.Errorf("expected the content type and cache control of the source, got %q and %q", download.ContentType, download.CacheControl)
+	}
+	objects, err := files.List(ctx, "", nil)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	for _, object := range objects {
+		if object.Name == "logo.png" && object.UserMetadata["owner"] != "alice" {
+			t.Errorf("expected the user metadata of the source, got %v", object.UserMetadata)
+		}
+	}
+}
+
+func TestSyncBucket_Prefix(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	src, dst, dstServer := newSyncProjects(t, ctx)
+	if _, err := dst.Storage.CreateBucket(ctx, supabase.BucketOption{Id: "backup", Name: "backup"}); err != nil {
+		t.Fatalf("create bucket: %v", err)
+	}
+
+	result, err := supabase.SyncBucket(ctx, src, dst, "assets", "docs/", &supabase.SyncOptions{DestinationBucket: "backup"})
+	if err != nil {
+		t.Fatalf("SyncBucket error = %v", err)
+	}
+	if result.Copied != 2 {
+		t.Errorf("expected the 2 objects under the prefix to be copied, got %+v", result)
+	}
+	if _, ok := dstServer.Object("backup", "docs/guide/intro.txt"); !ok {
+		t.Errorf("expected the nested object in the destination bucket")
+	}
+	if _, ok := dstServer.Object("backup", "logo.png"); ok {
+		t.Errorf("expected the object outside the prefix not to be copied")
+	}
+}
+
+func TestSyncBucket_SkipExisting(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	src, dst, dstServer := newSyncProjects(t, ctx)
+	if _, err := dst.Storage.From("assets").UploadWithContext(ctx, "logo.png", strings.NewReader("old logo"), nil); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	result, err := supabase.SyncBucket(ctx, src, dst, "assets", "", &supabase.SyncOptions{SkipExisting: true})
+	if err != nil {
+		t.Fatalf("SyncBucket error = %v", err)
+	}
+	if result.Copied != 2 || result.Skipped != 1 || len(result.Failed) != 0 {
+		t.Errorf("expected 2 copied and 1 skipped objects, got %+v", result)
+	}
+	if data, _ := dstServer.Object("assets", "logo.png"); string(data) != "old logo" {
+		t.Errorf("expected the existing object to be kept, got %q", data)
+	}
+
+	// without SkipExisting the objects are overwritten
+	result, err = supabase.SyncBucket(ctx, src, dst, "assets", "", nil)
+	if err != nil {
+		t.Fatalf("SyncBucket error = %v", err)
+	}
+	if result.Copied != 3 || result.Skipped != 0 {
+		t.Errorf("expected 3 copied objects, got %+v", result)
+	}
+	if data, _ := dstServer.Object("assets", "logo.png"); string(data) != "logo" {
+		t.Errorf("expected the existing object to be overwritten, got %q", data)
+	}
+}
+
+func TestSyncBucket_Failed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	src, dst, _ := newSyncProjects(t, ctx)
+
+	var mu sync.Mutex
+	progress := map[string]error{}
+	result, err := supabase.SyncBucket(ctx, src, dst, "assets", "", &supabase.SyncOptions{
+		DestinationBucket: "missing",
+		Progress: func(path string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			progress[path] = err
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected the failures in the result only, got %v", err)
+	}
+	if result.Copied != 0 {
+		t.Errorf("expected no copied objects, got %+v", result)
+	}
+
+	var failed []string
+	for path, err := range result.Failed {
+		failed = append(failed, path)
+		var apiErr supabase.APIError
+		if !errors.As(err, &apiErr) || apiErr.HTTPStatus() != http.StatusNotFound {
+			t.Errorf("expected the not found error of %s, got %v", path, err)
+		}
+		if progress[path] != err {
+			t.Errorf("expected the error of %s to be reported to Progress, got %v", path, progress[path])
+		}
+	}
+	sort.Strings(failed)
+	if strings.Join(failed, ",") != "docs/guide/intro.txt,docs/readme.txt,logo.png" {
+		t.Errorf("expected every object to fail, got %v", failed)
+	}
+}
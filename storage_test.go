@@ -0,0 +1,51 @@
+package supabase
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// refusedURL returns the URL of a local port nothing listens on.
+func refusedURL(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return "http://" + addr
+}
+
+func TestFile_NetworkErrors(t *testing.T) {
+	client := NewClient(refusedURL(t), "key")
+	files := client.Storage.From("avatars")
+	ctx := context.Background()
+
+	assertRetryable := func(name string, err error) {
+		t.Helper()
+		var netErr *NetworkError
+		if !errors.As(err, &netErr) || !IsRetryable(err) {
+			t.Errorf("%s: expected a retryable *NetworkError, got %v", name, err)
+		}
+	}
+
+	_, err := files.Download("a.txt")
+	assertRetryable("Download", err)
+	_, err = files.UploadWithContext(ctx, "a.txt", strings.NewReader("a"), nil)
+	assertRetryable("UploadWithContext", err)
+	_, err = files.UpdateWithContext(ctx, "a.txt", strings.NewReader("a"), nil)
+	assertRetryable("UpdateWithContext", err)
+	_, err = files.RemoveWithContext(ctx, []string{"a.txt"})
+	assertRetryable("RemoveWithContext", err)
+
+	// the methods without an error result report it in the message
+	if res := files.Upload("a.txt", strings.NewReader("a"), nil); res.Message == "" {
+		t.Error("Upload: expected the network error in the message")
+	}
+	if res := files.Remove([]string{"a.txt"}); res.Message == "" {
+		t.Error("Remove: expected the network error in the message")
+	}
+}
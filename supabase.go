@@ -1,20 +1,29 @@
 package supabase
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	postgrest "github.com/nedpals/supabase-go/postgrest/pkg"
 )
 
 const (
-	AuthEndpoint    = "auth/v1"
-	AdminEndpoint   = "auth/v1/admin"
-	RestEndpoint    = "rest/v1"
-	StorageEndpoint = "storage/v1"
+	AuthEndpoint      = "auth/v1"
+	AdminEndpoint     = "auth/v1/admin"
+	RestEndpoint      = "rest/v1"
+	StorageEndpoint   = "storage/v1"
+	FunctionsEndpoint = "functions/v1"
+	GraphQLEndpoint   = "graphql/v1"
 )
 
 type Client struct {
@@ -25,7 +34,297 @@ type Client struct {
 	Admin      *Admin
 	Auth       *Auth
 	Storage    *Storage
+	Functions  *Functions
+	GraphQL    *GraphQL
 	DB         *postgrest.Client
+
+	// transport is shared by the HTTP clients of all subsystems, which send
+	// requests through roundTripper
+	transport        *http.Transport
+	roundTripper     http.RoundTripper
+	serviceURLs      ServiceURLs
+	readReplicas     []string
+	readYourWrites   time.Duration
+	offlineQueue     postgrest.MutationQueue
+	breakerConfig    *CircuitBreakerConfig
+	serviceTimeouts  *ServiceTimeouts
+	signer           RequestSigner
+	maxResponseBytes int64
+	// jsonMarshal and jsonUnmarshal are set by WithJSONCodec
+	jsonMarshal   func(v interface{}) ([]byte, error)
+	jsonUnmarshal func(data []byte, v interface{}) error
+	// compressMin is the size of the DB request bodies compressed with gzip
+	compressMin int
+	// keepAliveInterval is the interval of the pings of WithKeepAlive
+	keepAliveInterval time.Duration
+	selfHosted        bool
+	debug             bool
+	autoSessionToken  bool
+	mu                sync.RWMutex
+	accessToken       string
+	// session is the session established through Auth when WithAutoSessionToken is enabled
+	session          *AuthenticatedDetails
+	sessionExpiresAt time.Time
+	refreshing       *refreshCall
+
+	// closed is cancelled by Close to stop background goroutines
+	closed    context.Context
+	cancel    context.CancelFunc
+	leakCheck *leakChecker
+	closeOnce sync.Once
+}
+
+// ClientOption configures a Client created with NewClient.
+type ClientOption func(c *Client)
+
+// WithDebug enables debug logging of outgoing PostgREST requests.
+func WithDebug(debug bool) ClientOption {
+	return func(c *Client) {
+		c.debug = debug
+	}
+}
+
+// WithAutoSessionToken makes DB and Storage requests use the access token of
+// the session established through Auth (SignIn, RefreshUser, ExchangeCode and
+// VerifyOtp) instead of the API key, so row level security policies apply to
+// the signed in user. The apikey header is always kept. The session is
+// refreshed when it is about to expire.
+func WithAutoSessionToken() ClientOption {
+	return func(c *Client) {
+		c.autoSessionToken = true
+	}
+}
+
+// WithProxy sends all requests through the given proxy. By default the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are respected.
+func WithProxy(proxyURL *url.URL) ClientOption {
+	return func(c *Client) {
+		c.transport.Proxy = http.ProxyURL(proxyURL)
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of all requests, e.g. to trust a
+// custom CA when self-hosting Supabase behind an internal PKI.
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *Client) {
+		c.transport.TLSClientConfig = config
+	}
+}
+
+// WithHTTP2 enables or disables HTTP/2. HTTP/2 is enabled by default.
+func WithHTTP2(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.transport.ForceAttemptHTTP2 = enabled
+		if !enabled {
+			// a non-nil empty map disables HTTP/2
+			c.transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		} else {
+			c.transport.TLSNextProto = nil
+		}
+	}
+}
+
+// ServiceURLs overrides the URL of individual services, e.g. when each
+// service of a self-hosted deployment listens on its own port. Empty URLs
+// are derived from the base URL.
+type ServiceURLs struct {
+	Auth      string
+	Rest      string
+	Storage   string
+	Functions string
+	GraphQL   string
+}
+
+// WithServiceURLs sets the URL of individual services.
+func WithServiceURLs(urls ServiceURLs) ClientOption {
+	return func(c *Client) {
+		c.serviceURLs = urls
+	}
+}
+
+// ResponseTooLargeError is returned when a response body exceeds the limit set with WithMaxResponseBytes.
+type ResponseTooLargeError = postgrest.ResponseTooLargeError
+
+// WithMaxResponseBytes limits the size of the response bodies read into memory
+// by DB queries, auth and admin calls, storage downloads and function
+// invocations. Larger responses fail with *ResponseTooLargeError.
+func WithMaxResponseBytes(n int64) ClientOption {
+	return func(c *Client) {
+		c.maxResponseBytes = n
+	}
+}
+
+// readBody reads a response body, enforcing the limit of WithMaxResponseBytes.
+func (c *Client) readBody(r io.Reader) ([]byte, error) {
+	return postgrest.ReadLimited(r, c.maxResponseBytes)
+}
+
+// WithJSONCodec sets the functions encoding request bodies and decoding
+// responses of all services, json.Marshal and json.Unmarshal by default, e.g.
+// to use goccy/go-json or sonic. They must be drop-in replacements of
+// encoding/json honoring its struct tags and interfaces.
+func WithJSONCodec(marshal func(v interface{}) ([]byte, error), unmarshal func(data []byte, v interface{}) error) ClientOption {
+	return func(c *Client) {
+		c.jsonMarshal = marshal
+		c.jsonUnmarshal = unmarshal
+	}
+}
+
+// marshalJSON encodes a request body with the codec of WithJSONCodec.
+func (c *Client) marshalJSON(v interface{}) ([]byte, error) {
+	if c.jsonMarshal == nil {
+		return json.Marshal(v)
+	}
+	return c.jsonMarshal(v)
+}
+
+// unmarshalJSON decodes a response body with the codec of WithJSONCodec.
+func (c *Client) unmarshalJSON(data []byte, v interface{}) error {
+	if c.jsonUnmarshal == nil {
+		return json.Unmarshal(data, v)
+	}
+	return c.jsonUnmarshal(data, v)
+}
+
+// WithRequestCompression compresses the bodies of DB inserts, upserts and
+// updates of at least minSize bytes with gzip. See
+// postgrest.WithRequestCompression for the gateway it requires.
+func WithRequestCompression(minSize int) ClientOption {
+	return func(c *Client) {
+		c.compressMin = minSize
+	}
+}
+
+// WithReadReplicas adds read replicas of the project, given by their base URL
+// like the primary. DB queries marked with UseReplica are sent to them.
+func WithReadReplicas(baseURLs ...string) ClientOption {
+	return func(c *Client) {
+		c.readReplicas = append(c.readReplicas, baseURLs...)
+	}
+}
+
+// WithReadYourWrites sends DB queries marked with UseReplica to the primary for
+// d after a write through the client, so rows just written are read back. The
+// pin applies to the user who wrote, the signed-in user or the access token
+// set with SetAccessToken, so the reads of another user signed in afterwards
+// go to the replicas. DB requests with their own Authorization header are
+// pinned by that header.
+func WithReadYourWrites(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.readYourWrites = d
+	}
+}
+
+// WithOfflineQueue stores DB mutations sent with ExecuteOrQueue in queue while
+// the network is unavailable, to be replayed with client.DB.ReplayQueue.
+func WithOfflineQueue(queue postgrest.MutationQueue) ClientOption {
+	return func(c *Client) {
+		c.offlineQueue = queue
+	}
+}
+
+// WithSelfHosted adjusts the client for self-hosted deployments without the
+// Kong gateway: services are served from the base URL (or their ServiceURLs)
+// without the /auth/v1, /rest/v1, /storage/v1, /functions/v1 and /graphql/v1
+// prefixes, and the apikey header is omitted when no key is given.
+func WithSelfHosted() ClientOption {
+	return func(c *Client) {
+		c.selfHosted = true
+	}
+}
+
+// serviceURL returns the URL of a service, without a trailing slash.
+func (c *Client) serviceURL(override string, endpoint string) string {
+	return c.serviceURLFrom(c.BaseURL, override, endpoint)
+}
+
+// serviceURLFrom returns the URL of a service of the deployment at baseURL.
+func (c *Client) serviceURLFrom(baseURL string, override string, endpoint string) string {
+	if override != "" {
+		return strings.TrimSuffix(override, "/")
+	}
+	if c.selfHosted {
+		return strings.TrimSuffix(baseURL, "/")
+	}
+	return baseURL + "/" + endpoint
+}
+
+func (c *Client) authURL() string {
+	return c.serviceURL(c.serviceURLs.Auth, AuthEndpoint)
+}
+
+func (c *Client) restURL() string {
+	return c.serviceURL(c.serviceURLs.Rest, RestEndpoint)
+}
+
+func (c *Client) storageURL() string {
+	return c.serviceURL(c.serviceURLs.Storage, StorageEndpoint)
+}
+
+func (c *Client) functionsURL() string {
+	return c.serviceURL(c.serviceURLs.Functions, FunctionsEndpoint)
+}
+
+func (c *Client) graphqlURL() string {
+	return c.serviceURL(c.serviceURLs.GraphQL, GraphQLEndpoint)
+}
+
+// sendsAPIKey reports whether the apikey header is sent with requests.
+func (c *Client) sendsAPIKey() bool {
+	return !c.selfHosted || c.apiKey != ""
+}
+
+// maxErrorBodySnippet is the maximum number of bytes of a response body kept in HTTPError.
+const maxErrorBodySnippet = 512
+
+// HTTPError is returned when a response could not be decoded as JSON, such as
+// an HTML error page returned by Kong or a proxy in front of Supabase.
+type HTTPError struct {
+	StatusCode  int
+	URL         string
+	ContentType string
+	// Body contains the beginning of the raw response body.
+	Body string
+}
+
+func (err *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected response from %s (status code: %d, content type: %q): %s", err.URL, err.StatusCode, err.ContentType, err.Body)
+}
+
+// HTTPStatus returns the HTTP status code of the response.
+func (err *HTTPError) HTTPStatus() int {
+	return err.StatusCode
+}
+
+func newHTTPError(res *http.Response, body []byte) *HTTPError {
+	if len(body) > maxErrorBodySnippet {
+		body = body[:maxErrorBodySnippet]
+	}
+
+	httpErr := &HTTPError{
+		StatusCode:  res.StatusCode,
+		ContentType: res.Header.Get("Content-Type"),
+		Body:        strings.TrimSpace(string(body)),
+	}
+	if res.Request != nil && res.Request.URL != nil {
+		httpErr.URL = res.Request.URL.String()
+	}
+	return httpErr
+}
+
+// isJSONResponse reports whether the response declares a JSON body. Responses
+// without a Content-Type are assumed to be JSON.
+func isJSONResponse(res *http.Response) bool {
+	contentType := res.Header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
 }
 
 type ErrorResponse struct {
@@ -37,44 +336,241 @@ func (err *ErrorResponse) Error() string {
 	return err.Message
 }
 
+// HTTPStatus returns the HTTP status code of the response.
+func (err *ErrorResponse) HTTPStatus() int {
+	return err.Code
+}
+
 // CreateClient creates a new Supabase client
 func CreateClient(baseURL string, supabaseKey string, debug ...bool) *Client {
-	parsedURL, err := url.Parse(fmt.Sprintf("%s/%s/", baseURL, RestEndpoint))
-	if err != nil {
-		panic(err)
+	var opts []ClientOption
+	if len(debug) > 0 {
+		// debug parameter is only for postgrest-go for now
+		opts = append(opts, WithDebug(debug[0]))
 	}
+	return NewClient(baseURL, supabaseKey, opts...)
+}
+
+// NewClient creates a new Supabase client configured with the given options
+func NewClient(baseURL string, supabaseKey string, opts ...ClientOption) *Client {
 	client := &Client{
-		BaseURL: baseURL,
-		apiKey:  supabaseKey,
-		Admin:   &Admin{},
-		Auth:    &Auth{},
-		Storage: &Storage{},
+		BaseURL:   baseURL,
+		apiKey:    supabaseKey,
+		Admin:     &Admin{},
+		Auth:      &Auth{},
+		Storage:   &Storage{},
+		Functions: &Functions{},
+		GraphQL:   &GraphQL{},
 		HTTPClient: &http.Client{
-			Timeout: time.Minute,
+			Timeout: defaultRequestTimeout,
+		},
+		transport: http.DefaultTransport.(*http.Transport).Clone(),
+	}
+	client.closed, client.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(client)
+	}
+	var transport http.RoundTripper = &networkTransport{parent: client.transport}
+	if client.signer != nil {
+		transport = &signerTransport{signer: client.signer, parent: transport}
+	}
+	if client.breakerConfig != nil {
+		transport = &breakerTransport{client: client, parent: transport, breakers: map[string]*circuitBreaker{}}
+	}
+	if client.serviceTimeouts != nil {
+		client.HTTPClient.Timeout = 0
+		transport = &timeoutTransport{client: client, parent: transport}
+	}
+	if client.leakCheck != nil {
+		transport = &leakCheckTransport{checker: client.leakCheck, parent: transport, timeout: client.HTTPClient.Timeout}
+		client.HTTPClient.Timeout = 0
+		client.leakCheck.watch(client)
+	}
+	client.roundTripper = &metaTransport{parent: transport}
+	client.HTTPClient.Transport = client.roundTripper
+	parsedURL, err := url.Parse(client.restURL() + "/")
+	if err != nil {
+		panic(err)
+	}
+	dbOpts := []postgrest.ClientOption{
+		func(c *postgrest.Client) {
+			c.Transport.Parent = &authTransport{client: client}
+			c.Debug = client.debug
 		},
-		DB: postgrest.NewClient(
-			*parsedURL,
-			postgrest.WithTokenAuth(supabaseKey),
-			func(c *postgrest.Client) {
-				// debug parameter is only for postgrest-go for now
-				if len(debug) > 0 {
-					c.Debug = debug[0]
-				}
-				c.AddHeader("apikey", supabaseKey)
-			},
-		),
+		postgrest.WithMaxResponseBytes(client.maxResponseBytes),
+	}
+	if client.sendsAPIKey() {
+		dbOpts = append(dbOpts, postgrest.WithAPIKey(client.apiKey))
+	}
+	if client.readYourWrites > 0 {
+		dbOpts = append(dbOpts, postgrest.WithReadYourWrites(client.readYourWrites), postgrest.WithPinKey(client.pinKey))
+	}
+	if client.jsonMarshal != nil || client.jsonUnmarshal != nil {
+		dbOpts = append(dbOpts, postgrest.WithJSONCodec(client.jsonMarshal, client.jsonUnmarshal))
 	}
+	if client.compressMin > 0 {
+		dbOpts = append(dbOpts, postgrest.WithRequestCompression(client.compressMin))
+	}
+	if client.offlineQueue != nil {
+		dbOpts = append(dbOpts, postgrest.WithOfflineQueue(client.offlineQueue))
+	}
+	for _, replica := range client.readReplicas {
+		replicaURL, err := url.Parse(client.serviceURLFrom(replica, "", RestEndpoint) + "/")
+		if err != nil {
+			panic(err)
+		}
+		dbOpts = append(dbOpts, postgrest.WithReadReplica(*replicaURL))
+	}
+	client.DB = postgrest.NewClient(*parsedURL, dbOpts...)
 	client.Admin.client = client
 	client.Admin.serviceKey = supabaseKey
 	client.Auth.client = client
 	client.Storage.client = client
+	client.Functions.client = client
+	client.GraphQL.client = client
+	client.startKeepAlive()
 	return client
 }
 
+// Close stops the background goroutines of the client and closes its idle
+// connections. The client must not be used after Close.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		c.cancel()
+		c.HTTPClient.CloseIdleConnections()
+		c.DB.CloseIdleConnections()
+		c.transport.CloseIdleConnections()
+	})
+	return nil
+}
+
+// SetAccessToken sets the token used for the Authorization header of DB,
+// Storage and Functions requests. An empty token reverts to the API key. An
+// Authorization header set on the DB client with SetAuth takes precedence.
+func (c *Client) SetAccessToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken = token
+	c.session = nil
+}
+
+// authToken returns the token to be used as the bearer for DB, Storage and Functions requests.
+func (c *Client) authToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.accessToken != "" {
+		return c.accessToken
+	}
+	return c.apiKey
+}
+
+// pinKey identifies the user of DB requests for WithReadYourWrites. It does
+// not refresh the session, the user ID stays the same across refreshes.
+func (c *Client) pinKey(ctx context.Context) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.session != nil && c.session.User.ID != "" {
+		return "user:" + c.session.User.ID
+	}
+	return "token:" + c.accessToken
+}
+
 func injectAuthorizationHeader(req *http.Request, value string) {
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", value))
 }
 
+// injectAPIKey sets the apikey header. The API key and the access token are
+// only kept on the Client, so every service reads the same values.
+func (c *Client) injectAPIKey(req *http.Request) {
+	if c.sendsAPIKey() {
+		req.Header.Set("apikey", c.apiKey)
+	}
+}
+
+// injectAuthHeaders sets the apikey header and the Authorization header with
+// the session access token or the API key.
+func (c *Client) injectAuthHeaders(req *http.Request) {
+	c.injectAPIKey(req)
+	injectAuthorizationHeader(req, c.sessionToken(req.Context()))
+}
+
+// authTransport sets the Authorization header of DB requests to the session
+// access token or the API key, keeping a header set on the DB client or the
+// request builder. The apikey header is set with postgrest.WithAPIKey.
+type authTransport struct {
+	client *Client
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") == "" {
+		req = req.Clone(req.Context())
+		injectAuthorizationHeader(req, t.client.sessionToken(req.Context()))
+	}
+	return t.client.roundTripper.RoundTrip(req)
+}
+
+// ResponseMeta contains the metadata of a response, such as rate limit
+// headers and request IDs, for debugging.
+type ResponseMeta struct {
+	StatusCode int
+	Header     http.Header
+}
+
+// RequestID returns the ID assigned to the request by the Supabase gateway.
+func (m *ResponseMeta) RequestID() string {
+	if id := m.Header.Get("sb-request-id"); id != "" {
+		return id
+	}
+	return m.Header.Get("x-request-id")
+}
+
+// GatewayVersion returns the version of the Supabase gateway.
+func (m *ResponseMeta) GatewayVersion() string {
+	return m.Header.Get("sb-gateway-version")
+}
+
+// RateLimitRemaining returns the number of requests left in the current
+// rate limit window, or -1 if the response has no rate limit headers.
+func (m *ResponseMeta) RateLimitRemaining() int {
+	remaining, err := strconv.Atoi(m.Header.Get("x-ratelimit-remaining"))
+	if err != nil {
+		return -1
+	}
+	return remaining
+}
+
+type responseMetaKey struct{}
+
+// WithResponseMeta returns a context which makes the auth, storage and
+// functions methods called with it store the metadata of their response in
+// meta. When a method sends several requests, meta holds the last response.
+//
+//	var meta supabase.ResponseMeta
+//	user, err := client.Auth.SignUp(supabase.WithResponseMeta(ctx, &meta), credentials)
+//	log.Println(meta.StatusCode, meta.RequestID())
+func WithResponseMeta(ctx context.Context, meta *ResponseMeta) context.Context {
+	return context.WithValue(ctx, responseMetaKey{}, meta)
+}
+
+// metaTransport stores the metadata of responses to requests with a context
+// created by WithResponseMeta.
+type metaTransport struct {
+	parent http.RoundTripper
+}
+
+func (t *metaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.parent.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if meta, ok := req.Context().Value(responseMetaKey{}).(*ResponseMeta); ok && meta != nil {
+		meta.StatusCode = res.StatusCode
+		meta.Header = res.Header.Clone()
+	}
+	return res, nil
+}
+
 func (c *Client) sendRequest(req *http.Request, v interface{}) error {
 	var errRes ErrorResponse
 	hasCustomError, err := c.sendCustomRequest(req, v, &errRes)
@@ -88,24 +584,54 @@ func (c *Client) sendRequest(req *http.Request, v interface{}) error {
 	return nil
 }
 
+// setErrorStatus sets the status code of a decoded error response whose body
+// did not include it, e.g. in the legacy GoTrue error format.
+func setErrorStatus(errorValue interface{}, status int) {
+	switch err := errorValue.(type) {
+	case *AuthError:
+		if err.StatusCode == 0 {
+			err.StatusCode = status
+		}
+	case *ErrorResponse:
+		if err.Code == 0 {
+			err.Code = status
+		}
+	case *FileErrorResponse:
+		if err.Status == "" {
+			err.Status = strconv.Itoa(status)
+		}
+	}
+}
+
 func (c *Client) sendCustomRequest(req *http.Request, successValue interface{}, errorValue interface{}) (bool, error) {
-	req.Header.Set("apikey", c.apiKey)
+	c.injectAPIKey(req)
 	res, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return true, err
 	}
 
 	defer res.Body.Close()
+	body, err := c.readBody(res.Body)
+	if err != nil {
+		return false, err
+	}
+
 	statusOK := res.StatusCode >= http.StatusOK && res.StatusCode < 300
 	if !statusOK {
-		if err = json.NewDecoder(res.Body).Decode(&errorValue); err == nil {
-			return true, nil
+		if isJSONResponse(res) {
+			if err = c.unmarshalJSON(body, &errorValue); err == nil {
+				setErrorStatus(errorValue, res.StatusCode)
+				return true, nil
+			}
 		}
 
-		return false, fmt.Errorf("unknown, status code: %d", res.StatusCode)
-	} else if res.StatusCode != http.StatusNoContent {
-		if err = json.NewDecoder(res.Body).Decode(&successValue); err != nil {
-			return false, err
+		return false, newHTTPError(res, body)
+	} else if res.StatusCode != http.StatusNoContent && len(body) > 0 {
+		if err = c.unmarshalJSON(body, &successValue); err != nil {
+			if !isJSONResponse(res) {
+				return false, newHTTPError(res, body)
+			}
+			return false, &DecodeError{Err: err}
 		}
 	}
 
@@ -0,0 +1,46 @@
+package supabase
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithReadYourWrites_User(t *testing.T) {
+	var primaryHits, replicaHits atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHits.Add(1)
+		w.Write([]byte(`[]`))
+	}))
+	defer primary.Close()
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		replicaHits.Add(1)
+		w.Write([]byte(`[]`))
+	}))
+	defer replica.Close()
+
+	client := NewClient(primary.URL, "key", WithReadReplicas(replica.URL), WithReadYourWrites(time.Minute))
+	read := func() {
+		if err := client.DB.From("posts").Select("*").UseReplica().Execute(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client.SetAccessToken("alice-token")
+	if err := client.DB.From("posts").Insert(map[string]string{"title": "x"}).Execute(nil); err != nil {
+		t.Fatal(err)
+	}
+	read()
+	if got := primaryHits.Load(); got != 2 {
+		t.Fatalf("expected the read after the write to go to the primary, got %d primary hits", got)
+	}
+
+	// the pin of alice does not apply to another user of the client
+	client.SetAccessToken("bob-token")
+	read()
+	if got := replicaHits.Load(); got != 1 {
+		t.Errorf("expected the read of another user to go to the replica, got %d replica hits", got)
+	}
+}
@@ -0,0 +1,535 @@
+package supabasetest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	supabase "github.com/nedpals/supabase-go"
+)
+
+// StorageServer is an in-memory implementation of the Storage API, so the
+// storage methods of a client can be tested without a Supabase stack. It
+// supports buckets, uploads, downloads with ranges, listings, removal, moves,
+// copies and signed URLs; policies and image transformations are not
+// implemented, every request is authorized.
+type StorageServer struct {
+	*httptest.Server
+
+	t       testing.TB
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+	// signed maps the tokens of signed URLs to their object and expiry
+	signed map[string]signedObject
+}
+
+type memoryBucket struct {
+	id        string
+	public    bool
+	createdAt time.Time
+	objects   map[string]*memoryObject
+}
+
+type memoryObject struct {
+	id           string
+	data         []byte
+	contentType  string
+	cacheControl string
+	userMetadata map[string]interface{}
+	createdAt    time.Time
+	updatedAt    time.Time
+}
+
+type signedObject struct {
+	bucket    string
+	path      string
+	expiresAt time.Time
+}
+
+// NewStorageServer starts an in-memory Storage API, closed when the test ends.
+func NewStorageServer(t testing.TB) *StorageServer {
+	s := &StorageServer{
+		t:       t,
+		buckets: map[string]*memoryBucket{},
+		signed:  map[string]signedObject{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Client returns a client whose storage requests are sent to the server. The
+// client runs with supabase.WithLeakCheck, so the test fails if a response
+// body is left open, and is closed when the test ends.
+func (s *StorageServer) Client(opts ...supabase.ClientOption) *supabase.Client {
+	opts = append([]supabase.ClientOption{
+		supabase.WithServiceURLs(supabase.ServiceURLs{Storage: s.URL}),
+		supabase.WithLeakCheck(s.t),
+	}, opts...)
+	return supabase.NewClient(s.URL, "test-key", opts...)
+}
+
+// Object returns the content of an object, false if it does not exist.
+func (s *StorageServer) Object(bucket, path string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return nil, false
+	}
+	object, ok := b.objects[path]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), object.data...), true
+}
+
+func (s *StorageServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	switch {
+	case path == "bucket" || path == "bucket/":
+		switch r.Method {
+		case http.MethodGet:
+			s.listBuckets(w)
+		case http.MethodPost:
+			s.createBucket(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case strings.HasPrefix(path, "bucket/"):
+		id := strings.TrimPrefix(path, "bucket/")
+		if id, ok := strings.CutSuffix(id, "/empty"); ok && r.Method == http.MethodPost {
+			s.emptyBucket(w, id)
+			return
+		}
+		s.bucket(w, r, id)
+	case path == "object/move" || path == "object/copy":
+		s.moveOrCopy(w, r, path == "object/copy")
+	case strings.HasPrefix(path, "object/list/"):
+		s.list(w, r, strings.TrimPrefix(path, "object/list/"))
+	case strings.HasPrefix(path, "object/sign/"):
+		bucket, object, _ := strings.Cut(strings.TrimPrefix(path, "object/sign/"), "/")
+		if r.Method == http.MethodPost {
+			s.sign(w, r, bucket, object)
+		} else {
+			s.serveSigned(w, r, bucket, object)
+		}
+	case strings.HasPrefix(path, "object/public/"):
+		bucket, object, _ := strings.Cut(strings.TrimPrefix(path, "object/public/"), "/")
+		s.serveObject(w, r, bucket, object, true)
+	case strings.HasPrefix(path, "object/authenticated/"):
+		bucket, object, _ := strings.Cut(strings.TrimPrefix(path, "object/authenticated/"), "/")
+		s.serveObject(w, r, bucket, object, false)
+	case strings.HasPrefix(path, "object/"):
+		bucket, object, _ := strings.Cut(strings.TrimPrefix(path, "object/"), "/")
+		switch r.Method {
+		case http.MethodPost, http.MethodPut:
+			s.upload(w, r, bucket, object)
+		case http.MethodDelete:
+			s.remove(w, r, bucket)
+		default:
+			s.serveObject(w, r, bucket, object, false)
+		}
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *StorageServer) listBuckets(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buckets := make([]map[string]interface{}, 0, len(s.buckets))
+	for _, b := range s.buckets {
+		buckets = append(buckets, b.response())
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i]["id"].(string) < buckets[j]["id"].(string) })
+	writeJSON(w, http.StatusOK, buckets)
+}
+
+func (s *StorageServer) createBucket(w http.ResponseWriter, r *http.Request) {
+	var option supabase.BucketOption
+	if err := json.NewDecoder(r.Body).Decode(&option); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	id := option.Id
+	if id == "" {
+		id = option.Name
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.buckets[id]; ok {
+		writeError(w, http.StatusConflict, "The resource already exists")
+		return
+	}
+	s.buckets[id] = &memoryBucket{id: id, public: option.Public, createdAt: time.Now(), objects: map[string]*memoryObject{}}
+	writeJSON(w, http.StatusOK, map[string]string{"name": id})
+}
+
+func (s *StorageServer) bucket(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Bucket not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, b.response())
+	case http.MethodPut:
+		var option supabase.BucketOption
+		if err := json.NewDecoder(r.Body).Decode(&option); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		b.public = option.Public
+		writeJSON(w, http.StatusOK, map[string]string{"message": "Successfully updated"})
+	case http.MethodDelete:
+		if len(b.objects) > 0 {
+			writeError(w, http.StatusConflict, "The bucket you tried to delete is not empty")
+			return
+		}
+		delete(s.buckets, id)
+		writeJSON(w, http.StatusOK, map[string]string{"message": "Successfully deleted"})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *StorageServer) emptyBucket(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Bucket not found")
+		return
+	}
+	b.objects = map[string]*memoryObject{}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Successfully emptied"})
+}
+
+func (s *StorageServer) upload(w http.ResponseWriter, r *http.Request, bucket, path string) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var userMetadata map[string]interface{}
+	if header := r.Header.Get("x-metadata"); header != "" {
+		decoded, err := base64.StdEncoding.DecodeString(header)
+		if err == nil {
+			err = json.Unmarshal(decoded, &userMetadata)
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid metadata")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucket]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Bucket not found")
+		return
+	}
+
+	existing, exists := b.objects[path]
+	switch {
+	case r.Method == http.MethodPut && !exists:
+		writeError(w, http.StatusNotFound, "Object not found")
+		return
+	case r.Method == http.MethodPost && exists && r.Header.Get("x-upsert") != "true":
+		writeError(w, http.StatusConflict, "The resource already exists")
+		return
+	}
+
+	// like Storage, the cache duration in seconds is served as max-age
+	cacheControl := r.Header.Get("Cache-Control")
+	if _, err := strconv.Atoi(cacheControl); err == nil {
+		cacheControl = "max-age=" + cacheControl
+	}
+
+	now := time.Now()
+	object := &memoryObject{
+		id:           newID(),
+		data:         data,
+		contentType:  r.Header.Get("Content-Type"),
+		cacheControl: cacheControl,
+		userMetadata: userMetadata,
+		createdAt:    now,
+		updatedAt:    now,
+	}
+	if exists {
+		object.id, object.createdAt = existing.id, existing.createdAt
+	}
+	b.objects[path] = object
+	writeJSON(w, http.StatusOK, map[string]string{"key": bucket + "/" + path, "id": object.id})
+}
+
+func (s *StorageServer) remove(w http.ResponseWriter, r *http.Request, bucket string) {
+	var body struct {
+		Prefixes []string `json:"prefixes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucket]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Bucket not found")
+		return
+	}
+	removed := []map[string]interface{}{}
+	for _, path := range body.Prefixes {
+		if object, ok := b.objects[path]; ok {
+			removed = append(removed, object.response(bucket, path))
+			delete(b.objects, path)
+		}
+	}
+	writeJSON(w, http.StatusOK, removed)
+}
+
+func (s *StorageServer) list(w http.ResponseWriter, r *http.Request, bucket string) {
+	var body supabase.ListFileRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucket]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Bucket not found")
+		return
+	}
+
+	prefix := strings.Trim(body.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	folders := map[string]bool{}
+	entries := []map[string]interface{}{}
+	for path, object := range b.objects {
+		name, ok := strings.CutPrefix(path, prefix)
+		if !ok {
+			continue
+		}
+		if folder, _, nested := strings.Cut(name, "/"); nested {
+			if !folders[folder] {
+				folders[folder] = true
+				entries = append(entries, map[string]interface{}{"name": folder, "id": nil, "metadata": nil})
+			}
+			continue
+		}
+		entry := object.response(bucket, path)
+		entry["name"] = name
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		less := entries[i]["name"].(string) < entries[j]["name"].(string)
+		if strings.EqualFold(body.SortBy.Order, "desc") {
+			return !less
+		}
+		return less
+	})
+	start, end := body.Offset, body.Offset+body.Limit
+	if start > len(entries) {
+		start = len(entries)
+	}
+	if body.Limit <= 0 || end > len(entries) {
+		end = len(entries)
+	}
+	writeJSON(w, http.StatusOK, entries[start:end])
+}
+
+func (s *StorageServer) moveOrCopy(w http.ResponseWriter, r *http.Request, copyObject bool) {
+	var body struct {
+		BucketID          string                 `json:"bucketId"`
+		SourceKey         string                 `json:"sourceKey"`
+		DestinationKey    string                 `json:"destinationKey"`
+		DestinationBucket string                 `json:"destinationBucket"`
+		Metadata          map[string]interface{} `json:"metadata"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if body.DestinationBucket == "" {
+		body.DestinationBucket = body.BucketID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	source, ok := s.buckets[body.BucketID]
+	destination, destinationOK := s.buckets[body.DestinationBucket]
+	if !ok || !destinationOK {
+		writeError(w, http.StatusNotFound, "Bucket not found")
+		return
+	}
+	object, ok := source.objects[body.SourceKey]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Object not found")
+		return
+	}
+	samePath := body.BucketID == body.DestinationBucket && body.SourceKey == body.DestinationKey
+	if _, exists := destination.objects[body.DestinationKey]; exists && !samePath && r.Header.Get("x-upsert") != "true" {
+		writeError(w, http.StatusConflict, "The resource already exists")
+		return
+	}
+
+	moved := *object
+	moved.updatedAt = time.Now()
+	if copyObject && !samePath {
+		moved.id = newID()
+		moved.createdAt = moved.updatedAt
+	}
+	if body.Metadata != nil {
+		moved.userMetadata = body.Metadata
+	}
+	if !copyObject {
+		delete(source.objects, body.SourceKey)
+	}
+	destination.objects[body.DestinationKey] = &moved
+
+	if copyObject {
+		writeJSON(w, http.StatusOK, map[string]string{"key": body.DestinationBucket + "/" + body.DestinationKey, "id": moved.id})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Successfully moved"})
+}
+
+func (s *StorageServer) sign(w http.ResponseWriter, r *http.Request, bucket, path string) {
+	var body struct {
+		ExpiresIn int `json:"expiresIn"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ExpiresIn <= 0 {
+		writeError(w, http.StatusBadRequest, "expiresIn must be a positive number of seconds")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lookup(bucket, path); !ok {
+		writeError(w, http.StatusNotFound, "Object not found")
+		return
+	}
+	token := newID()
+	s.signed[token] = signedObject{bucket: bucket, path: path, expiresAt: time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)}
+	writeJSON(w, http.StatusOK, map[string]string{"signedURL": "/object/sign/" + bucket + "/" + path + "?token=" + token})
+}
+
+func (s *StorageServer) serveSigned(w http.ResponseWriter, r *http.Request, bucket, path string) {
+	s.mu.Lock()
+	signed, ok := s.signed[r.URL.Query().Get("token")]
+	s.mu.Unlock()
+	if !ok || signed.bucket != bucket || signed.path != path || time.Now().After(signed.expiresAt) {
+		writeError(w, http.StatusBadRequest, "invalid or expired signature")
+		return
+	}
+	s.serveObject(w, r, bucket, path, false)
+}
+
+func (s *StorageServer) serveObject(w http.ResponseWriter, r *http.Request, bucket, path string, public bool) {
+	s.mu.Lock()
+	object, ok := s.lookup(bucket, path)
+	if ok && public && !s.buckets[bucket].public {
+		ok = false
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "Object not found")
+		return
+	}
+
+	if object.contentType != "" {
+		w.Header().Set("Content-Type", object.contentType)
+	}
+	if object.cacheControl != "" {
+		w.Header().Set("Cache-Control", object.cacheControl)
+	}
+	w.Header().Set("ETag", `"`+object.id+`"`)
+	http.ServeContent(w, r, path, object.updatedAt, bytes.NewReader(object.data))
+}
+
+// lookup returns an object, the caller must hold the lock.
+func (s *StorageServer) lookup(bucket, path string) (*memoryObject, bool) {
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return nil, false
+	}
+	object, ok := b.objects[path]
+	return object, ok
+}
+
+func (b *memoryBucket) response() map[string]interface{} {
+	created := b.createdAt.UTC().Format(time.RFC3339)
+	return map[string]interface{}{
+		"id":         b.id,
+		"name":       b.id,
+		"owner":      "",
+		"public":     b.public,
+		"created_at": created,
+		"updated_at": created,
+	}
+}
+
+func (o *memoryObject) response(bucket, path string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":          path,
+		"bucket_id":     bucket,
+		"id":            o.id,
+		"created_at":    o.createdAt.UTC().Format(time.RFC3339),
+		"updated_at":    o.updatedAt.UTC().Format(time.RFC3339),
+		"user_metadata": o.userMetadata,
+		"metadata": map[string]interface{}{
+			"size":         len(o.data),
+			"mimetype":     o.contentType,
+			"cacheControl": o.cacheControl,
+		},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the format of the Storage API.
+func writeError(w http.ResponseWriter, status int, message string) {
+	shortError := http.StatusText(status)
+	if status == http.StatusConflict {
+		shortError = "Duplicate"
+	}
+	writeJSON(w, status, map[string]string{
+		"statusCode": strconv.Itoa(status),
+		"error":      shortError,
+		"message":    message,
+	})
+}
+
+func newID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,189 @@
+package supabasetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	supabase "github.com/nedpals/supabase-go"
+)
+
+func TestStorageServer(t *testing.T) {
+	server := NewStorageServer(t)
+	client := server.Client()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if _, err := client.Storage.CreateBucket(ctx, supabase.BucketOption{Id: "avatars", Name: "avatars"}); err != nil {
+		t.Fatalf("create bucket: %v", err)
+	}
+	buckets, err := client.Storage.ListBuckets(ctx)
+	if err != nil || len(buckets) != 1 || buckets[0].Id != "avatars" {
+		t.Fatalf("expected the avatars bucket, got %v %v", buckets, err)
+	}
+
+	files := client.Storage.From("avatars")
+	res := files.Upload("users/alice.png", strings.NewReader("alice"), &supabase.FileUploadOptions{ContentType: "image/png"})
+	if res.Key != "avatars/users/alice.png" {
+		t.Fatalf("expected the key of the uploaded object, got %+v", res)
+	}
+	files.Upload("users/bob.png", strings.NewReader("bob"), nil)
+	files.Upload("readme.txt", strings.NewReader("readme"), nil)
+
+	data, err := files.Download("users/alice.png")
+	if err != nil || string(data) != "alice" {
+		t.Fatalf("expected the uploaded content, got %q %v", data, err)
+	}
+
+	objects, err := files.List(ctx, "users", nil)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var names []string
+	for _, object := range objects {
+		names = append(names, object.Name)
+	}
+	if strings.Join(names, ",") != "alice.png,bob.png" {
+		t.Errorf("expected the objects of the users folder, got %v", names)
+	}
+	root, err := files.List(ctx, "", nil)
+	if err != nil || len(root) != 2 || root[0].Name != "readme.txt" || root[1].Name != "users" {
+		t.Errorf("expected readme.txt and the users folder, got %+v %v", root, err)
+	}
+
+	signed, err := files.CreateSignedUrl("users/alice.png", 60)
+	if err != nil || signed.Token == "" || !strings.HasPrefix(signed.SignedUrl, server.URL+"/object/sign/avatars/users/alice.png?token=") {
+		t.Fatalf("expected a signed URL, got %+v %v", signed, err)
+	}
+	if body := get(ctx, t, client, signed.SignedUrl); body != "alice" {
+		t.Errorf("expected the signed URL to serve the object, got %q", body)
+	}
+
+	if _, err := files.Move("users/bob.png", "users/robert.png", nil); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if _, ok := server.Object("avatars", "users/bob.png"); ok {
+		t.Errorf("expected the moved object to be gone")
+	}
+	if data, ok := server.Object("avatars", "users/robert.png"); !ok || string(data) != "bob" {
+		t.Errorf("expected the object at its new path, got %q", data)
+	}
+
+	files.Remove([]string{"users/alice.png", "readme.txt"})
+	if _, ok := server.Object("avatars", "users/alice.png"); ok {
+		t.Errorf("expected the removed object to be gone")
+	}
+	if _, ok := server.Object("avatars", "users/robert.png"); !ok {
+		t.Errorf("expected the other object to be kept")
+	}
+}
+
+func TestStorageServer_Errors(t *testing.T) {
+	server := NewStorageServer(t)
+	client := server.Client()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if _, err := client.Storage.CreateBucket(ctx, supabase.BucketOption{Id: "docs", Name: "docs"}); err != nil {
+		t.Fatalf("create bucket: %v", err)
+	}
+	_, err := client.Storage.CreateBucket(ctx, supabase.BucketOption{Id: "docs", Name: "docs"})
+	var fileErr *supabase.FileErrorResponse
+	if !errors.As(err, &fileErr) || fileErr.HTTPStatus() != http.StatusConflict || fileErr.ShortError != "Duplicate" {
+		t.Errorf("expected a 409 Duplicate error creating an existing bucket, got %v", err)
+	}
+	if _, err := client.Storage.GetBucket(ctx, "missing"); !errors.As(err, &fileErr) || fileErr.HTTPStatus() != http.StatusNotFound {
+		t.Errorf("expected a 404 error getting a missing bucket, got %v", err)
+	}
+
+	files := client.Storage.From("docs")
+	files.Upload("a.txt", strings.NewReader("a"), nil)
+	files.Upload("b.txt", strings.NewReader("b"), nil)
+
+	// an upload without upsert keeps the existing object
+	files.Upload("a.txt", strings.NewReader("replaced"), nil)
+	if data, _ := server.Object("docs", "a.txt"); !bytes.Equal(data, []byte("a")) {
+		t.Errorf("expected the existing object to be kept, got %q", data)
+	}
+
+	_, err = files.Copy("a.txt", "b.txt", nil)
+	if !errors.Is(err, supabase.ErrObjectAlreadyExists) || !errors.As(err, &fileErr) || fileErr.HTTPStatus() != http.StatusConflict {
+		t.Errorf("expected ErrObjectAlreadyExists copying onto an existing object, got %v", err)
+	}
+	if _, err := files.Copy("a.txt", "b.txt", &supabase.FileMoveOptions{Overwrite: true}); err != nil {
+		t.Errorf("expected the copy to overwrite the object, got %v", err)
+	}
+
+	if _, err := files.Download("missing.txt"); !errors.Is(err, supabase.ErrNotFound) || !errors.As(err, &fileErr) || fileErr.HTTPStatus() != http.StatusNotFound {
+		t.Errorf("expected ErrNotFound downloading a missing object, got %v", err)
+	}
+	if _, err := files.Move("missing.txt", "c.txt", nil); !errors.Is(err, supabase.ErrNotFound) {
+		t.Errorf("expected ErrNotFound moving a missing object, got %v", err)
+	}
+	if _, err := files.CreateSignedUrl("missing.txt", 60); !errors.Is(err, supabase.ErrNotFound) {
+		t.Errorf("expected ErrNotFound signing a missing object, got %v", err)
+	}
+}
+
+func TestStorageServer_ContextMethods(t *testing.T) {
+	server := NewStorageServer(t)
+	client := server.Client()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if _, err := client.Storage.CreateBucket(ctx, supabase.BucketOption{Id: "docs", Name: "docs"}); err != nil {
+		t.Fatalf("create bucket: %v", err)
+	}
+	files := client.Storage.From("docs")
+
+	res, err := files.UploadWithContext(ctx, "a.txt", strings.NewReader("a"), nil)
+	if err != nil || res.Key != "docs/a.txt" {
+		t.Fatalf("expected the upload to succeed, got %v %v", res, err)
+	}
+	if _, err := files.UploadWithContext(ctx, "a.txt", strings.NewReader("b"), nil); !errors.Is(err, supabase.ErrObjectAlreadyExists) {
+		t.Errorf("expected ErrObjectAlreadyExists uploading an existing object, got %v", err)
+	}
+	if _, err := files.UpdateWithContext(ctx, "a.txt", strings.NewReader("updated"), nil); err != nil {
+		t.Errorf("expected the update to succeed, got %v", err)
+	}
+	if data, _ := server.Object("docs", "a.txt"); string(data) != "updated" {
+		t.Errorf("expected the updated object, got %q", data)
+	}
+	if _, err := files.UpdateWithContext(ctx, "missing.txt", strings.NewReader("b"), nil); !errors.Is(err, supabase.ErrNotFound) {
+		t.Errorf("expected ErrNotFound updating a missing object, got %v", err)
+	}
+
+	if _, err := files.RemoveWithContext(ctx, []string{"a.txt"}); err != nil {
+		t.Errorf("expected the removal to succeed, got %v", err)
+	}
+	if _, ok := server.Object("docs", "a.txt"); ok {
+		t.Error("expected the object to be removed")
+	}
+	if _, err := client.Storage.From("missing").RemoveWithContext(ctx, []string{"a.txt"}); !errors.Is(err, supabase.ErrNotFound) {
+		t.Errorf("expected ErrNotFound removing from a missing bucket, got %v", err)
+	}
+}
+
+// get fetches a URL with the client and returns the body.
+func get(ctx context.Context, t *testing.T, client *supabase.Client, url string) string {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := client.HTTPClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
@@ -0,0 +1,104 @@
+package supabase
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultRequestTimeout is the timeout of the requests sent with Client.HTTPClient.
+const defaultRequestTimeout = time.Minute
+
+// ServiceTimeouts sets the timeout of the requests to each service. A zero
+// timeout leaves the requests of a service bounded by their context only.
+type ServiceTimeouts struct {
+	// Auth applies to auth and admin requests
+	Auth time.Duration
+	// DB applies to DB queries and GraphQL requests
+	DB time.Duration
+	// Storage applies to uploads and downloads, which may need much longer than other requests
+	Storage time.Duration
+	// Functions applies to function invocations, but not to streamed responses
+	Functions time.Duration
+}
+
+// WithServiceTimeouts replaces the single timeout of HTTPClient, a minute for
+// auth and function calls, with a timeout per service, e.g. a short one for
+// sign in and a long one for uploads. The timeout covers the whole request,
+// including reading the response body. Requests are attributed to a service
+// by their URL, so with WithSelfHosted each service needs its own ServiceURLs.
+func WithServiceTimeouts(timeouts ServiceTimeouts) ClientOption {
+	return func(c *Client) {
+		c.serviceTimeouts = &timeouts
+	}
+}
+
+type noServiceTimeoutKey struct{}
+
+// withoutServiceTimeout returns a context exempting a request from the service
+// timeouts, for responses streamed for as long as the context allows.
+func withoutServiceTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noServiceTimeoutKey{}, true)
+}
+
+// timeoutTransport applies the timeouts of WithServiceTimeouts.
+type timeoutTransport struct {
+	client *Client
+	parent http.RoundTripper
+}
+
+func (t *timeoutTransport) timeout(req *http.Request) time.Duration {
+	if exempt, _ := req.Context().Value(noServiceTimeoutKey{}).(bool); exempt {
+		return 0
+	}
+
+	timeouts := t.client.serviceTimeouts
+	switch Service(t.client.serviceOf(req)) {
+	case ServiceAuth:
+		return timeouts.Auth
+	case ServiceRest, ServiceGraphQL, "rest-replica":
+		return timeouts.DB
+	case ServiceStorage:
+		return timeouts.Storage
+	case ServiceFunctions:
+		return timeouts.Functions
+	}
+	return 0
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timeout := t.timeout(req)
+	if timeout <= 0 {
+		return t.parent.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	res, err := t.parent.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = &cancelOnCloseBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// cancelOnCloseBody releases the timeout of a request once its body is read or closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.cancel()
+	}
+	return n, err
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,131 @@
+package supabase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrWebhookExpired   = errors.New("webhook timestamp outside of the tolerance")
+)
+
+// WebhookTolerance is the maximum age of a signed webhook delivery.
+const WebhookTolerance = 5 * time.Minute
+
+// maxWebhookBodyBytes is the maximum size of a webhook body read by ReadWebhook.
+const maxWebhookBodyBytes = 1 << 20
+
+// WebhookType is the operation which triggered a database webhook.
+type WebhookType string
+
+const (
+	WebhookInsert WebhookType = "INSERT"
+	WebhookUpdate WebhookType = "UPDATE"
+	WebhookDelete WebhookType = "DELETE"
+)
+
+// DatabaseWebhookPayload is the payload of a database webhook. Record is nil
+// for deletes and OldRecord is nil for inserts.
+type DatabaseWebhookPayload[T any] struct {
+	Type      WebhookType `json:"type"`
+	Table     string      `json:"table"`
+	Schema    string      `json:"schema"`
+	Record    *T          `json:"record"`
+	OldRecord *T          `json:"old_record"`
+}
+
+// ParseDatabaseWebhook decodes the body of a database webhook, decoding the
+// records into T.
+func ParseDatabaseWebhook[T any](body []byte) (*DatabaseWebhookPayload[T], error) {
+	var payload DatabaseWebhookPayload[T]
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	switch payload.Type {
+	case WebhookInsert, WebhookUpdate, WebhookDelete:
+	default:
+		return nil, fmt.Errorf("unknown webhook type %q", payload.Type)
+	}
+	return &payload, nil
+}
+
+// VerifyWebhookSignature verifies a delivery signed following the Standard
+// Webhooks specification, as sent to auth hooks, with the webhook-id,
+// webhook-timestamp and webhook-signature headers. The secret is given as
+// shown in the dashboard, e.g. "v1,whsec_...".
+func VerifyWebhookSignature(secret string, header http.Header, body []byte) error {
+	id := header.Get("webhook-id")
+	timestamp := header.Get("webhook-timestamp")
+	signatures := header.Get("webhook-signature")
+	if id == "" || timestamp == "" || signatures == "" {
+		return ErrInvalidSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > WebhookTolerance || age < -WebhookTolerance {
+		return ErrWebhookExpired
+	}
+
+	secret = strings.TrimPrefix(secret, "v1,")
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	if err != nil {
+		return fmt.Errorf("invalid webhook secret: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	// the header may list several signatures while the secret is rotated
+	for _, signature := range strings.Fields(signatures) {
+		version, value, _ := strings.Cut(signature, ",")
+		if version != "v1" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// VerifyWebhookSecret checks a shared secret sent in the given header, as
+// configured in the HTTP headers of a database webhook.
+func VerifyWebhookSecret(header http.Header, name string, secret string) error {
+	value := header.Get(name)
+	if value == "" || subtle.ConstantTimeCompare([]byte(value), []byte(secret)) != 1 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ReadWebhook reads the body of a webhook delivery signed with the Standard
+// Webhooks scheme and verifies its signature, see VerifyWebhookSignature.
+func ReadWebhook(r *http.Request, secret string) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxWebhookBodyBytes {
+		return nil, fmt.Errorf("webhook body exceeds the limit of %d bytes", maxWebhookBodyBytes)
+	}
+	if err := VerifyWebhookSignature(secret, r.Header, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,160 @@
+package supabase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testWebhookKey    = []byte("webhook-signing-key")
+	testWebhookSecret = "v1,whsec_" + base64.StdEncoding.EncodeToString(testWebhookKey)
+)
+
+// signWebhook returns the v1 signature of a delivery signed with key.
+func signWebhook(key []byte, id string, timestamp int64, body string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + strconv.FormatInt(timestamp, 10) + "." + body))
+	return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func webhookHeader(id string, timestamp int64, signature string) http.Header {
+	header := http.Header{}
+	if id != "" {
+		header.Set("webhook-id", id)
+	}
+	if timestamp != 0 {
+		header.Set("webhook-timestamp", strconv.FormatInt(timestamp, 10))
+	}
+	if signature != "" {
+		header.Set("webhook-signature", signature)
+	}
+	return header
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	const id = "msg_2KWPBgLlAfxdpx2AI54pPJ85f4W"
+	const body = `{"user":{"id":"user-1"}}`
+	now := time.Now().Unix()
+	valid := signWebhook(testWebhookKey, id, now, body)
+	past := now - int64(WebhookTolerance/time.Second) - 60
+	future := now + int64(WebhookTolerance/time.Second) + 60
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  http.Header
+		body    string
+		wantErr error
+	}{
+		{name: "valid", header: webhookHeader(id, now, valid)},
+		{
+			name:   "secret without prefix",
+			secret: base64.StdEncoding.EncodeToString(testWebhookKey),
+			header: webhookHeader(id, now, valid),
+		},
+		{
+			name:    "wrong secret",
+			secret:  "v1,whsec_" + base64.StdEncoding.EncodeToString([]byte("another-key")),
+			header:  webhookHeader(id, now, valid),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "tampered body",
+			header:  webhookHeader(id, now, valid),
+			body:    `{"user":{"id":"user-2"}}`,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "tampered id",
+			header:  webhookHeader("msg_other", now, valid),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "timestamp too old",
+			header:  webhookHeader(id, past, signWebhook(testWebhookKey, id, past, body)),
+			wantErr: ErrWebhookExpired,
+		},
+		{
+			name:    "timestamp in the future",
+			header:  webhookHeader(id, future, signWebhook(testWebhookKey, id, future, body)),
+			wantErr: ErrWebhookExpired,
+		},
+		{
+			name:   "several signatures",
+			header: webhookHeader(id, now, signWebhook([]byte("old-key"), id, now, body)+" "+valid),
+		},
+		{
+			name:    "several signatures none valid",
+			header:  webhookHeader(id, now, signWebhook([]byte("old-key"), id, now, body)+" "+signWebhook([]byte("other-key"), id, now, body)),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "unknown version",
+			header:  webhookHeader(id, now, "v1a,"+strings.TrimPrefix(valid, "v1,")),
+			wantErr: ErrInvalidSignature,
+		},
+		{name: "missing id", header: webhookHeader("", now, valid), wantErr: ErrInvalidSignature},
+		{name: "missing timestamp", header: webhookHeader(id, 0, valid), wantErr: ErrInvalidSignature},
+		{name: "missing signature", header: webhookHeader(id, now, ""), wantErr: ErrInvalidSignature},
+		{
+			name:    "timestamp not a number",
+			header:  http.Header{"Webhook-Id": {id}, "Webhook-Timestamp": {"yesterday"}, "Webhook-Signature": {valid}},
+			wantErr: ErrInvalidSignature,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := tt.secret
+			if secret == "" {
+				secret = testWebhookSecret
+			}
+			payload := tt.body
+			if payload == "" {
+				payload = body
+			}
+			err := VerifyWebhookSignature(secret, tt.header, []byte(payload))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyWebhookSignature() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyWebhookSignature_InvalidSecret(t *testing.T) {
+	now := time.Now().Unix()
+	header := webhookHeader("msg_1", now, signWebhook(testWebhookKey, "msg_1", now, "{}"))
+
+	err := VerifyWebhookSignature("v1,whsec_not base64!", header, []byte("{}"))
+	if err == nil || errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("VerifyWebhookSignature() error = %v, want an invalid secret error", err)
+	}
+}
+
+func TestReadWebhook(t *testing.T) {
+	const body = `{"type":"INSERT","table":"posts","schema":"public","record":{"id":1},"old_record":null}`
+	now := time.Now().Unix()
+
+	req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+	req.Header = webhookHeader("msg_1", now, signWebhook(testWebhookKey, "msg_1", now, body))
+	got, err := ReadWebhook(req, testWebhookSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != body {
+		t.Errorf("ReadWebhook() = %s, want %s", got, body)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body+" "))
+	req.Header = webhookHeader("msg_1", now, signWebhook(testWebhookKey, "msg_1", now, body))
+	if _, err := ReadWebhook(req, testWebhookSecret); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("ReadWebhook() of a tampered body error = %v, want %v", err, ErrInvalidSignature)
+	}
+}